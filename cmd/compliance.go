@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MChorfa/TraceSync/internal/compliance"
+	"github.com/spf13/cobra"
+)
+
+var complianceCmd = &cobra.Command{
+	Use:   "compliance <artifact>",
+	Short: "Run the compliance check on an artifact",
+	Long: `This command runs the compliance checks of an upload without uploading: the policy
+rules, dependency pinning, the license policy and the vulnerability database.
+
+The report is printed as text by default; --report-format selects JSON, JUnit XML
+(a test case per rule, for CI test reports) or SARIF (for code scanning dashboards),
+and --report-file writes it to a file. The command exits non-zero when an issue
+fails the check.
+
+The checks use the artifact's imported SBOM if it has one, otherwise the SBOM last
+written next to it by sbom generate or upload, or else one built from its current
+content. --sbom imports a supplied CycloneDX or SPDX SBOM first, as sbom import does,
+so that a vendor SBOM is checked the same way as a generated one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if path, _ := cmd.Flags().GetString("sbom"); path != "" {
+			if err := importSBOM(os.Stderr, args[0], path); err != nil {
+				os.Exit(1)
+			}
+		}
+		report, err := compliance.CheckCompliance(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Compliance check failed: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeComplianceReport(cmd, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(1)
+		}
+		if !report.Passed() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(complianceCmd)
+	addReportFlags(complianceCmd)
+	complianceCmd.Flags().String("sbom", "", "Import this CycloneDX or SPDX SBOM for the artifact before checking it")
+}
+
+// addReportFlags adds the flags selecting how compliance reports are
+// written to a command.
+func addReportFlags(cmd *cobra.Command) {
+	cmd.Flags().String("report-format", string(compliance.ReportText), fmt.Sprintf("Compliance report format %v", compliance.ReportFormats))
+	cmd.Flags().String("report-file", "", "Write the compliance report to a file (the text summary is still printed)")
+}
+
+// writeComplianceReport writes a compliance report as selected with
+// --report-format and --report-file. With a report file, the text summary
+// is printed as well.
+func writeComplianceReport(cmd *cobra.Command, report *compliance.Report) error {
+	name, _ := cmd.Flags().GetString("report-format")
+	format, err := compliance.ParseReportFormat(name)
+	if err != nil {
+		return err
+	}
+	path, _ := cmd.Flags().GetString("report-file")
+	if path == "" {
+		return report.Write(os.Stdout, format)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	if err := report.Write(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+	if err := report.Write(os.Stdout, compliance.ReportText); err != nil {
+		return err
+	}
+	fmt.Printf("Compliance report saved to: %s\n", path)
+	return nil
+}
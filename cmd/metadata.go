@@ -2,35 +2,146 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/registry"
 	"github.com/spf13/cobra"
 )
 
 var metadataCmd = &cobra.Command{
 	Use:   "metadata <artifact>",
 	Short: "Manage metadata tagging for artifacts",
-	Long:  `This command allows you to add metadata tags to artifacts for classification and easier retrieval.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `This command displays and manages the metadata of an artifact: it adds and removes tags,
+loads tags from a YAML or JSON file, and sets core fields such as name, version and type.
+The resulting metadata is printed as a table, YAML or JSON.
+
+The size and digests of the artifact are recorded when it is first tagged and whenever
+its version changes. Other edits fail if the artifact no longer matches them; pass
+--retag to record the current content under the same version instead.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		artifact := args[0]
-		fmt.Printf("Managing metadata for artifact: %s\n", artifact)
+		output, _ := cmd.Flags().GetString("output")
+
+		// Keep stdout parseable when printing YAML or JSON
+		progress := os.Stdout
+		if output != "table" {
+			progress = os.Stderr
+		}
+		fmt.Fprintf(progress, "Managing metadata for artifact: %s\n", artifact)
 
 		metadata, _ := cmd.Flags().GetStringToString("add")
-		if len(metadata) > 0 {
-			err := artifactmanager.TagArtifact(artifact, metadata)
+		tagsFile, _ := cmd.Flags().GetString("from-file")
+		if tagsFile != "" {
+			fileTags, err := artifactmanager.ReadTagsFile(tagsFile)
 			if err != nil {
-				fmt.Printf("Error adding metadata: %v\n", err)
+				fmt.Fprintf(progress, "Error loading tags: %v\n", err)
 				return
 			}
-			fmt.Println("Metadata added successfully.")
+			// Tags given with --add take precedence over the file
+			for key, value := range metadata {
+				fileTags[key] = value
+			}
+			metadata = fileTags
+		}
+		// Every requested change is applied in one update, or none is
+		edit := artifactmanager.MetadataEdit{Tags: metadata}
+		edit.Remove, _ = cmd.Flags().GetStringSlice("remove")
+		edit.Fields, _ = cmd.Flags().GetStringToString("set")
+		edit.Retag, _ = cmd.Flags().GetBool("retag")
+		specFile, _ := cmd.Flags().GetString("dataset-spec")
+		if specFile != "" {
+			spec, err := artifactmanager.ReadDatasetSpecFile(specFile)
+			if err != nil {
+				fmt.Fprintf(progress, "Error loading dataset spec: %v\n", err)
+				return
+			}
+			edit.Dataset = &spec
 		}
 
-		// Implement logic to display current metadata
+		if len(edit.Tags) > 0 || len(edit.Remove) > 0 || len(edit.Fields) > 0 || edit.Dataset != nil || edit.Retag {
+			if err := artifactmanager.EditArtifact(artifact, edit); err != nil {
+				fmt.Fprintf(progress, "Error updating metadata: %v\n", err)
+				return
+			}
+			fmt.Fprintln(progress, "Metadata updated successfully.")
+			recordEvent(artifact, registry.Event{Action: "tag", Status: "tagged"}, nil)
+		}
+
+		// Display current metadata
+		current, err := artifactmanager.GetArtifactMetadata(artifact)
+		if err != nil {
+			fmt.Fprintf(progress, "Error reading metadata: %v\n", err)
+			return
+		}
+		if err := printOutput(output, current, func(w io.Writer) {
+			writeMetadataTable(w, current)
+		}); err != nil {
+			fmt.Fprintf(progress, "Error displaying metadata: %v\n", err)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(metadataCmd)
 	metadataCmd.Flags().StringToStringP("add", "a", nil, "Add metadata key-value pairs")
+	metadataCmd.Flags().StringSliceP("remove", "r", nil, "Remove metadata tags by key")
+	metadataCmd.Flags().StringToString("set", nil, "Set core metadata fields (name, version, type)")
+	metadataCmd.Flags().StringP("from-file", "f", "", "Add metadata tags from a YAML or JSON file")
+	metadataCmd.Flags().String("dataset-spec", "", "Set the expected dataset schema and thresholds from a YAML or JSON file")
+	metadataCmd.Flags().Bool("retag", false, "Record the digests of the artifact's current content")
+	metadataCmd.Flags().StringP("output", "o", "table", "Output format (table, yaml, json)")
+}
+
+// writeMetadataTable renders artifact metadata as aligned key/value rows.
+func writeMetadataTable(w io.Writer, metadata artifactmanager.ArtifactMetadata) {
+	fmt.Fprintf(w, "Name:\t%s\n", metadata.Name)
+	fmt.Fprintf(w, "Version:\t%s\n", metadata.Version)
+	fmt.Fprintf(w, "Type:\t%s\n", artifactmanager.ArtifactType(metadata))
+	fmt.Fprintf(w, "Created:\t%s\n", metadata.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Updated:\t%s\n", metadata.UpdatedAt.Format(time.RFC3339))
+	if metadata.Size > 0 {
+		fmt.Fprintf(w, "Size:\t%d\n", metadata.Size)
+	}
+	for _, algorithm := range sortedKeys(metadata.Digests) {
+		fmt.Fprintf(w, "Digest:\t%s:%s\n", algorithm, metadata.Digests[algorithm])
+	}
+	if len(metadata.Manifest) > 0 {
+		fmt.Fprintf(w, "Files:\t%d\n", len(metadata.Manifest))
+	}
+	if metadata.Dataset != nil {
+		fmt.Fprintf(w, "Dataset Columns:\t%d\n", len(metadata.Dataset.Columns))
+	}
+	if len(metadata.History) > 0 {
+		fmt.Fprintf(w, "Earlier Versions:\t%d\n", len(metadata.History))
+	}
+
+	fmt.Fprintln(w, "Tags:\t")
+	for _, key := range sortedKeys(metadata.Tags) {
+		fmt.Fprintf(w, "  %s\t%s\n", key, metadata.Tags[key])
+	}
+
+	if len(metadata.Lineage) > 0 {
+		fmt.Fprintln(w, "Lineage:\t")
+	}
+	for _, entry := range metadata.Lineage {
+		fmt.Fprintf(w, "  %s\t%s\n", entry.Timestamp.Format(time.RFC3339), entry.Action)
+		for _, key := range sortedKeys(entry.Details) {
+			fmt.Fprintf(w, "    %s\t%s\n", key, entry.Details[key])
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in lexical order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -13,13 +13,16 @@ var monitorCmd = &cobra.Command{
 	Short: "Monitor data lineage and quality of artifacts",
 	Long:  `This command monitors the data lineage, quality, and other metrics for datasets or models.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		showLineage, _ := cmd.Flags().GetBool("lineage")
+		showQuality, _ := cmd.Flags().GetBool("quality")
+
 		if len(args) > 0 {
 			artifact := args[0]
 			fmt.Printf("Monitoring artifact: %s\n", artifact)
-			monitorArtifact(artifact)
+			monitorArtifact(artifact, showLineage, showQuality)
 		} else {
 			fmt.Println("Monitoring all artifacts...")
-			monitorAllArtifacts()
+			monitorAllArtifacts(showLineage, showQuality)
 		}
 	},
 }
@@ -30,10 +33,7 @@ func init() {
 	monitorCmd.Flags().BoolP("quality", "q", false, "Show quality metrics")
 }
 
-func monitorArtifact(artifact string) {
-	showLineage, _ := monitorCmd.Flags().GetBool("lineage")
-	showQuality, _ := monitorCmd.Flags().GetBool("quality")
-
+func monitorArtifact(artifact string, showLineage, showQuality bool) {
 	if showLineage {
 		lineageData, err := lineage.GetLineage(artifact)
 		if err != nil {
@@ -57,16 +57,27 @@ func monitorArtifact(artifact string) {
 	}
 }
 
-func monitorAllArtifacts() {
-	artifacts, err := telemetry.GetAllArtifacts()
+func monitorAllArtifacts(showLineage, showQuality bool) {
+	reg, err := openRegistry()
+	if err != nil {
+		fmt.Printf("Error opening registry: %v\n", err)
+		return
+	}
+
+	artifacts, err := telemetry.GetAllArtifacts(reg)
 	if err != nil {
 		fmt.Printf("Error retrieving artifacts: %v\n", err)
 		return
 	}
 
+	if len(artifacts) == 0 {
+		fmt.Println("No artifacts registered. Tag, validate or upload an artifact to register it.")
+		return
+	}
+
 	for _, artifact := range artifacts {
 		fmt.Printf("Monitoring artifact: %s\n", artifact)
-		monitorArtifact(artifact)
+		monitorArtifact(artifact, showLineage, showQuality)
 		fmt.Println("---")
 	}
 }
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormats lists the values accepted by --output flags.
+var outputFormats = []string{"table", "yaml", "json"}
+
+// printOutput writes value to stdout in the requested format. The table
+// format is rendered by writeTable into an aligned tab writer.
+func printOutput(format string, value interface{}, writeTable func(w io.Writer)) error {
+	switch format {
+	case "yaml":
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case "json":
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	case "table", "":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		writeTable(w)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unsupported output format %q (supported: %v)", format, outputFormats)
+	}
+}
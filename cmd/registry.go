@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/registry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Inspect the local artifact registry",
+	Long:  `This command lists and maintains the local registry of artifacts that TraceSync has tagged, validated or uploaded.`,
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered artifacts",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		reg, err := openRegistry()
+		if err != nil {
+			fmt.Printf("Error opening registry: %v\n", err)
+			return
+		}
+		entries, err := reg.List()
+		if err != nil {
+			fmt.Printf("Error listing artifacts: %v\n", err)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("No artifacts registered.")
+			return
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\t%s\t%s\n", entry.Path, entry.Version, entry.Status, entry.UpdatedAt.Format(time.RFC3339))
+		}
+	},
+}
+
+var registryRemoveCmd = &cobra.Command{
+	Use:   "remove <artifact>",
+	Short: "Remove an artifact from the registry",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reg, err := openRegistry()
+		if err != nil {
+			fmt.Printf("Error opening registry: %v\n", err)
+			return
+		}
+		if err := reg.Delete(args[0]); err != nil {
+			fmt.Printf("Error removing artifact: %v\n", err)
+			return
+		}
+		fmt.Println("Artifact removed from registry.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryListCmd)
+	registryCmd.AddCommand(registryRemoveCmd)
+}
+
+// openRegistry opens the registry configured with --registry or the config
+// file, falling back to the default location.
+func openRegistry() (*registry.Registry, error) {
+	path := viper.GetString("registry")
+	if path == "" {
+		var err error
+		if path, err = registry.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return registry.Open(path)
+}
+
+// recordEvent stores an event for the artifact in the registry, refreshing the
+// name, version and digests from its descriptor. Registry failures are
+// reported but never fail the command itself.
+func recordEvent(artifact string, event registry.Event, fn func(*registry.Entry)) {
+	reg, err := openRegistry()
+	if err == nil {
+		err = reg.Record(artifact, event, func(entry *registry.Entry) {
+			if metadata, err := artifactmanager.GetArtifactMetadata(artifact); err == nil {
+				entry.Name = metadata.Name
+				entry.Version = metadata.Version
+				entry.Digests = metadata.Digests
+			}
+			if fn != nil {
+				fn(entry)
+			}
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update artifact registry: %v\n", err)
+	}
+}
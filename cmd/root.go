@@ -6,7 +6,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/compliance"
+	"github.com/MChorfa/TraceSync/internal/policy"
+	"github.com/MChorfa/TraceSync/internal/schema"
+	"github.com/MChorfa/TraceSync/internal/vulndb"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -49,9 +55,13 @@ func init() {
 	// Define persistent flags and configuration settings
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.tracesync.yaml)")
 	rootCmd.PersistentFlags().String("env", "staging", "Environment context (production, staging, etc.)")
+	rootCmd.PersistentFlags().String("registry", "", "artifact registry file (default is $TRACESYNC_REGISTRY or <user config dir>/tracesync/registry.json)")
+	rootCmd.PersistentFlags().String("vulndb", "", "vulnerability database directory (default is $TRACESYNC_VULNDB or <user config dir>/tracesync/vulndb)")
 
 	// Bind environment flag to Viper
 	viper.BindPFlag("env", rootCmd.PersistentFlags().Lookup("env"))
+	viper.BindPFlag("registry", rootCmd.PersistentFlags().Lookup("registry"))
+	viper.BindPFlag("vulndb", rootCmd.PersistentFlags().Lookup("vulndb"))
 
 	// Define local flags specific to the root command
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
@@ -81,4 +91,94 @@ func initConfig() {
 	} else {
 		fmt.Fprintln(os.Stderr, "Warning: No configuration file found.")
 	}
+
+	cobra.CheckErr(loadDescriptorSchemas())
+	cobra.CheckErr(loadLicensePolicy())
+	cobra.CheckErr(loadVulnerabilityDatabase())
+	cobra.CheckErr(loadPolicies())
+}
+
+// configPath resolves a path from the config file against the config
+// file's directory.
+func configPath(path string) string {
+	if configFile := viper.ConfigFileUsed(); configFile != "" && !filepath.IsAbs(path) {
+		return filepath.Join(filepath.Dir(configFile), path)
+	}
+	return path
+}
+
+// loadDescriptorSchemas installs the descriptor schemas configured under
+// descriptor_schemas, a mapping of artifact types to JSON or YAML schema
+// files. Relative paths are resolved against the config file's directory.
+func loadDescriptorSchemas() error {
+	files := viper.GetStringMapString("descriptor_schemas")
+	if len(files) == 0 {
+		return nil
+	}
+	for artifactType, path := range files {
+		files[artifactType] = configPath(path)
+	}
+
+	registry, err := schema.LoadRegistry(files)
+	if err != nil {
+		return err
+	}
+	artifactmanager.SetDescriptorSchemas(registry)
+	return nil
+}
+
+// loadLicensePolicy installs the license policy file configured under
+// license_policy, applied with the strictness of the --env environment.
+func loadLicensePolicy() error {
+	path := viper.GetString("license_policy")
+	if path == "" {
+		return nil
+	}
+	policy, err := compliance.LoadLicensePolicy(configPath(path))
+	if err != nil {
+		return err
+	}
+	compliance.SetLicensePolicy(policy, viper.GetString("env"))
+	return nil
+}
+
+// loadPolicies installs the compliance rules of the directory configured
+// under policy_dir alongside the built-in rules, evaluated in the --env
+// environment.
+func loadPolicies() error {
+	dir := viper.GetString("policy_dir")
+	if dir == "" {
+		compliance.SetPolicies(nil, viper.GetString("env"))
+		return nil
+	}
+	set, err := policy.LoadDir(configPath(dir))
+	if err != nil {
+		return err
+	}
+	compliance.SetPolicies(set, viper.GetString("env"))
+	return nil
+}
+
+// loadVulnerabilityDatabase has the compliance check match components against
+// the vulnerability database. The default database is only used once data
+// has been imported into it; one configured explicitly is always used, so
+// the check fails rather than silently skipping matching when it is missing.
+// Vulnerabilities rated at or above vulnerability_threshold (default high)
+// fail the check.
+func loadVulnerabilityDatabase() error {
+	threshold := vulndb.SeverityHigh
+	if name := viper.GetString("vulnerability_threshold"); name != "" {
+		var err error
+		if threshold, err = vulndb.ParseSeverity(name); err != nil {
+			return fmt.Errorf("invalid vulnerability_threshold: %w", err)
+		}
+	}
+	db, explicit, err := openVulnerabilityDatabase()
+	if err != nil {
+		return err
+	}
+	if explicit || db.Exists() {
+		compliance.SetVulnerabilityDatabase(db, threshold)
+	}
+	return nil
 }
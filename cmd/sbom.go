@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/compliance"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var sbomCmd = &cobra.Command{
+	Use:   "sbom",
+	Short: "Generate and inspect software bills of materials",
+	Long: `This command works with the SBOMs (Software Bill of Materials) of artifacts.
+
+SBOMs are written next to the artifact. The default format is CycloneDX 1.5 JSON;
+set --sbom-format or the sbom_format config key to choose another one.`,
+}
+
+var sbomGenerateCmd = &cobra.Command{
+	Use:   "generate <artifact>",
+	Short: "Generate the SBOM of an artifact",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := sbomFormat(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if _, _, err := generateSBOM(args[0], format); err != nil {
+			fmt.Printf("SBOM generation failed: %v\n", err)
+		}
+	},
+}
+
+var sbomImportCmd = &cobra.Command{
+	Use:   "import <artifact> <sbom>",
+	Short: "Attach a supplied SBOM to an artifact",
+	Long: `This command attaches a CycloneDX (1.2 to 1.6) or SPDX (2.2 or 2.3, JSON or tag-value)
+SBOM supplied for an artifact, such as one from the vendor of a third-party artifact.
+
+The SBOM is validated against its specification first: the structure required by the
+spec schema, unique and resolvable bom-refs and SPDX identifiers, package URLs and
+license expressions. A valid SBOM is kept as <name>-sbom.imported.<ext> next to the
+artifact and recorded in its descriptor entry. From then on it is used in place of the
+SBOM generated from the artifact's content, by sbom generate, upload and compliance.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := importSBOM(os.Stdout, args[0], args[1]); err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+var sbomDiffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare the components of two SBOMs",
+	Long: `This command lists the components that were added, removed, upgraded or downgraded
+between two SBOMs, and those whose license changed. Each argument is an SBOM file in any
+supported format, or an artifact, whose existing SBOM is used or, failing that, its
+imported SBOM or one built from its current content. Earlier versions of an artifact are
+given as <artifact>@<version> and use the copy of the SBOM recorded when the version was
+archived, e.g. 'tracesync sbom diff model.bin@1.0.0 model.bin'.
+
+With --fail-on, the command exits non-zero when a change of one of the listed kinds is
+found: added, removed, upgraded, downgraded, license or copyleft (a copyleft license a
+component did not have before). The default is the sbom_diff_fail_on config key.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		failOn, _ := cmd.Flags().GetStringSlice("fail-on")
+		if !cmd.Flags().Changed("fail-on") {
+			failOn = viper.GetStringSlice("sbom_diff_fail_on")
+		}
+		kinds, err := compliance.ParseChangeKinds(failOn)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var sboms [2]*compliance.SBOM
+		for i, arg := range args {
+			if sboms[i], err = loadSBOMArgument(arg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		diff := compliance.DiffSBOMs(sboms[0], sboms[1])
+		if err := printOutput(output, diff, func(w io.Writer) {
+			writeSBOMDiff(w, diff)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error displaying diff: %v\n", err)
+			os.Exit(1)
+		}
+		if failing := diff.Matching(kinds); len(failing) > 0 {
+			fmt.Fprintf(os.Stderr, "%d change(s) of kinds %s found.\n", len(failing), strings.Join(kinds, ", "))
+			os.Exit(1)
+		}
+	},
+}
+
+var sbomMergeCmd = &cobra.Command{
+	Use:   "merge <sbom-or-artifact>...",
+	Short: "Combine the SBOMs of several artifacts into a release SBOM",
+	Long: `This command combines the SBOMs of the artifacts of a release, such as a model, its
+tokenizer, its serving binary and its evaluation dataset, into one SBOM describing the
+release. Each argument is an SBOM file in any supported format, or an artifact.
+
+Every artifact becomes a component of the release. Components with the same package URL
+or a common hash are listed once, with the dependencies recorded in every SBOM. Components
+included in different versions are reported as conflicts; with --fail-on-conflict they
+make the command exit non-zero.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		version, _ := cmd.Flags().GetString("version")
+		path, _ := cmd.Flags().GetString("file")
+		failOnConflict, _ := cmd.Flags().GetBool("fail-on-conflict")
+		format, err := sbomFormat(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var sboms []*compliance.SBOM
+		inputComponents := 0
+		for _, arg := range args {
+			sbom, err := loadSBOMArgument(arg)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			sboms = append(sboms, sbom)
+			inputComponents += len(sbom.Components) + 1
+		}
+
+		merged, conflicts, err := compliance.MergeSBOMs(name, version, sboms)
+		if err != nil {
+			fmt.Printf("SBOM merge failed: %v\n", err)
+			os.Exit(1)
+		}
+		data, err := compliance.EncodeSBOM(merged, format)
+		if err != nil {
+			fmt.Printf("SBOM merge failed: %v\n", err)
+			os.Exit(1)
+		}
+		if path == "" {
+			path = compliance.SBOMPath(name, name, format)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			fmt.Printf("Failed to write SBOM file: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Merged %d SBOMs into %s: %d components, %d duplicates combined.\n",
+			len(sboms), path, len(merged.Components), inputComponents-len(merged.Components))
+		if len(conflicts) > 0 {
+			fmt.Println("Version conflicts:")
+			for _, conflict := range conflicts {
+				fmt.Printf("- %s\n", conflict)
+			}
+			if failOnConflict {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sbomCmd)
+	sbomCmd.AddCommand(sbomGenerateCmd)
+	addSBOMFormatFlag(sbomGenerateCmd)
+
+	sbomCmd.AddCommand(sbomDiffCmd)
+	sbomDiffCmd.Flags().StringP("output", "o", "table", "Output format (table, yaml, json)")
+	sbomDiffCmd.Flags().StringSlice("fail-on", nil, fmt.Sprintf("Exit non-zero on changes of these kinds %v", compliance.ChangeKinds))
+
+	sbomCmd.AddCommand(sbomImportCmd)
+
+	sbomCmd.AddCommand(sbomMergeCmd)
+	addSBOMFormatFlag(sbomMergeCmd)
+	sbomMergeCmd.Flags().String("name", "", "Name of the release")
+	sbomMergeCmd.Flags().String("version", "", "Version of the release")
+	sbomMergeCmd.Flags().StringP("file", "f", "", "Write the merged SBOM to this file (default is <name>-sbom.json in the current directory, by format)")
+	sbomMergeCmd.Flags().Bool("fail-on-conflict", false, "Exit non-zero when components are included in different versions")
+	sbomMergeCmd.MarkFlagRequired("name")
+}
+
+// importSBOM attaches a supplied SBOM to an artifact, listing every
+// specification violation if it is rejected.
+func importSBOM(w io.Writer, artifact, path string) error {
+	importedPath, err := compliance.ImportSBOM(artifact, path)
+	var validationErr *compliance.SBOMValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fmt.Fprintf(w, "SBOM import failed: %s is not a valid %s SBOM:\n", path, validationErr.Format)
+		for _, violation := range validationErr.Violations {
+			fmt.Fprintf(w, "  %s\n", violation)
+		}
+		return err
+	case err != nil:
+		fmt.Fprintf(w, "SBOM import failed: %v\n", err)
+		return err
+	}
+	fmt.Fprintf(w, "SBOM imported and saved to: %s\n", importedPath)
+	return nil
+}
+
+// generateSBOM writes the SBOM of an artifact next to it and prints where,
+// along with anything that was left out of the SBOM.
+func generateSBOM(artifact string, format compliance.Format) (*compliance.SBOM, string, error) {
+	metadata, err := artifactmanager.GetArtifactMetadata(artifact)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+	sbom, err := compliance.ArtifactSBOM(artifact, metadata)
+	if err != nil {
+		return nil, "", err
+	}
+	sbomPath, err := compliance.WriteSBOM(artifact, sbom, format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, warning := range sbom.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if metadata.SBOM != nil {
+		fmt.Printf("SBOM imported from %s and saved to: %s\n", metadata.SBOM.Source, sbomPath)
+	} else {
+		fmt.Printf("SBOM generated and saved to: %s\n", sbomPath)
+	}
+	return sbom, sbomPath, nil
+}
+
+// loadSBOMArgument reads an SBOM file, the SBOM of an artifact, or, given as
+// <artifact>@<version>, the SBOM recorded when that version was archived.
+func loadSBOMArgument(path string) (*compliance.SBOM, error) {
+	if _, err := os.Stat(path); err != nil {
+		if i := strings.LastIndex(path, "@"); i > 0 {
+			return loadVersionSBOM(path[:i], path[i+1:])
+		}
+	}
+	metadata, err := artifactmanager.GetArtifactMetadata(path)
+	if err != nil {
+		return compliance.LoadSBOM(path)
+	}
+	return loadArtifactSBOM(path, metadata)
+}
+
+// loadArtifactSBOM reads the current SBOM of an artifact.
+func loadArtifactSBOM(path string, metadata artifactmanager.ArtifactMetadata) (*compliance.SBOM, error) {
+	if sbomPath, ok := compliance.FindSBOM(path, metadata.Name); ok {
+		return compliance.LoadSBOM(sbomPath)
+	}
+	return compliance.ArtifactSBOM(path, metadata)
+}
+
+// loadVersionSBOM reads the SBOM of one version of an artifact: the current
+// SBOM for the current version, the recorded snapshot for archived ones.
+func loadVersionSBOM(path, version string) (*compliance.SBOM, error) {
+	metadata, err := artifactmanager.GetArtifactMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Version == version {
+		return loadArtifactSBOM(path, metadata)
+	}
+	sbomPath, err := artifactmanager.GetVersionSBOM(path, version)
+	if err != nil {
+		return nil, err
+	}
+	return compliance.LoadSBOM(sbomPath)
+}
+
+// writeSBOMDiff renders an SBOM diff as aligned rows.
+func writeSBOMDiff(w io.Writer, diff *compliance.SBOMDiff) {
+	fmt.Fprintf(w, "Old:\t%s %s (%d components)\n", diff.Old.Name, diff.Old.Version, diff.Old.Components)
+	fmt.Fprintf(w, "New:\t%s %s (%d components)\n", diff.New.Name, diff.New.Version, diff.New.Components)
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(w, "No component changes.")
+		return
+	}
+	fmt.Fprintln(w, "CHANGE\tCOMPONENT\tVERSION\tLICENSE")
+	for _, change := range diff.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.Join(change.Kinds, ","), change.Name,
+			transition(change.OldVersion, change.NewVersion), transition(change.OldLicense, change.NewLicense))
+	}
+}
+
+// transition renders an old and new value as "old -> new", or the one
+// that is set.
+func transition(old, new string) string {
+	switch {
+	case old == "" || old == new:
+		return new
+	case new == "":
+		return old
+	}
+	return old + " -> " + new
+}
+
+// addSBOMFormatFlag adds the --sbom-format flag to a command.
+func addSBOMFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().String("sbom-format", "", fmt.Sprintf("SBOM format %v (default is the sbom_format config key or %s)", compliance.Formats, compliance.FormatCycloneDX))
+}
+
+// sbomFormat returns the SBOM format chosen with --sbom-format or the
+// sbom_format config key.
+func sbomFormat(cmd *cobra.Command) (compliance.Format, error) {
+	name, _ := cmd.Flags().GetString("sbom-format")
+	if name == "" {
+		name = viper.GetString("sbom_format")
+	}
+	return compliance.ParseFormat(name)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <expression>",
+	Short: "Search artifacts by metadata fields and tags",
+	Long: `This command searches artifact metadata with a small expression language.
+
+Comparisons use =, !=, >, >=, <, <= or ~ (glob match) and can be combined
+with AND, OR, NOT and parentheses. Fields are name, version, type, path,
+size, created_at, updated_at and digest; any other name refers to a tag, which can
+also be written as tags.<key>. Quote values that contain spaces.
+
+By default the artifacts in the local registry are searched; use --dir to
+scan the descriptors below a directory instead.
+
+Example:
+
+  tracesync search 'owner=vision AND stage!=archived AND created_at>2026-01-01'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		q, err := query.Parse(args[0])
+		if err != nil {
+			fmt.Printf("Invalid search expression: %v\n", err)
+			return
+		}
+
+		dir, _ := cmd.Flags().GetString("dir")
+		artifacts, err := searchCandidates(dir)
+		if err != nil {
+			fmt.Printf("Error loading artifacts: %v\n", err)
+			return
+		}
+
+		results := []searchResult{}
+		for path, metadata := range artifacts {
+			if q.Match(searchFields(path, metadata)) {
+				results = append(results, searchResult{Path: path, Metadata: metadata})
+			}
+		}
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].Path < results[j].Path
+		})
+
+		output, _ := cmd.Flags().GetString("output")
+		if err := printOutput(output, results, func(w io.Writer) {
+			fmt.Fprintln(w, "PATH\tNAME\tVERSION\tUPDATED")
+			for _, result := range results {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Path, result.Metadata.Name, result.Metadata.Version, result.Metadata.UpdatedAt.Format(time.RFC3339))
+			}
+		}); err != nil {
+			fmt.Printf("Error displaying results: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().StringP("dir", "d", "", "Search descriptors below this directory instead of the registry")
+	searchCmd.Flags().StringP("output", "o", "table", "Output format (table, yaml, json)")
+}
+
+type searchResult struct {
+	Path     string                           `yaml:"path" json:"path"`
+	Metadata artifactmanager.ArtifactMetadata `yaml:"metadata" json:"metadata"`
+}
+
+// searchCandidates loads the metadata to search, either from the descriptors
+// below dir or from the artifacts in the registry.
+func searchCandidates(dir string) (map[string]artifactmanager.ArtifactMetadata, error) {
+	if dir != "" {
+		return artifactmanager.ListArtifacts(dir)
+	}
+
+	reg, err := openRegistry()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := reg.List()
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make(map[string]artifactmanager.ArtifactMetadata, len(entries))
+	for _, entry := range entries {
+		metadata, err := artifactmanager.GetArtifactMetadata(entry.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", entry.Path, err)
+			continue
+		}
+		artifacts[entry.Path] = metadata
+	}
+	return artifacts, nil
+}
+
+// searchFields flattens artifact metadata into the fields a query can reference.
+func searchFields(path string, metadata artifactmanager.ArtifactMetadata) map[string]string {
+	fields := map[string]string{
+		"path":       path,
+		"name":       metadata.Name,
+		"version":    metadata.Version,
+		"type":       artifactmanager.ArtifactType(metadata),
+		"created_at": metadata.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at": metadata.UpdatedAt.Format(time.RFC3339Nano),
+		"size":       strconv.FormatInt(metadata.Size, 10),
+		"digest":     metadata.Digests[artifactmanager.DigestSHA256],
+	}
+	for key, value := range metadata.Tags {
+		fields["tags."+key] = value
+	}
+	return fields
+}
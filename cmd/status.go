@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/MChorfa/TraceSync/internal/registry"
 	"github.com/spf13/cobra"
 )
 
+// statusEventCount is the number of recent events shown by the status command.
+const statusEventCount = 5
+
 var statusCmd = &cobra.Command{
 	Use:   "status <artifact>",
 	Short: "Check the status of an ongoing or completed artifact transfer",
@@ -14,7 +20,44 @@ var statusCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		artifact := args[0]
 		fmt.Printf("Checking status of artifact: %s\n", artifact)
-		// Implement status checking logic here
+
+		reg, err := openRegistry()
+		if err != nil {
+			fmt.Printf("Error opening registry: %v\n", err)
+			return
+		}
+		entry, err := reg.Get(artifact)
+		if errors.Is(err, registry.ErrNotFound) {
+			fmt.Println("Artifact is not registered. Tag, validate or upload it first.")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error reading registry: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Name: %s\n", entry.Name)
+		fmt.Printf("Version: %s\n", entry.Version)
+		fmt.Printf("Status: %s\n", entry.Status)
+		if entry.Backend != "" {
+			fmt.Printf("Backend: %s\n", entry.Backend)
+		}
+		fmt.Printf("Last Updated: %s\n", entry.UpdatedAt.Format(time.RFC3339))
+
+		events := entry.Events
+		if len(events) > statusEventCount {
+			events = events[len(events)-statusEventCount:]
+		}
+		if len(events) > 0 {
+			fmt.Println("Recent events:")
+		}
+		for _, event := range events {
+			fmt.Printf("- %s: %s (%s)", event.Timestamp.Format(time.RFC3339), event.Action, event.Status)
+			if event.Message != "" {
+				fmt.Printf(" %s", event.Message)
+			}
+			fmt.Println()
+		}
 	},
 }
 
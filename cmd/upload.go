@@ -3,11 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/MChorfa/TraceSync/internal/artifactmanager"
 	"github.com/MChorfa/TraceSync/internal/compliance"
+	"github.com/MChorfa/TraceSync/internal/registry"
 	"github.com/MChorfa/TraceSync/internal/storagemanager"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
 var uploadCmd = &cobra.Command{
@@ -17,31 +21,64 @@ var uploadCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		artifact := args[0]
+		startedOn := time.Now()
+		format, err := sbomFormat(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 		fmt.Printf("Uploading artifact: %s\n", artifact)
 
 		// Validate artifact
 		if err := artifactmanager.ValidateArtifact(artifact); err != nil {
-			fmt.Printf("Artifact validation failed: %v\n", err)
-			return
+			recordEvent(artifact, registry.Event{Action: "validate", Status: "validation_failed", Message: err.Error()}, nil)
+			printValidationError(os.Stdout, err)
+			os.Exit(1)
 		}
 
-		// Generate SBOM
-		if err := compliance.GenerateSBOM(artifact); err != nil {
+		// Generate the SBOM that the provenance and the compliance check use
+		sbom, sbomPath, err := generateSBOM(artifact, format)
+		if err != nil {
 			fmt.Printf("SBOM generation failed: %v\n", err)
-			return
+			os.Exit(1)
+		}
+
+		// Record how the artifact was produced
+		provenancePath, err := compliance.GenerateProvenance(artifact, compliance.ProvenanceOptions{
+			BuilderID:          viper.GetString("provenance_builder_id"),
+			ExternalParameters: uploadParameters(cmd, artifact),
+			InternalParameters: map[string]any{"environment": viper.GetString("env")},
+			StartedOn:          startedOn,
+			FinishedOn:         time.Now(),
+			SBOMPath:           sbomPath,
+			SBOM:               sbom,
+		})
+		if err != nil {
+			fmt.Printf("Provenance generation failed: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("Provenance generated and saved to: %s\n", provenancePath)
 
-		// Perform compliance check
-		if err := compliance.PerformComplianceCheck(artifact); err != nil {
+		// Perform compliance check, with the provenance available to policies
+		report, err := compliance.CheckSBOMCompliance(artifact, sbom, sbomPath)
+		if err != nil {
 			fmt.Printf("Compliance check failed: %v\n", err)
-			return
+			os.Exit(1)
+		}
+		if err := writeComplianceReport(cmd, report); err != nil {
+			fmt.Printf("Error writing compliance report: %v\n", err)
+			os.Exit(1)
+		}
+		if !report.Passed() {
+			fmt.Printf("Compliance check failed: %v\n", &compliance.ComplianceError{Report: report})
+			os.Exit(1)
 		}
 
 		// Encrypt artifact
 		encryptedArtifact, err := storagemanager.EncryptArtifact(artifact)
 		if err != nil {
 			fmt.Printf("Artifact encryption failed: %v\n", err)
-			return
+			os.Exit(1)
 		}
 
 		// Determine storage backend
@@ -49,17 +86,44 @@ var uploadCmd = &cobra.Command{
 
 		// Upload encrypted artifact
 		if err := storagemanager.UploadArtifact(encryptedArtifact, backend); err != nil {
+			recordEvent(artifact, registry.Event{Action: "upload", Status: "upload_failed", Message: err.Error()}, nil)
 			fmt.Printf("Artifact upload failed: %v\n", err)
-			return
+			os.Exit(1)
 		}
+		// The provenance travels with the artifact, unencrypted so it can be
+		// verified without the key
+		if err := storagemanager.UploadArtifact(provenancePath, backend); err != nil {
+			recordEvent(artifact, registry.Event{Action: "upload", Status: "upload_failed", Message: err.Error()}, nil)
+			fmt.Printf("Provenance upload failed: %v\n", err)
+			os.Exit(1)
+		}
+		recordEvent(artifact, registry.Event{Action: "upload", Status: "uploaded", Message: backend}, func(entry *registry.Entry) {
+			entry.Backend = backend
+		})
 
 		fmt.Println("Artifact uploaded successfully.")
 	},
 }
 
+// uploadParameters returns the provenance external parameters of an upload:
+// the command, the artifact and the flags set on the command line.
+func uploadParameters(cmd *cobra.Command, artifact string) map[string]any {
+	flags := make(map[string]string)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		flags[flag.Name] = flag.Value.String()
+	})
+	return map[string]any{
+		"command":  cmd.CommandPath(),
+		"artifact": artifact,
+		"flags":    flags,
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(uploadCmd)
 
 	uploadCmd.Flags().StringToStringP("metadata", "m", nil, "Metadata key-value pairs")
 	uploadCmd.Flags().StringToStringP("lineage", "l", nil, "Data lineage information")
+	addSBOMFormatFlag(uploadCmd)
+	addReportFlags(uploadCmd)
 }
@@ -1,31 +1,112 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/dataset"
+	"github.com/MChorfa/TraceSync/internal/registry"
 	"github.com/spf13/cobra"
 )
 
 var validateCmd = &cobra.Command{
 	Use:   "validate <dataset>",
 	Short: "Validate the quality and completeness of a dataset",
-	Long:  `This command runs quality checks to ensure that the dataset is complete and valid for use.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `This command runs quality checks to ensure that the dataset is complete and valid for use.
+
+The descriptor is checked first, including the recorded digests. When the descriptor
+declares a dataset spec (see 'tracesync metadata --dataset-spec'), the data itself is
+checked as well: the file must parse, declared columns must be present with the right
+types, null rates must stay under their thresholds, primary keys must be unique and the
+row count must be within bounds. CSV, TSV, JSONL and Parquet files are supported.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		artifact := args[0]
-		fmt.Printf("Validating dataset: %s\n", artifact)
+		output, _ := cmd.Flags().GetString("output")
+
+		// Keep stdout parseable when printing YAML or JSON
+		progress := os.Stdout
+		if output != "table" {
+			progress = os.Stderr
+		}
+		fmt.Fprintf(progress, "Validating dataset: %s\n", artifact)
 
 		err := artifactmanager.ValidateArtifact(artifact)
 		if err != nil {
-			fmt.Printf("Validation failed: %v\n", err)
-			return
+			recordEvent(artifact, registry.Event{Action: "validate", Status: "validation_failed", Message: err.Error()}, nil)
+		}
+		if errors.Is(err, artifactmanager.ErrDigestMismatch) {
+			fmt.Fprintf(progress, "Validation failed: %v\n", err)
+			fmt.Fprintln(progress, "The artifact was modified after it was tagged; bump its version or run 'tracesync metadata --retag' if the change is intended.")
+			os.Exit(1)
+		}
+		if errors.Is(err, artifactmanager.ErrDigestMissing) {
+			fmt.Fprintf(progress, "Validation failed: %v\n", err)
+			fmt.Fprintln(progress, "Record the digests of the current content with 'tracesync metadata --retag'.")
+			os.Exit(1)
+		}
+		if err != nil {
+			printValidationError(progress, err)
+			os.Exit(1)
 		}
 
-		fmt.Println("Validation successful.")
+		metadata, err := artifactmanager.GetArtifactMetadata(artifact)
+		if err != nil {
+			fmt.Fprintf(progress, "Validation failed: %v\n", err)
+			os.Exit(1)
+		}
+		if metadata.Dataset != nil {
+			report, err := dataset.Validate(artifact, *metadata.Dataset)
+			if err != nil {
+				recordEvent(artifact, registry.Event{Action: "validate", Status: "validation_failed", Message: err.Error()}, nil)
+				fmt.Fprintf(progress, "Validation failed: %v\n", err)
+				os.Exit(1)
+			}
+			if err := printOutput(output, report, func(w io.Writer) {
+				writeDatasetReport(w, report)
+			}); err != nil {
+				fmt.Fprintf(progress, "Error displaying report: %v\n", err)
+			}
+			if !report.Passed() {
+				recordEvent(artifact, registry.Event{Action: "validate", Status: "validation_failed", Message: "dataset checks failed"}, nil)
+				fmt.Fprintln(progress, "Validation failed: dataset checks failed.")
+				os.Exit(1)
+			}
+		}
+
+		recordEvent(artifact, registry.Event{Action: "validate", Status: "validated"}, nil)
+		fmt.Fprintln(progress, "Validation successful.")
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringP("output", "o", "table", "Report format (table, yaml, json)")
+}
+
+// printValidationError reports a validation failure, listing every schema
+// violation on its own line.
+func printValidationError(w io.Writer, err error) {
+	var schemaErr *artifactmanager.SchemaError
+	if !errors.As(err, &schemaErr) {
+		fmt.Fprintf(w, "Validation failed: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "Validation failed: metadata does not match the %s descriptor schema:\n", schemaErr.Type)
+	for _, violation := range schemaErr.Violations {
+		fmt.Fprintf(w, "  %s\n", violation)
+	}
+}
+
+// writeDatasetReport renders a dataset validation report as aligned rows.
+func writeDatasetReport(w io.Writer, report *dataset.Report) {
+	fmt.Fprintf(w, "Format:\t%s\n", report.Format)
+	fmt.Fprintf(w, "Rows:\t%d\n", report.Rows)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Check, check.Status, check.Message)
+	}
 }
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/registry"
+	"github.com/MChorfa/TraceSync/internal/semver"
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Manage artifact versions",
+	Long: `This command bumps artifact versions and lists or shows earlier versions.
+
+Versions are semantic versions (MAJOR.MINOR.PATCH). Each time an artifact moves to a
+new version, the previous descriptor entry, including its digests, is archived in the
+descriptor's version history, which cannot be changed afterwards.`,
+}
+
+var versionBumpCmd = &cobra.Command{
+	Use:       "bump <major|minor|patch> <artifact>",
+	Short:     "Bump the version of an artifact",
+	Long:      `This command increments the given part of the artifact's version, records the digests of its current content and archives the previous version.`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: []string{semver.Major, semver.Minor, semver.Patch},
+	Run: func(cmd *cobra.Command, args []string) {
+		part, artifact := args[0], args[1]
+
+		version, err := artifactmanager.BumpVersion(artifact, part)
+		if err != nil {
+			fmt.Printf("Error bumping version: %v\n", err)
+			return
+		}
+		recordEvent(artifact, registry.Event{Action: "version", Status: "tagged", Message: "bumped to " + version}, nil)
+		fmt.Printf("Artifact %s is now at version %s\n", artifact, version)
+	},
+}
+
+// versionSummary is one row of the version list.
+type versionSummary struct {
+	Version    string            `yaml:"version" json:"version"`
+	Current    bool              `yaml:"current" json:"current"`
+	Size       int64             `yaml:"size,omitempty" json:"size,omitempty"`
+	Digests    map[string]string `yaml:"digests,omitempty" json:"digests,omitempty"`
+	UpdatedAt  time.Time         `yaml:"updated_at" json:"updated_at"`
+	ArchivedAt *time.Time        `yaml:"archived_at,omitempty" json:"archived_at,omitempty"`
+}
+
+var versionListCmd = &cobra.Command{
+	Use:   "list <artifact>",
+	Short: "List the versions of an artifact",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		metadata, err := artifactmanager.GetArtifactMetadata(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading metadata: %v\n", err)
+			return
+		}
+
+		var versions []versionSummary
+		for _, record := range metadata.History {
+			archivedAt := record.ArchivedAt
+			versions = append(versions, versionSummary{
+				Version:    record.Version,
+				Size:       record.Size,
+				Digests:    record.Digests,
+				UpdatedAt:  record.UpdatedAt,
+				ArchivedAt: &archivedAt,
+			})
+		}
+		versions = append(versions, versionSummary{
+			Version:   metadata.Version,
+			Current:   true,
+			Size:      metadata.Size,
+			Digests:   metadata.Digests,
+			UpdatedAt: metadata.UpdatedAt,
+		})
+
+		if err := printOutput(output, versions, func(w io.Writer) {
+			fmt.Fprintln(w, "VERSION\tCURRENT\tDIGEST\tUPDATED")
+			for _, version := range versions {
+				fmt.Fprintf(w, "%s\t%t\t%s\t%s\n", version.Version, version.Current,
+					version.Digests[artifactmanager.DigestSHA256], version.UpdatedAt.Format(time.RFC3339))
+			}
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error displaying versions: %v\n", err)
+		}
+	},
+}
+
+var versionShowCmd = &cobra.Command{
+	Use:   "show <artifact> <version>",
+	Short: "Show the metadata of an artifact version",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		metadata, err := artifactmanager.GetArtifactVersion(args[0], args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading version: %v\n", err)
+			return
+		}
+		if err := printOutput(output, metadata, func(w io.Writer) {
+			writeMetadataTable(w, metadata)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error displaying metadata: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.AddCommand(versionBumpCmd)
+	versionCmd.AddCommand(versionListCmd)
+	versionCmd.AddCommand(versionShowCmd)
+
+	versionListCmd.Flags().StringP("output", "o", "table", "Output format (table, yaml, json)")
+	versionShowCmd.Flags().StringP("output", "o", "table", "Output format (table, yaml, json)")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MChorfa/TraceSync/internal/vulndb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var vulndbCmd = &cobra.Command{
+	Use:   "vulndb",
+	Short: "Manage the offline vulnerability database",
+	Long: `This command maintains the local mirror of OSV vulnerability data that the compliance check matches
+SBOM components against. The database is only ever updated by importing data, so it works on air-gapped runners.`,
+}
+
+var vulndbImportCmd = &cobra.Command{
+	Use:   "import <dir-or-zip>...",
+	Short: "Import OSV entries from directories of JSON files or zip archives",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, _, err := openVulnerabilityDatabase()
+		if err != nil {
+			fmt.Printf("Error opening vulnerability database: %v\n", err)
+			os.Exit(1)
+		}
+		for _, source := range args {
+			result, err := db.Import(source)
+			if err != nil {
+				fmt.Printf("Error importing %s: %v\n", source, err)
+				os.Exit(1)
+			}
+			fmt.Printf("Imported %s into %s: %d added, %d updated, %d unchanged.\n",
+				source, db.Path(), result.Added, result.Updated, result.Unchanged)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vulndbCmd)
+	vulndbCmd.AddCommand(vulndbImportCmd)
+}
+
+// openVulnerabilityDatabase opens the database configured with --vulndb, the
+// config file or $TRACESYNC_VULNDB, falling back to the default location. It
+// reports whether the database was configured explicitly.
+func openVulnerabilityDatabase() (*vulndb.DB, bool, error) {
+	path := viper.GetString("vulndb")
+	explicit := path != "" || os.Getenv("TRACESYNC_VULNDB") != ""
+	if path == "" {
+		var err error
+		if path, err = vulndb.DefaultPath(); err != nil {
+			return nil, false, err
+		}
+	}
+	return vulndb.Open(path), explicit, nil
+}
@@ -4,137 +4,409 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/MChorfa/TraceSync/internal/semver"
 	"gopkg.in/yaml.v3"
 )
 
+// ErrRevisionConflict is returned when metadata is saved on top of a
+// descriptor entry that another writer changed in the meantime.
+var ErrRevisionConflict = errors.New("artifact metadata was modified concurrently")
+
 type ArtifactMetadata struct {
-	Name      string            `yaml:"name"`
-	Version   string            `yaml:"version"`
-	CreatedAt time.Time         `yaml:"created_at"`
-	UpdatedAt time.Time         `yaml:"updated_at"`
-	Tags      map[string]string `yaml:"tags"`
-	Lineage   []LineageEntry    `yaml:"lineage"`
+	Revision  int64             `yaml:"revision" json:"revision"`
+	Name      string            `yaml:"name" json:"name"`
+	Version   string            `yaml:"version" json:"version"`
+	Type      string            `yaml:"type,omitempty" json:"type,omitempty"`
+	CreatedAt time.Time         `yaml:"created_at" json:"created_at"`
+	UpdatedAt time.Time         `yaml:"updated_at" json:"updated_at"`
+	Size      int64             `yaml:"size,omitempty" json:"size,omitempty"`
+	Digests   map[string]string `yaml:"digests,omitempty" json:"digests,omitempty"`
+	Manifest  []ManifestEntry   `yaml:"manifest,omitempty" json:"manifest,omitempty"`
+	Dataset   *DatasetSpec      `yaml:"dataset,omitempty" json:"dataset,omitempty"`
+	SBOM      *ImportedSBOM     `yaml:"sbom,omitempty" json:"sbom,omitempty"`
+	Tags      map[string]string `yaml:"tags" json:"tags"`
+	Lineage   []LineageEntry    `yaml:"lineage" json:"lineage"`
+	History   []VersionRecord   `yaml:"history,omitempty" json:"history,omitempty"`
 }
 
 type LineageEntry struct {
-	Timestamp time.Time         `yaml:"timestamp"`
-	Action    string            `yaml:"action"`
-	Details   map[string]string `yaml:"details"`
+	Timestamp time.Time         `yaml:"timestamp" json:"timestamp"`
+	Action    string            `yaml:"action" json:"action"`
+	Details   map[string]string `yaml:"details" json:"details"`
 }
 
 func TagArtifact(artifactPath string, metadata map[string]string) error {
-	metadataFilePath := filepath.Join(filepath.Dir(artifactPath), "ModelDescriptor.yaml")
+	return editArtifact(artifactPath, MetadataEdit{Tags: metadata}, true)
+}
 
-	var artifactMetadata ArtifactMetadata
-	if _, err := os.Stat(metadataFilePath); err == nil {
-		// File exists, read existing metadata
-		data, err := os.ReadFile(metadataFilePath)
-		if err != nil {
-			return fmt.Errorf("failed to read metadata file: %w", err)
+// MetadataEdit is a set of changes to an artifact's metadata that
+// EditArtifact applies together.
+type MetadataEdit struct {
+	// Tags are added or replaced, as with TagArtifact; a version tag sets
+	// the version field.
+	Tags map[string]string
+	// Remove lists tag keys to remove, as with UntagArtifact.
+	Remove []string
+	// Fields sets core fields, as with SetArtifactFields.
+	Fields map[string]string
+	// Dataset replaces the dataset spec, as with SetDatasetSpec.
+	Dataset *DatasetSpec
+	// Retag records the size and digests of the artifact's current content
+	// in place of the recorded ones.
+	Retag bool
+}
+
+// EditArtifact applies every change of an edit in a single descriptor
+// update: either all of them are stored, with one revision, or none is.
+// Adding tags creates the entry of an artifact that has none yet; other
+// changes need a tagged artifact.
+//
+// Digests are recorded when the entry is created, when the version changes
+// and when the edit asks to retag. Any other edit first checks the artifact
+// against its recorded digests and fails with ErrDigestMismatch if the
+// content has changed, so a modified artifact cannot keep its version.
+func EditArtifact(artifactPath string, edit MetadataEdit) error {
+	return editArtifact(artifactPath, edit, len(edit.Tags) > 0)
+}
+
+func editArtifact(artifactPath string, edit MetadataEdit, create bool) error {
+	key := artifactKey(artifactPath)
+
+	// The version tag sets the version field rather than being stored as a tag
+	version, hasVersion := edit.Tags["version"]
+	if hasVersion {
+		if _, err := semver.Parse(version); err != nil {
+			return err
 		}
-		if err := yaml.Unmarshal(data, &artifactMetadata); err != nil {
-			return fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+
+	return modifyDescriptor(descriptorPath(artifactPath), func(descriptor *Descriptor) error {
+		artifactMetadata, ok := descriptor.Artifacts[key]
+		previousVersion, history := artifactMetadata.Version, artifactMetadata.History
+		switch {
+		case !ok && !create:
+			return fmt.Errorf("no metadata for %s, please tag the artifact first", key)
+		case !ok:
+			// No entry for this artifact yet, initialize new metadata
+			artifactMetadata = ArtifactMetadata{
+				Name:      key,
+				Version:   DefaultVersion,
+				CreatedAt: time.Now(),
+			}
+			if hasVersion {
+				artifactMetadata.Version = version
+			}
+		case hasVersion && version != artifactMetadata.Version:
+			// Snapshot the previous version before anything changes
+			if err := setVersion(&artifactMetadata, version); err != nil {
+				return err
+			}
 		}
-	} else {
-		// File doesn't exist, initialize new metadata
-		artifactMetadata = ArtifactMetadata{
-			Name:      filepath.Base(artifactPath),
-			Version:   "1.0",
-			CreatedAt: time.Now(),
-			Tags:      make(map[string]string),
+		if artifactMetadata.Tags == nil {
+			artifactMetadata.Tags = make(map[string]string)
 		}
-	}
 
-	// Update metadata
-	artifactMetadata.UpdatedAt = time.Now()
-	for key, value := range metadata {
-		artifactMetadata.Tags[key] = value
+		// Update metadata
+		for key, value := range edit.Tags {
+			if key != "version" {
+				artifactMetadata.Tags[key] = value
+			}
+		}
+		if len(edit.Remove) > 0 {
+			if err := removeTags(&artifactMetadata, edit.Remove); err != nil {
+				return err
+			}
+		}
+		if err := setFields(&artifactMetadata, edit.Fields); err != nil {
+			return err
+		}
+		if edit.Dataset != nil {
+			artifactMetadata.Dataset = edit.Dataset
+		}
+
+		// Record the content a new entry or version describes; otherwise the
+		// content must still be the one that was recorded
+		if !ok || edit.Retag || artifactMetadata.Version != previousVersion {
+			content, err := describeContent(artifactPath)
+			if err != nil {
+				return err
+			}
+			content.apply(&artifactMetadata)
+		} else if err := verifyContent(artifactPath, artifactMetadata); err != nil {
+			return err
+		}
+		if err := snapshotSBOMs(artifactPath, history, &artifactMetadata); err != nil {
+			return err
+		}
+
+		artifactMetadata.UpdatedAt = time.Now()
+		artifactMetadata.Revision++
+		descriptor.Artifacts[key] = artifactMetadata
+		return nil
+	})
+}
+
+// artifactContent is the size and digests of an artifact's current content.
+type artifactContent struct {
+	size     int64
+	digests  map[string]string
+	manifest []ManifestEntry
+}
+
+// verifyContent checks an artifact against the digests, or the manifest of a
+// bundle, recorded in its metadata. An entry without digests has nothing to
+// check against.
+func verifyContent(artifactPath string, artifactMetadata ArtifactMetadata) error {
+	if len(artifactMetadata.Digests) == 0 {
+		return nil
+	}
+	info, err := os.Stat(artifactPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat artifact: %w", err)
 	}
+	if info.IsDir() {
+		return verifyManifest(artifactPath, artifactMetadata)
+	}
+	return verifyDigests(artifactPath, artifactMetadata)
+}
 
-	// Write updated metadata to file
-	data, err := yaml.Marshal(artifactMetadata)
+// describeContent digests a file, or builds the manifest of a directory.
+func describeContent(artifactPath string) (artifactContent, error) {
+	info, err := os.Stat(artifactPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+		return artifactContent{}, fmt.Errorf("failed to stat artifact: %w", err)
 	}
-	if err := os.WriteFile(metadataFilePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write metadata file: %w", err)
+
+	var content artifactContent
+	switch {
+	case info.IsDir():
+		content.manifest, err = BuildManifest(artifactPath, DigestSHA256)
+		if err != nil {
+			return artifactContent{}, err
+		}
+		content.size, content.digests = manifestDigests(content.manifest)
+	case info.Mode().IsRegular():
+		content.size, content.digests, err = ComputeDigests(artifactPath, DigestSHA256)
+		if err != nil {
+			return artifactContent{}, err
+		}
 	}
+	return content, nil
+}
 
-	return nil
+func (c artifactContent) apply(artifactMetadata *ArtifactMetadata) {
+	if c.digests != nil {
+		artifactMetadata.Size = c.size
+		artifactMetadata.Digests = c.digests
+		artifactMetadata.Manifest = c.manifest
+	}
 }
 
 func TrackLineage(artifactPath string, details map[string]string) error {
-	metadataFilePath := filepath.Join(filepath.Dir(artifactPath), "ModelDescriptor.yaml")
+	return UpdateArtifactMetadata(artifactPath, func(artifactMetadata *ArtifactMetadata) error {
+		// Add new lineage entry
+		newEntry := LineageEntry{
+			Timestamp: time.Now(),
+			Action:    "Transformation",
+			Details:   details,
+		}
+		artifactMetadata.Lineage = append(artifactMetadata.Lineage, newEntry)
+		return nil
+	})
+}
 
-	var artifactMetadata ArtifactMetadata
-	if _, err := os.Stat(metadataFilePath); err == nil {
-		// File exists, read existing metadata
-		data, err := os.ReadFile(metadataFilePath)
-		if err != nil {
-			return fmt.Errorf("failed to read metadata file: %w", err)
+// UpdateArtifactMetadata applies update to the descriptor entry of an already
+// tagged artifact and writes the result back. The update runs against the
+// latest entry while the descriptor is locked, so concurrent updates merge.
+func UpdateArtifactMetadata(artifactPath string, update func(*ArtifactMetadata) error) error {
+	metadataFilePath := descriptorPath(artifactPath)
+	key := artifactKey(artifactPath)
+
+	if _, err := os.Stat(metadataFilePath); os.IsNotExist(err) {
+		return errors.New("metadata file not found, please tag the artifact first")
+	}
+
+	return modifyDescriptor(metadataFilePath, func(descriptor *Descriptor) error {
+		artifactMetadata, ok := descriptor.Artifacts[key]
+		if !ok {
+			return fmt.Errorf("no metadata for %s, please tag the artifact first", key)
 		}
-		if err := yaml.Unmarshal(data, &artifactMetadata); err != nil {
-			return fmt.Errorf("failed to unmarshal metadata: %w", err)
+
+		history := artifactMetadata.History
+		if err := update(&artifactMetadata); err != nil {
+			return err
 		}
-	} else {
-		return errors.New("metadata file not found, please tag the artifact first")
+		if err := keepHistory(history, &artifactMetadata); err != nil {
+			return err
+		}
+		if err := snapshotSBOMs(artifactPath, history, &artifactMetadata); err != nil {
+			return err
+		}
+		artifactMetadata.UpdatedAt = time.Now()
+		artifactMetadata.Revision++
+		descriptor.Artifacts[key] = artifactMetadata
+		return nil
+	})
+}
+
+// SaveArtifactMetadata replaces the artifact's descriptor entry with metadata.
+// metadata.Revision must equal the stored revision (zero for an artifact that
+// has no entry yet), meaning the entry has not changed since the caller read
+// it; otherwise ErrRevisionConflict is returned and nothing is written.
+func SaveArtifactMetadata(artifactPath string, metadata ArtifactMetadata) error {
+	key := artifactKey(artifactPath)
+
+	return modifyDescriptor(descriptorPath(artifactPath), func(descriptor *Descriptor) error {
+		stored := descriptor.Artifacts[key]
+		if stored.Revision != metadata.Revision {
+			return fmt.Errorf("%w: %s is at revision %d, update was based on revision %d",
+				ErrRevisionConflict, key, stored.Revision, metadata.Revision)
+		}
+
+		// History is append-only; a version change snapshots the stored entry
+		metadata.History = stored.History
+		if stored.Revision > 0 && metadata.Version != stored.Version {
+			if err := archiveVersion(stored, &metadata, metadata.Version); err != nil {
+				return err
+			}
+			if err := snapshotSBOMs(artifactPath, stored.History, &metadata); err != nil {
+				return err
+			}
+		}
+
+		metadata.UpdatedAt = time.Now()
+		metadata.Revision++
+		descriptor.Artifacts[key] = metadata
+		return nil
+	})
+}
+
+// UntagArtifact removes the given tag keys from the artifact's metadata.
+// Nothing is written if any of the keys is not set.
+func UntagArtifact(artifactPath string, keys []string) error {
+	return UpdateArtifactMetadata(artifactPath, func(artifactMetadata *ArtifactMetadata) error {
+		return removeTags(artifactMetadata, keys)
+	})
+}
+
+func removeTags(artifactMetadata *ArtifactMetadata, keys []string) error {
+	var missing []string
+	for _, key := range keys {
+		if _, ok := artifactMetadata.Tags[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("tags not found: %s", strings.Join(missing, ", "))
 	}
 
-	// Add new lineage entry
-	newEntry := LineageEntry{
-		Timestamp: time.Now(),
-		Action:    "Transformation",
-		Details:   details,
+	for _, key := range keys {
+		delete(artifactMetadata.Tags, key)
 	}
-	artifactMetadata.Lineage = append(artifactMetadata.Lineage, newEntry)
+	return nil
+}
+
+// SettableFields lists the core metadata fields that SetArtifactFields accepts.
+var SettableFields = []string{"name", "version", "type"}
 
-	// Write updated metadata to file
-	data, err := yaml.Marshal(artifactMetadata)
+// SetArtifactFields sets core metadata fields such as name, version and the
+// artifact type that selects the descriptor schema.
+func SetArtifactFields(artifactPath string, fields map[string]string) error {
+	return UpdateArtifactMetadata(artifactPath, func(artifactMetadata *ArtifactMetadata) error {
+		return setFields(artifactMetadata, fields)
+	})
+}
+
+func setFields(artifactMetadata *ArtifactMetadata, fields map[string]string) error {
+	for field, value := range fields {
+		if value == "" {
+			return fmt.Errorf("field %s cannot be empty", field)
+		}
+		switch field {
+		case "name":
+			artifactMetadata.Name = value
+		case "version":
+			if err := setVersion(artifactMetadata, value); err != nil {
+				return err
+			}
+		case "type":
+			artifactMetadata.Type = value
+		default:
+			return fmt.Errorf("unsupported field %q (supported: %s)", field, strings.Join(SettableFields, ", "))
+		}
+	}
+	return nil
+}
+
+// ReadTagsFile loads tags from a YAML or JSON file holding a flat mapping of
+// keys to scalar values.
+func ReadTagsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+		return nil, fmt.Errorf("failed to read tags file: %w", err)
 	}
-	if err := os.WriteFile(metadataFilePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write metadata file: %w", err)
+
+	// Decode into nodes so values keep their literal spelling (dates,
+	// versions such as 1.10) instead of being converted to Go types.
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tags file: %w", err)
 	}
 
-	return nil
+	tags := make(map[string]string, len(raw))
+	for key, node := range raw {
+		if node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("tag %q must be a scalar value", key)
+		}
+		if node.Tag == "!!null" {
+			tags[key] = ""
+			continue
+		}
+		tags[key] = node.Value
+	}
+	return tags, nil
 }
 
 func ValidateArtifact(artifactPath string) error {
 	// Check if the artifact file exists
-	if _, err := os.Stat(artifactPath); os.IsNotExist(err) {
+	info, err := os.Stat(artifactPath)
+	if os.IsNotExist(err) {
 		return fmt.Errorf("artifact file does not exist: %s", artifactPath)
 	}
+	if err != nil {
+		return fmt.Errorf("failed to stat artifact: %w", err)
+	}
 
 	// Check if the metadata file exists
-	metadataFilePath := filepath.Join(filepath.Dir(artifactPath), "ModelDescriptor.yaml")
+	metadataFilePath := descriptorPath(artifactPath)
 	if _, err := os.Stat(metadataFilePath); os.IsNotExist(err) {
 		return fmt.Errorf("metadata file does not exist: %s", metadataFilePath)
 	}
 
 	// Read and parse the metadata file
-	data, err := os.ReadFile(metadataFilePath)
+	descriptor, err := readDescriptor(metadataFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to read metadata file: %w", err)
+		return err
 	}
-
-	var artifactMetadata ArtifactMetadata
-	if err := yaml.Unmarshal(data, &artifactMetadata); err != nil {
-		return fmt.Errorf("failed to unmarshal metadata: %w", err)
+	artifactMetadata, ok := descriptor.Artifacts[artifactKey(artifactPath)]
+	if !ok {
+		return fmt.Errorf("no metadata for %s in %s", artifactKey(artifactPath), metadataFilePath)
 	}
 
-	// Perform basic validation checks
-	if artifactMetadata.Name == "" {
-		return errors.New("artifact name is missing in metadata")
+	// Check the entry against the descriptor schema for its type
+	if err := validateSchema(artifactPath, artifactMetadata); err != nil {
+		return err
 	}
-	if artifactMetadata.Version == "" {
-		return errors.New("artifact version is missing in metadata")
+
+	// Make sure the artifact is still the one that was tagged
+	if len(artifactMetadata.Digests) == 0 && (info.IsDir() || info.Mode().IsRegular()) {
+		return fmt.Errorf("%w: %s", ErrDigestMissing, artifactPath)
 	}
-	if artifactMetadata.CreatedAt.IsZero() {
-		return errors.New("artifact creation date is missing in metadata")
+	if err := verifyContent(artifactPath, artifactMetadata); err != nil {
+		return err
 	}
 
 	// Add more specific validation checks as needed for your use case
@@ -145,21 +417,19 @@ func ValidateArtifact(artifactPath string) error {
 // ... existing helper functions ...
 
 func GetArtifactMetadata(artifactPath string) (ArtifactMetadata, error) {
-	metadataFilePath := filepath.Join(filepath.Dir(artifactPath), "ModelDescriptor.yaml")
+	metadataFilePath := descriptorPath(artifactPath)
 
-	var artifactMetadata ArtifactMetadata
-	if _, err := os.Stat(metadataFilePath); err == nil {
-		// File exists, read existing metadata
-		data, err := os.ReadFile(metadataFilePath)
-		if err != nil {
-			return ArtifactMetadata{}, fmt.Errorf("failed to read metadata file: %w", err)
-		}
-		if err := yaml.Unmarshal(data, &artifactMetadata); err != nil {
-			return ArtifactMetadata{}, fmt.Errorf("failed to unmarshal metadata: %w", err)
-		}
-	} else {
+	if _, err := os.Stat(metadataFilePath); os.IsNotExist(err) {
 		return ArtifactMetadata{}, errors.New("metadata file not found")
 	}
+	descriptor, err := readDescriptor(metadataFilePath)
+	if err != nil {
+		return ArtifactMetadata{}, err
+	}
+	artifactMetadata, ok := descriptor.Artifacts[artifactKey(artifactPath)]
+	if !ok {
+		return ArtifactMetadata{}, fmt.Errorf("no metadata for %s in %s", artifactKey(artifactPath), metadataFilePath)
+	}
 
 	return artifactMetadata, nil
 }
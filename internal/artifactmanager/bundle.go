@@ -0,0 +1,162 @@
+package artifactmanager
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestEntry describes one file of a directory artifact (bundle).
+type ManifestEntry struct {
+	Path    string            `yaml:"path" json:"path"`
+	Size    int64             `yaml:"size" json:"size"`
+	Digests map[string]string `yaml:"digests" json:"digests"`
+}
+
+// BundleFiles returns the files that make up the bundle rooted at dir as
+// sorted, slash-separated paths relative to dir. Symbolic links to files are
+// followed; descriptors and their lock and temporary files are skipped so that
+// tagging files inside a bundle does not change the bundle itself.
+func BundleFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || isDescriptorFile(entry.Name()) {
+			return nil
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bundle files: %w", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isDescriptorFile reports whether name is a descriptor or one of the files
+// used while writing it.
+func isDescriptorFile(name string) bool {
+	return name == DescriptorFileName || strings.HasPrefix(name, "."+DescriptorFileName)
+}
+
+// BuildManifest computes the manifest of the bundle rooted at dir.
+func BuildManifest(dir string, algorithms ...string) ([]ManifestEntry, error) {
+	files, err := BundleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make([]ManifestEntry, 0, len(files))
+	for _, file := range files {
+		size, digests, err := ComputeDigests(filepath.Join(dir, filepath.FromSlash(file)), algorithms...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		manifest = append(manifest, ManifestEntry{Path: file, Size: size, Digests: digests})
+	}
+	return manifest, nil
+}
+
+// manifestDigests summarizes a manifest into the total size and one digest
+// per algorithm over its sorted "<digest>  <path>" lines, so a bundle has a
+// single identity like a file artifact does.
+func manifestDigests(manifest []ManifestEntry) (int64, map[string]string) {
+	var size int64
+	lines := make(map[string]*strings.Builder)
+	for _, entry := range manifest {
+		size += entry.Size
+		for algorithm, digest := range entry.Digests {
+			if lines[algorithm] == nil {
+				lines[algorithm] = &strings.Builder{}
+			}
+			fmt.Fprintf(lines[algorithm], "%s  %s\n", digest, entry.Path)
+		}
+	}
+
+	if len(manifest) == 0 {
+		lines[DigestSHA256] = &strings.Builder{}
+	}
+
+	digests := make(map[string]string, len(lines))
+	for algorithm, b := range lines {
+		h := digestAlgorithms[algorithm]()
+		h.Write([]byte(b.String()))
+		digests[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	return size, digests
+}
+
+// verifyManifest compares the bundle at dir with the manifest recorded in
+// metadata and reports every missing, unexpected or modified file.
+func verifyManifest(dir string, metadata ArtifactMetadata) error {
+	algorithms := []string{DigestSHA256}
+	if len(metadata.Manifest) > 0 {
+		algorithms = algorithms[:0]
+		for algorithm := range metadata.Manifest[0].Digests {
+			algorithms = append(algorithms, algorithm)
+		}
+		sort.Strings(algorithms)
+	}
+
+	current, err := BuildManifest(dir, algorithms...)
+	if err != nil {
+		return err
+	}
+
+	recorded := make(map[string]ManifestEntry, len(metadata.Manifest))
+	for _, entry := range metadata.Manifest {
+		recorded[entry.Path] = entry
+	}
+
+	var problems []string
+	for _, entry := range current {
+		expected, ok := recorded[entry.Path]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unexpected file %s", entry.Path))
+			continue
+		}
+		delete(recorded, entry.Path)
+		if entry.Size != expected.Size {
+			problems = append(problems, fmt.Sprintf("%s is %d bytes, manifest records %d", entry.Path, entry.Size, expected.Size))
+			continue
+		}
+		for _, algorithm := range algorithms {
+			if entry.Digests[algorithm] != expected.Digests[algorithm] {
+				problems = append(problems, fmt.Sprintf("%s %s digest changed", entry.Path, algorithm))
+				break
+			}
+		}
+	}
+	for _, entry := range metadata.Manifest {
+		if _, ok := recorded[entry.Path]; ok {
+			problems = append(problems, fmt.Sprintf("missing file %s", entry.Path))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: bundle %s: %s", ErrDigestMismatch, dir, strings.Join(problems, "; "))
+	}
+	return nil
+}
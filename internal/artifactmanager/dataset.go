@@ -0,0 +1,51 @@
+package artifactmanager
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DatasetSpec declares what a dataset artifact is expected to contain. It is
+// stored in the descriptor and checked by the dataset validator.
+type DatasetSpec struct {
+	// Format is csv, tsv, jsonl or parquet. It is inferred from the file
+	// extension when empty.
+	Format     string       `yaml:"format,omitempty" json:"format,omitempty"`
+	Columns    []ColumnSpec `yaml:"columns,omitempty" json:"columns,omitempty"`
+	PrimaryKey []string     `yaml:"primary_key,omitempty" json:"primary_key,omitempty"`
+	MinRows    *int64       `yaml:"min_rows,omitempty" json:"min_rows,omitempty"`
+	MaxRows    *int64       `yaml:"max_rows,omitempty" json:"max_rows,omitempty"`
+}
+
+// ColumnSpec declares an expected column. Type is one of string, integer,
+// number, boolean, timestamp or date; MaxNullRate is a fraction between 0
+// and 1.
+type ColumnSpec struct {
+	Name        string   `yaml:"name" json:"name"`
+	Type        string   `yaml:"type,omitempty" json:"type,omitempty"`
+	MaxNullRate *float64 `yaml:"max_null_rate,omitempty" json:"max_null_rate,omitempty"`
+}
+
+// ReadDatasetSpecFile loads a dataset spec from a YAML or JSON file.
+func ReadDatasetSpecFile(path string) (DatasetSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DatasetSpec{}, fmt.Errorf("failed to read dataset spec: %w", err)
+	}
+
+	var spec DatasetSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return DatasetSpec{}, fmt.Errorf("failed to unmarshal dataset spec: %w", err)
+	}
+	return spec, nil
+}
+
+// SetDatasetSpec stores the dataset spec in the artifact's descriptor entry.
+func SetDatasetSpec(artifactPath string, spec DatasetSpec) error {
+	return UpdateArtifactMetadata(artifactPath, func(artifactMetadata *ArtifactMetadata) error {
+		artifactMetadata.Dataset = &spec
+		return nil
+	})
+}
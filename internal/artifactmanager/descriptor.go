@@ -0,0 +1,177 @@
+package artifactmanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MChorfa/TraceSync/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// DescriptorFileName is the name of the descriptor kept in every directory
+// that holds tagged artifacts.
+const DescriptorFileName = "ModelDescriptor.yaml"
+
+// ProvenanceSuffix ends the name of the provenance written next to an
+// artifact, after the artifact's name.
+const ProvenanceSuffix = "-provenance.intoto.json"
+
+// Descriptor is the on-disk layout of ModelDescriptor.yaml. Metadata is keyed
+// by artifact file name so that weights, tokenizers and eval sets sharing a
+// directory each keep their own tags, version and lineage.
+type Descriptor struct {
+	Artifacts map[string]ArtifactMetadata `yaml:"artifacts"`
+}
+
+// descriptorPath returns the descriptor file that holds metadata for the artifact.
+func descriptorPath(artifactPath string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(artifactPath)), DescriptorFileName)
+}
+
+// artifactKey returns the key under which the artifact is stored in its descriptor.
+func artifactKey(artifactPath string) string {
+	return filepath.Base(filepath.Clean(artifactPath))
+}
+
+// readDescriptor loads the descriptor at path. Descriptors written in the
+// legacy single-artifact layout are migrated in memory; the new layout is
+// persisted on the next write.
+func readDescriptor(path string) (*Descriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+
+	if _, ok := raw["artifacts"]; ok || len(raw) == 0 {
+		var descriptor Descriptor
+		if err := yaml.Unmarshal(data, &descriptor); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		if descriptor.Artifacts == nil {
+			descriptor.Artifacts = make(map[string]ArtifactMetadata)
+		}
+		return &descriptor, nil
+	}
+
+	var legacy ArtifactMetadata
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	return migrateLegacyDescriptor(legacy), nil
+}
+
+// writeDescriptor marshals the descriptor and atomically replaces the file at
+// path, so a crash mid-write never leaves a truncated descriptor behind.
+func writeDescriptor(path string, descriptor *Descriptor) error {
+	data, err := yaml.Marshal(descriptor)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+	return nil
+}
+
+// descriptorLockPath returns the lock file guarding writes to the descriptor.
+func descriptorLockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+// modifyDescriptor runs fn against the latest content of the descriptor at
+// path while holding its advisory lock and writes the result back. A missing
+// descriptor is passed to fn as an empty one. Concurrent writers are
+// serialized, so each applies its change on top of the others' instead of
+// overwriting them.
+func modifyDescriptor(path string, fn func(*Descriptor) error) error {
+	lock, err := utils.LockFile(descriptorLockPath(path))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	descriptor := &Descriptor{Artifacts: make(map[string]ArtifactMetadata)}
+	if _, err := os.Stat(path); err == nil {
+		if descriptor, err = readDescriptor(path); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(descriptor); err != nil {
+		return err
+	}
+	return writeDescriptor(path, descriptor)
+}
+
+// migrateLegacyDescriptor converts a shared descriptor into the per-artifact
+// layout. The entry is kept only for the artifact it was written for, named
+// in it; other files in the directory were never tagged themselves and stay
+// untagged rather than inheriting its version, tags and digests.
+func migrateLegacyDescriptor(legacy ArtifactMetadata) *Descriptor {
+	descriptor := &Descriptor{Artifacts: make(map[string]ArtifactMetadata)}
+	if legacy.Name != "" {
+		descriptor.Artifacts[legacy.Name] = copyMetadata(legacy, legacy.Name)
+	}
+	return descriptor
+}
+
+// copyMetadata returns a deep copy of metadata renamed to name.
+func copyMetadata(metadata ArtifactMetadata, name string) ArtifactMetadata {
+	copied := metadata
+	copied.Name = name
+	copied.Tags = make(map[string]string, len(metadata.Tags))
+	for key, value := range metadata.Tags {
+		copied.Tags[key] = value
+	}
+	copied.Lineage = append([]LineageEntry(nil), metadata.Lineage...)
+	return copied
+}
+
+// MigrateDescriptor rewrites a legacy shared descriptor in dir using the
+// per-artifact layout. Descriptors already in the new layout are rewritten
+// unchanged.
+func MigrateDescriptor(dir string) error {
+	path := filepath.Join(dir, DescriptorFileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("metadata file does not exist: %s", path)
+	}
+
+	return modifyDescriptor(path, func(*Descriptor) error {
+		return nil
+	})
+}
+
+// ListArtifacts walks root and returns the metadata of every artifact found
+// in descriptors below it, keyed by artifact path.
+func ListArtifacts(root string) (map[string]ArtifactMetadata, error) {
+	artifacts := make(map[string]ArtifactMetadata)
+
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || entry.Name() != DescriptorFileName {
+			return nil
+		}
+
+		descriptor, err := readDescriptor(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for key, metadata := range descriptor.Artifacts {
+			artifacts[filepath.Join(filepath.Dir(path), key)] = metadata
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for descriptors: %w", err)
+	}
+
+	return artifacts, nil
+}
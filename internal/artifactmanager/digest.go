@@ -0,0 +1,93 @@
+package artifactmanager
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+)
+
+// DigestSHA256 is the digest algorithm recorded for every tagged artifact.
+const DigestSHA256 = "sha256"
+
+// ErrDigestMismatch is returned when an artifact no longer matches the size
+// or digests recorded in its descriptor.
+var ErrDigestMismatch = errors.New("artifact content has drifted from its descriptor")
+
+// ErrDigestMissing is returned when a descriptor entry has no digests to
+// check the artifact against, as with entries migrated from older layouts.
+var ErrDigestMissing = errors.New("descriptor records no digest for the artifact")
+
+// digestAlgorithms maps the algorithm names used in descriptors to their
+// hash constructors.
+var digestAlgorithms = map[string]func() hash.Hash{
+	DigestSHA256: sha256.New,
+	"sha512":     sha512.New,
+}
+
+// ComputeDigests reads the file at path once and returns its size together
+// with a hex-encoded digest for each requested algorithm.
+func ComputeDigests(path string, algorithms ...string) (int64, map[string]string, error) {
+	if len(algorithms) == 0 {
+		algorithms = []string{DigestSHA256}
+	}
+
+	hashes := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		newHash, ok := digestAlgorithms[algorithm]
+		if !ok {
+			return 0, nil, fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+		}
+		hashes[algorithm] = newHash()
+		writers = append(writers, hashes[algorithm])
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer file.Close()
+
+	size, err := io.Copy(io.MultiWriter(writers...), file)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+
+	digests := make(map[string]string, len(hashes))
+	for algorithm, h := range hashes {
+		digests[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	return size, digests, nil
+}
+
+// verifyDigests recomputes the digests recorded in metadata and reports any
+// drift as an ErrDigestMismatch.
+func verifyDigests(artifactPath string, metadata ArtifactMetadata) error {
+	algorithms := make([]string, 0, len(metadata.Digests))
+	for algorithm := range metadata.Digests {
+		algorithms = append(algorithms, algorithm)
+	}
+	sort.Strings(algorithms)
+
+	size, digests, err := ComputeDigests(artifactPath, algorithms...)
+	if err != nil {
+		return err
+	}
+
+	if size != metadata.Size {
+		return fmt.Errorf("%w: %s is %d bytes, descriptor records %d", ErrDigestMismatch, artifactPath, size, metadata.Size)
+	}
+	for _, algorithm := range algorithms {
+		if digests[algorithm] != metadata.Digests[algorithm] {
+			return fmt.Errorf("%w: %s %s is %s, descriptor records %s",
+				ErrDigestMismatch, artifactPath, algorithm, digests[algorithm], metadata.Digests[algorithm])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package artifactmanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/utils"
+)
+
+// Suffixes end the names of the SBOMs written next to an artifact, after the
+// artifact's name: CycloneDX (and legacy), SPDX JSON and SPDX tag-value.
+const (
+	SBOMSuffix             = "-sbom.json"
+	SPDXJSONSBOMSuffix     = "-sbom.spdx.json"
+	SPDXTagValueSBOMSuffix = "-sbom.spdx"
+)
+
+// ImportedSBOM records an SBOM supplied for an artifact, such as one from
+// the vendor of a third-party artifact. It is used in place of the SBOM
+// TraceSync would generate from the artifact's content.
+type ImportedSBOM struct {
+	// File is the name of the copy of the SBOM kept next to the artifact.
+	File string `yaml:"file" json:"file"`
+	// Source is the path the SBOM was imported from.
+	Source     string            `yaml:"source" json:"source"`
+	Format     string            `yaml:"format" json:"format"`
+	Digests    map[string]string `yaml:"digests" json:"digests"`
+	ImportedAt time.Time         `yaml:"imported_at" json:"imported_at"`
+}
+
+// SetImportedSBOM stores the imported SBOM in the artifact's descriptor
+// entry, replacing any previous one.
+func SetImportedSBOM(artifactPath string, sbom ImportedSBOM) error {
+	return UpdateArtifactMetadata(artifactPath, func(artifactMetadata *ArtifactMetadata) error {
+		artifactMetadata.SBOM = &sbom
+		return nil
+	})
+}
+
+// SBOMSnapshot is a copy of the SBOM an artifact had when one of its
+// versions was archived, so that versions can still be compared after the
+// SBOM next to the artifact is regenerated.
+type SBOMSnapshot struct {
+	// File is the name of the copy, kept next to the artifact.
+	File    string            `yaml:"file" json:"file"`
+	Size    int64             `yaml:"size" json:"size"`
+	Digests map[string]string `yaml:"digests" json:"digests"`
+}
+
+// currentSBOM returns the path of the SBOM in use for an artifact: the
+// imported one, otherwise a generated one in any format.
+func currentSBOM(artifactPath string, artifactMetadata ArtifactMetadata) (string, bool) {
+	dir := filepath.Dir(filepath.Clean(artifactPath))
+	candidates := []string{
+		artifactMetadata.Name + SBOMSuffix,
+		artifactMetadata.Name + SPDXJSONSBOMSuffix,
+		artifactMetadata.Name + SPDXTagValueSBOMSuffix,
+	}
+	if artifactMetadata.SBOM != nil {
+		candidates = []string{artifactMetadata.SBOM.File}
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// snapshotSBOMs copies the current SBOM for every version that was archived
+// since history, naming the copy <artifact>@<version> plus the SBOM's suffix.
+// Versions archived while the artifact had no SBOM get no snapshot.
+func snapshotSBOMs(artifactPath string, history []VersionRecord, artifactMetadata *ArtifactMetadata) error {
+	for i := len(history); i < len(artifactMetadata.History); i++ {
+		record := &artifactMetadata.History[i]
+		source, ok := currentSBOM(artifactPath, record.ArtifactMetadata)
+		if !ok || record.SBOMSnapshot != nil {
+			continue
+		}
+		suffix := strings.TrimPrefix(filepath.Base(source), record.Name)
+		file := artifactKey(artifactPath) + "@" + record.Version + suffix
+
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM: %w", err)
+		}
+		target := filepath.Join(filepath.Dir(source), file)
+		if err := utils.WriteFileAtomic(target, data, 0644); err != nil {
+			return fmt.Errorf("failed to write SBOM snapshot: %w", err)
+		}
+		size, digests, err := ComputeDigests(target, DigestSHA256)
+		if err != nil {
+			return err
+		}
+		record.SBOMSnapshot = &SBOMSnapshot{File: file, Size: size, Digests: digests}
+	}
+	return nil
+}
+
+// GetVersionSBOM returns the path of the SBOM snapshot recorded when the
+// given version of the artifact was archived, after checking it has not
+// changed since.
+func GetVersionSBOM(artifactPath string, version string) (string, error) {
+	artifactMetadata, err := GetArtifactMetadata(artifactPath)
+	if err != nil {
+		return "", err
+	}
+	for _, record := range artifactMetadata.History {
+		if record.Version != version {
+			continue
+		}
+		if record.SBOMSnapshot == nil {
+			return "", fmt.Errorf("no SBOM was recorded for version %s of %s", version, artifactKey(artifactPath))
+		}
+		path := filepath.Join(filepath.Dir(filepath.Clean(artifactPath)), record.SBOMSnapshot.File)
+		if err := verifyDigests(path, ArtifactMetadata{Size: record.SBOMSnapshot.Size, Digests: record.SBOMSnapshot.Digests}); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("%w: %s has no archived version %s", ErrVersionNotFound, artifactKey(artifactPath), version)
+}
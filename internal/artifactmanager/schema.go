@@ -0,0 +1,96 @@
+package artifactmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/MChorfa/TraceSync/internal/schema"
+)
+
+var (
+	schemasMu         sync.RWMutex
+	descriptorSchemas = schema.NewRegistry()
+)
+
+// SetDescriptorSchemas replaces the schemas ValidateArtifact checks
+// descriptor entries against. By default only the built-in schema requiring
+// name, version and created_at is used.
+func SetDescriptorSchemas(registry *schema.Registry) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	descriptorSchemas = registry
+}
+
+// SchemaError lists every descriptor schema violation of an artifact.
+type SchemaError struct {
+	Artifact   string
+	Type       string
+	Violations []schema.Violation
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return fmt.Sprintf("metadata of %s violates the %s descriptor schema: %s", e.Artifact, e.Type, strings.Join(messages, "; "))
+}
+
+// ArtifactType returns the type used to select the descriptor schema.
+func ArtifactType(artifactMetadata ArtifactMetadata) string {
+	if artifactMetadata.Type == "" {
+		return schema.DefaultType
+	}
+	return artifactMetadata.Type
+}
+
+// validateSchema checks a descriptor entry against the configured schemas.
+func validateSchema(artifactPath string, artifactMetadata ArtifactMetadata) error {
+	document, err := metadataDocument(artifactMetadata)
+	if err != nil {
+		return err
+	}
+
+	schemasMu.RLock()
+	registry := descriptorSchemas
+	schemasMu.RUnlock()
+
+	artifactType := ArtifactType(artifactMetadata)
+	if violations := registry.Validate(artifactType, document); len(violations) > 0 {
+		return &SchemaError{Artifact: artifactPath, Type: artifactType, Violations: violations}
+	}
+	return nil
+}
+
+// metadataDocument converts a descriptor entry to the generic JSON form
+// schemas are evaluated on. Empty strings and unset timestamps are left out
+// so that "required" treats them as missing.
+func metadataDocument(artifactMetadata ArtifactMetadata) (map[string]interface{}, error) {
+	data, err := json.Marshal(artifactMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+
+	for key, value := range document {
+		if value == "" || value == nil {
+			delete(document, key)
+		}
+	}
+	if artifactMetadata.CreatedAt.IsZero() {
+		delete(document, "created_at")
+	}
+	if artifactMetadata.UpdatedAt.IsZero() {
+		delete(document, "updated_at")
+	}
+	// Rules about tags apply even before the first tag is added
+	if _, ok := document["tags"]; !ok {
+		document["tags"] = map[string]interface{}{}
+	}
+	return document, nil
+}
@@ -0,0 +1,137 @@
+package artifactmanager
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/semver"
+)
+
+// DefaultVersion is the version given to newly tagged artifacts.
+const DefaultVersion = "1.0.0"
+
+// ErrVersionNotFound is returned when an artifact has no such version.
+var ErrVersionNotFound = errors.New("version not found")
+
+// VersionRecord is an immutable snapshot of a descriptor entry taken when the
+// artifact moved on to a new version, together with a copy of its SBOM. Lineage stays on the current entry,
+// which keeps the complete record.
+type VersionRecord struct {
+	ArtifactMetadata `yaml:",inline"`
+	ArchivedAt       time.Time `yaml:"archived_at" json:"archived_at"`
+	// SBOMSnapshot is the SBOM the artifact had when the version was
+	// archived, if it had one.
+	SBOMSnapshot *SBOMSnapshot `yaml:"sbom_snapshot,omitempty" json:"sbom_snapshot,omitempty"`
+}
+
+// BumpVersion increments the major, minor or patch part of the artifact's
+// version, records the digests of its current content and archives the
+// previous version. It returns the new version.
+func BumpVersion(artifactPath string, part string) (string, error) {
+	content, err := describeContent(artifactPath)
+	if err != nil {
+		return "", err
+	}
+
+	var bumped string
+	err = UpdateArtifactMetadata(artifactPath, func(artifactMetadata *ArtifactMetadata) error {
+		current, err := semver.Parse(artifactMetadata.Version)
+		if err != nil {
+			return fmt.Errorf("cannot bump %s: %w; set a semantic version first", artifactMetadata.Name, err)
+		}
+		next, err := current.Bump(part)
+		if err != nil {
+			return err
+		}
+		if err := setVersion(artifactMetadata, next.String()); err != nil {
+			return err
+		}
+		content.apply(artifactMetadata)
+		bumped = next.String()
+		return nil
+	})
+	return bumped, err
+}
+
+// GetArtifactVersion returns the metadata of the current version or of an
+// archived one.
+func GetArtifactVersion(artifactPath string, version string) (ArtifactMetadata, error) {
+	artifactMetadata, err := GetArtifactMetadata(artifactPath)
+	if err != nil {
+		return ArtifactMetadata{}, err
+	}
+	if artifactMetadata.Version == version {
+		return artifactMetadata, nil
+	}
+	for _, record := range artifactMetadata.History {
+		if record.Version == version {
+			return record.ArtifactMetadata, nil
+		}
+	}
+	return ArtifactMetadata{}, fmt.Errorf("%w: %s has no version %s", ErrVersionNotFound, artifactKey(artifactPath), version)
+}
+
+// setVersion moves an entry to a new version, archiving the current one.
+func setVersion(artifactMetadata *ArtifactMetadata, version string) error {
+	if version == artifactMetadata.Version {
+		return nil
+	}
+	return archiveVersion(*artifactMetadata, artifactMetadata, version)
+}
+
+// archiveVersion validates version and appends a snapshot of previous to the
+// history of current, which then takes the new version. Versions must be
+// semantic versions and move forward.
+func archiveVersion(previous ArtifactMetadata, current *ArtifactMetadata, version string) error {
+	next, err := semver.Parse(version)
+	if err != nil {
+		return err
+	}
+	if prior, err := semver.Parse(previous.Version); err == nil && semver.Compare(next, prior) <= 0 {
+		return fmt.Errorf("version %s must be greater than the current version %s", version, previous.Version)
+	}
+	for _, record := range previous.History {
+		if record.Version == version {
+			return fmt.Errorf("version %s already exists in the history of %s", version, previous.Name)
+		}
+	}
+
+	current.History = append(previous.History, VersionRecord{
+		ArtifactMetadata: snapshot(previous),
+		ArchivedAt:       time.Now(),
+	})
+	current.Version = version
+	return nil
+}
+
+// snapshot copies an entry without its history and lineage, so later
+// changes to the entry cannot alter the archived record.
+func snapshot(artifactMetadata ArtifactMetadata) ArtifactMetadata {
+	artifactMetadata.History = nil
+	artifactMetadata.Lineage = nil
+	artifactMetadata.Tags = copyStringMap(artifactMetadata.Tags)
+	artifactMetadata.Digests = copyStringMap(artifactMetadata.Digests)
+	artifactMetadata.Manifest = append([]ManifestEntry(nil), artifactMetadata.Manifest...)
+	return artifactMetadata
+}
+
+// keepHistory makes sure an update only appended to the version history.
+func keepHistory(history []VersionRecord, artifactMetadata *ArtifactMetadata) error {
+	if len(artifactMetadata.History) < len(history) {
+		return errors.New("version history cannot be modified")
+	}
+	artifactMetadata.History = append(append([]VersionRecord(nil), history...), artifactMetadata.History[len(history):]...)
+	return nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
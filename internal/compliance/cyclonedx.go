@@ -0,0 +1,239 @@
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CycloneDX 1.5 JSON document structure. Only the parts TraceSync produces
+// are modelled; see https://cyclonedx.org/docs/1.5/json/.
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber,omitempty"`
+	Version      int             `json:"version"`
+	Metadata     *cdxMetadata    `json:"metadata,omitempty"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies,omitempty"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp,omitempty"`
+	Tools     *cdxTools     `json:"tools,omitempty"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxComponent struct {
+	BOMRef      string             `json:"bom-ref,omitempty"`
+	Type        string             `json:"type"`
+	Name        string             `json:"name"`
+	Version     string             `json:"version,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Hashes      []cdxHash          `json:"hashes,omitempty"`
+	Licenses    []cdxLicenseChoice `json:"licenses,omitempty"`
+	PURL        string             `json:"purl,omitempty"`
+	Properties  []cdxProperty      `json:"properties,omitempty"`
+	ModelCard   *cdxModelCard      `json:"modelCard,omitempty"`
+	Data        []cdxData          `json:"data,omitempty"`
+	Components  []cdxComponent     `json:"components,omitempty"`
+}
+
+type cdxModelCard struct {
+	ModelParameters      *cdxModelParameters      `json:"modelParameters,omitempty"`
+	QuantitativeAnalysis *cdxQuantitativeAnalysis `json:"quantitativeAnalysis,omitempty"`
+	Properties           []cdxProperty            `json:"properties,omitempty"`
+}
+
+type cdxModelParameters struct {
+	Approach           *cdxApproach    `json:"approach,omitempty"`
+	Task               string          `json:"task,omitempty"`
+	ArchitectureFamily string          `json:"architectureFamily,omitempty"`
+	ModelArchitecture  string          `json:"modelArchitecture,omitempty"`
+	Datasets           []cdxDatasetRef `json:"datasets,omitempty"`
+}
+
+type cdxApproach struct {
+	Type string `json:"type"`
+}
+
+type cdxDatasetRef struct {
+	Ref string `json:"ref"`
+}
+
+type cdxQuantitativeAnalysis struct {
+	PerformanceMetrics []cdxPerformanceMetric `json:"performanceMetrics"`
+}
+
+type cdxPerformanceMetric struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type cdxData struct {
+	Type           string `json:"type"`
+	Name           string `json:"name,omitempty"`
+	Classification string `json:"classification,omitempty"`
+	Description    string `json:"description,omitempty"`
+}
+
+type cdxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+// cdxLicenseChoice holds either a single license or an SPDX expression.
+type cdxLicenseChoice struct {
+	License    *cdxLicense `json:"license,omitempty"`
+	Expression string      `json:"expression,omitempty"`
+}
+
+type cdxLicense struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// propertyHashPrefix names the properties holding hashes that CycloneDX has
+// no algorithm for.
+const propertyHashPrefix = "tracesync:hash:"
+
+// encodeCycloneDX renders an SBOM as a CycloneDX 1.5 JSON document.
+func encodeCycloneDX(sbom *SBOM) ([]byte, error) {
+	subject := toCycloneDXComponent(sbom.Subject)
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: sbom.SerialNumber,
+		Version:      1,
+		Metadata: &cdxMetadata{
+			Timestamp: sbom.CreatedAt.UTC().Format(time.RFC3339),
+			Tools: &cdxTools{Components: []cdxComponent{{
+				Type:    ComponentApplication,
+				Name:    ToolName,
+				Version: toolVersion(),
+			}}},
+			Component: &subject,
+		},
+		Components: []cdxComponent{},
+	}
+	for _, component := range sbom.Components {
+		bom.Components = append(bom.Components, toCycloneDXComponent(component))
+	}
+	for _, dependency := range sbom.Dependencies {
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: dependency.Ref, DependsOn: dependency.DependsOn})
+	}
+
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SBOM to JSON: %w", err)
+	}
+	return data, nil
+}
+
+func toCycloneDXComponent(component Component) cdxComponent {
+	converted := cdxComponent{
+		BOMRef:      component.BOMRef,
+		Type:        component.Type,
+		Name:        component.Name,
+		Version:     component.Version,
+		Description: component.Description,
+		PURL:        component.PURL,
+	}
+	if converted.Type == "" {
+		converted.Type = ComponentLibrary
+	}
+	standard, other := sortedHashes(component.Hashes)
+	for _, algorithm := range standard {
+		converted.Hashes = append(converted.Hashes, cdxHash{
+			Algorithm: hashAlgorithms[strings.ToLower(algorithm)],
+			Content:   component.Hashes[algorithm],
+		})
+	}
+	if component.License != "" {
+		converted.Licenses = []cdxLicenseChoice{cycloneDXLicense(component.License)}
+	}
+	for _, name := range sortedProperties(component.Properties) {
+		converted.Properties = append(converted.Properties, cdxProperty{Name: name, Value: component.Properties[name]})
+	}
+	// CycloneDX has no algorithm for hashes such as Go's h1
+	for _, algorithm := range other {
+		converted.Properties = append(converted.Properties, cdxProperty{
+			Name:  propertyHashPrefix + algorithm,
+			Value: component.Hashes[algorithm],
+		})
+	}
+	if component.ModelCard != nil {
+		converted.ModelCard = toCycloneDXModelCard(component.ModelCard)
+	}
+	if data := component.Data; data != nil {
+		converted.Data = []cdxData{{Type: data.Type, Name: data.Name, Classification: data.Classification, Description: data.Description}}
+	}
+	for _, child := range component.Components {
+		converted.Components = append(converted.Components, toCycloneDXComponent(child))
+	}
+	return converted
+}
+
+// hyperparameterPropertyPrefix names the model card properties holding
+// hyperparameters, which CycloneDX has no field for.
+const hyperparameterPropertyPrefix = "tracesync:hyperparameter:"
+
+func toCycloneDXModelCard(card *ModelCard) *cdxModelCard {
+	converted := &cdxModelCard{}
+	parameters := cdxModelParameters{
+		Task:               card.Task,
+		ArchitectureFamily: card.ArchitectureFamily,
+		ModelArchitecture:  card.Architecture,
+	}
+	if card.Approach != "" {
+		parameters.Approach = &cdxApproach{Type: card.Approach}
+	}
+	for _, ref := range card.Datasets {
+		parameters.Datasets = append(parameters.Datasets, cdxDatasetRef{Ref: ref})
+	}
+	if parameters.Approach != nil || parameters.Task != "" || parameters.ArchitectureFamily != "" ||
+		parameters.ModelArchitecture != "" || len(parameters.Datasets) > 0 {
+		converted.ModelParameters = &parameters
+	}
+	if len(card.Metrics) > 0 {
+		converted.QuantitativeAnalysis = &cdxQuantitativeAnalysis{}
+		for _, name := range sortedProperties(card.Metrics) {
+			converted.QuantitativeAnalysis.PerformanceMetrics = append(converted.QuantitativeAnalysis.PerformanceMetrics,
+				cdxPerformanceMetric{Type: name, Value: card.Metrics[name]})
+		}
+	}
+	for _, name := range sortedProperties(card.Hyperparameters) {
+		converted.Properties = append(converted.Properties, cdxProperty{
+			Name:  hyperparameterPropertyPrefix + name,
+			Value: card.Hyperparameters[name],
+		})
+	}
+	return converted
+}
+
+// cycloneDXLicense picks the license form CycloneDX expects: an SPDX id, an
+// SPDX expression, or a free-form name.
+func cycloneDXLicense(license string) cdxLicenseChoice {
+	if IsSPDXLicenseID(license) {
+		return cdxLicenseChoice{License: &cdxLicense{ID: license}}
+	}
+	if expression, ok := spdxExpression(license); ok {
+		return cdxLicenseChoice{Expression: expression}
+	}
+	return cdxLicenseChoice{License: &cdxLicense{Name: license}}
+}
@@ -0,0 +1,501 @@
+package compliance
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LoadSBOM reads an SBOM file in any of the supported formats.
+func LoadSBOM(path string) (*SBOM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+	sbom, _, err := DecodeSBOM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode SBOM %s: %w", path, err)
+	}
+	return sbom, nil
+}
+
+// DecodeSBOM parses an SBOM document, detecting its format: CycloneDX JSON,
+// SPDX JSON or tag-value, or TraceSync's legacy JSON.
+func DecodeSBOM(data []byte) (*SBOM, Format, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		if !bytes.Contains(trimmed, []byte("SPDXVersion:")) {
+			return nil, "", fmt.Errorf("unrecognized SBOM format")
+		}
+		doc, err := parseSPDXTagValue(trimmed)
+		if err != nil {
+			return nil, "", err
+		}
+		return decodeSPDX(doc), FormatSPDXTagValue, nil
+	}
+
+	var probe struct {
+		BOMFormat   string          `json:"bomFormat"`
+		SPDXVersion string          `json:"spdxVersion"`
+		Components  json.RawMessage `json:"components"`
+	}
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return nil, "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch {
+	case probe.BOMFormat == "CycloneDX":
+		var bom cdxBOM
+		if err := json.Unmarshal(trimmed, &bom); err != nil {
+			return nil, "", fmt.Errorf("invalid CycloneDX document: %w", err)
+		}
+		return decodeCycloneDX(&bom), FormatCycloneDX, nil
+	case probe.SPDXVersion != "":
+		var doc spdxDocument
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, "", fmt.Errorf("invalid SPDX document: %w", err)
+		}
+		return decodeSPDX(&doc), FormatSPDXJSON, nil
+	case probe.Components != nil:
+		var legacy legacySBOM
+		if err := json.Unmarshal(trimmed, &legacy); err != nil {
+			return nil, "", fmt.Errorf("invalid TraceSync SBOM: %w", err)
+		}
+		return decodeLegacy(&legacy), FormatLegacy, nil
+	}
+	return nil, "", fmt.Errorf("unrecognized SBOM format")
+}
+
+func decodeCycloneDX(bom *cdxBOM) *SBOM {
+	sbom := &SBOM{SerialNumber: bom.SerialNumber}
+	if metadata := bom.Metadata; metadata != nil {
+		sbom.CreatedAt, _ = time.Parse(time.RFC3339, metadata.Timestamp)
+		if metadata.Component != nil {
+			sbom.Subject = fromCycloneDXComponent(*metadata.Component)
+		}
+	}
+	sbom.Name = sbom.Subject.Name
+	sbom.Version = sbom.Subject.Version
+	sbom.Description = sbom.Subject.Description
+	for _, component := range bom.Components {
+		sbom.Components = append(sbom.Components, fromCycloneDXComponent(component))
+	}
+	for _, dependency := range bom.Dependencies {
+		sbom.Dependencies = append(sbom.Dependencies, Dependency{Ref: dependency.Ref, DependsOn: dependency.DependsOn})
+	}
+	return sbom
+}
+
+func fromCycloneDXComponent(converted cdxComponent) Component {
+	component := Component{
+		BOMRef:      converted.BOMRef,
+		Type:        converted.Type,
+		Name:        converted.Name,
+		Version:     converted.Version,
+		Description: converted.Description,
+		PURL:        converted.PURL,
+	}
+	for _, hash := range converted.Hashes {
+		setHash(&component, digestAlgorithm(hash.Algorithm), hash.Content)
+	}
+	var licenses []string
+	for _, choice := range converted.Licenses {
+		switch {
+		case choice.Expression != "":
+			licenses = append(licenses, choice.Expression)
+		case choice.License != nil && choice.License.ID != "":
+			licenses = append(licenses, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			licenses = append(licenses, choice.License.Name)
+		}
+	}
+	component.License = strings.Join(licenses, " AND ")
+	for _, property := range converted.Properties {
+		if algorithm, ok := strings.CutPrefix(property.Name, propertyHashPrefix); ok {
+			setHash(&component, algorithm, property.Value)
+			continue
+		}
+		if component.Properties == nil {
+			component.Properties = make(map[string]string)
+		}
+		component.Properties[property.Name] = property.Value
+	}
+	if card := converted.ModelCard; card != nil {
+		component.ModelCard = fromCycloneDXModelCard(card)
+	}
+	if len(converted.Data) > 0 {
+		data := converted.Data[0]
+		component.Data = &Data{Type: data.Type, Name: data.Name, Classification: data.Classification, Description: data.Description}
+	}
+	for _, child := range converted.Components {
+		component.Components = append(component.Components, fromCycloneDXComponent(child))
+	}
+	return component
+}
+
+func fromCycloneDXModelCard(converted *cdxModelCard) *ModelCard {
+	card := &ModelCard{}
+	if parameters := converted.ModelParameters; parameters != nil {
+		card.Task = parameters.Task
+		card.ArchitectureFamily = parameters.ArchitectureFamily
+		card.Architecture = parameters.ModelArchitecture
+		if parameters.Approach != nil {
+			card.Approach = parameters.Approach.Type
+		}
+		for _, dataset := range parameters.Datasets {
+			card.Datasets = append(card.Datasets, dataset.Ref)
+		}
+	}
+	if analysis := converted.QuantitativeAnalysis; analysis != nil {
+		for _, metric := range analysis.PerformanceMetrics {
+			if card.Metrics == nil {
+				card.Metrics = make(map[string]string)
+			}
+			card.Metrics[metric.Type] = metric.Value
+		}
+	}
+	for _, property := range converted.Properties {
+		if name, ok := strings.CutPrefix(property.Name, hyperparameterPropertyPrefix); ok {
+			if card.Hyperparameters == nil {
+				card.Hyperparameters = make(map[string]string)
+			}
+			card.Hyperparameters[name] = property.Value
+		}
+	}
+	return card
+}
+
+// digestAlgorithm maps a hash algorithm name of an SBOM format, such as
+// SHA-256 or SHA256, to TraceSync's name for it.
+func digestAlgorithm(name string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	for algorithm, formatName := range hashAlgorithms {
+		if normalized == strings.ToLower(strings.ReplaceAll(formatName, "-", "")) {
+			return algorithm
+		}
+	}
+	return normalized
+}
+
+func setHash(component *Component, algorithm, value string) {
+	if value == "" {
+		return
+	}
+	if component.Hashes == nil {
+		component.Hashes = make(map[string]string)
+	}
+	component.Hashes[algorithm] = value
+}
+
+func decodeLegacy(legacy *legacySBOM) *SBOM {
+	sbom := &SBOM{
+		Name:        legacy.Name,
+		Version:     legacy.Version,
+		Description: legacy.Description,
+		CreatedAt:   legacy.CreatedAt,
+		Subject:     Component{Name: legacy.Name, Version: legacy.Version, Description: legacy.Description},
+	}
+	for _, component := range legacy.Components {
+		sbom.Components = append(sbom.Components, Component{
+			Type:    component.Type,
+			Name:    component.Name,
+			Version: component.Version,
+			License: component.License,
+		})
+	}
+	return sbom
+}
+
+// spdxTypes maps SPDX primary package purposes to component types.
+var spdxTypes = func() map[string]string {
+	types := make(map[string]string, len(spdxPurposes))
+	for componentType, purpose := range spdxPurposes {
+		types[purpose] = componentType
+	}
+	return types
+}()
+
+// decodeSPDX converts an SPDX document. The described package becomes the
+// subject; CONTAINS relationships nest packages and DEPENDS_ON ones become
+// dependencies, with SPDX identifiers as bom-refs.
+func decodeSPDX(doc *spdxDocument) *SBOM {
+	sbom := &SBOM{}
+	sbom.CreatedAt, _ = time.Parse(time.RFC3339, doc.CreationInfo.Created)
+
+	extracted := make(map[string]string)
+	for _, license := range doc.ExtractedLicenses {
+		name := license.Name
+		if name == "" || name == noAssertion {
+			name = license.ExtractedText
+		}
+		extracted[license.LicenseID] = name
+	}
+
+	packages := make(map[string]spdxPackage, len(doc.Packages))
+	for _, pkg := range doc.Packages {
+		packages[pkg.SPDXID] = pkg
+	}
+
+	subject := ""
+	if len(doc.DocumentDescribes) > 0 {
+		subject = doc.DocumentDescribes[0]
+	}
+	children := make(map[string][]string)
+	contained := make(map[string]bool)
+	var dependencies []Dependency
+	dependencyIndex := make(map[string]int)
+	dependsOn := func(from, to string) {
+		i, ok := dependencyIndex[from]
+		if !ok {
+			i = len(dependencies)
+			dependencyIndex[from] = i
+			dependencies = append(dependencies, Dependency{Ref: from})
+		}
+		dependencies[i].DependsOn = append(dependencies[i].DependsOn, to)
+	}
+	for _, relationship := range doc.Relationships {
+		from, to := relationship.SPDXElementID, relationship.RelatedSPDXElement
+		switch relationship.RelationshipType {
+		case "DESCRIBES":
+			if from == doc.SPDXID && subject == "" {
+				subject = to
+			}
+		case "DESCRIBED_BY":
+			if to == doc.SPDXID && subject == "" {
+				subject = from
+			}
+		case "CONTAINS":
+			children[from] = append(children[from], to)
+			contained[to] = true
+		case "CONTAINED_BY":
+			children[to] = append(children[to], from)
+			contained[from] = true
+		case "DEPENDS_ON":
+			dependsOn(from, to)
+		case "DEPENDENCY_OF":
+			dependsOn(to, from)
+		}
+	}
+
+	visited := make(map[string]bool)
+	var build func(id string) Component
+	build = func(id string) Component {
+		visited[id] = true
+		component := fromSPDXPackage(packages[id], extracted)
+		for _, child := range children[id] {
+			if _, ok := packages[child]; ok && !visited[child] {
+				component.Components = append(component.Components, build(child))
+			}
+		}
+		return component
+	}
+	if _, ok := packages[subject]; ok {
+		sbom.Subject = build(subject)
+	}
+	for _, pkg := range doc.Packages {
+		if !visited[pkg.SPDXID] && !contained[pkg.SPDXID] {
+			sbom.Components = append(sbom.Components, build(pkg.SPDXID))
+		}
+	}
+	// Packages only reachable through a containment cycle
+	for _, pkg := range doc.Packages {
+		if !visited[pkg.SPDXID] {
+			sbom.Components = append(sbom.Components, build(pkg.SPDXID))
+		}
+	}
+
+	sbom.Name = sbom.Subject.Name
+	sbom.Version = sbom.Subject.Version
+	sbom.Description = sbom.Subject.Description
+	if sbom.Name == "" {
+		sbom.Name = doc.Name
+	}
+	sbom.Dependencies = dependencies
+	return sbom
+}
+
+func fromSPDXPackage(pkg spdxPackage, extracted map[string]string) Component {
+	component := Component{
+		BOMRef:      pkg.SPDXID,
+		Type:        spdxTypes[pkg.PrimaryPackagePurpose],
+		Name:        pkg.Name,
+		Version:     pkg.VersionInfo,
+		Description: pkg.Description,
+	}
+	if component.Type == "" {
+		component.Type = ComponentLibrary
+	}
+	license := pkg.LicenseDeclared
+	if license == "" || license == noAssertion || license == "NONE" {
+		license = pkg.LicenseConcluded
+	}
+	if license == noAssertion || license == "NONE" {
+		license = ""
+	}
+	if name, ok := extracted[license]; ok {
+		license = name
+	}
+	component.License = license
+	for _, checksum := range pkg.Checksums {
+		setHash(&component, digestAlgorithm(checksum.Algorithm), checksum.ChecksumValue)
+	}
+	for _, ref := range pkg.ExternalRefs {
+		if ref.ReferenceType == "purl" && component.PURL == "" {
+			component.PURL = ref.ReferenceLocator
+		}
+	}
+	return component
+}
+
+// parseSPDXTagValue reads an SPDX tag-value document into the structure
+// shared with the JSON format. File and snippet sections are skipped.
+func parseSPDXTagValue(data []byte) (*spdxDocument, error) {
+	doc := &spdxDocument{}
+	var pkg *spdxPackage
+	var license *spdxExtractedLicense
+	// Document tags come before the first package, file or license section
+	inDocument := true
+	flush := func() {
+		if pkg != nil {
+			doc.Packages = append(doc.Packages, *pkg)
+			pkg = nil
+		}
+		if license != nil {
+			doc.ExtractedLicenses = append(doc.ExtractedLicenses, *license)
+			license = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected Tag: value", lineNumber)
+		}
+		tag = strings.TrimSpace(tag)
+		value = strings.TrimSpace(value)
+		// <text> values may span several lines
+		if strings.HasPrefix(value, "<text>") {
+			text := strings.TrimPrefix(value, "<text>")
+			for !strings.Contains(text, "</text>") {
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("line %d: unterminated <text> value", lineNumber)
+				}
+				lineNumber++
+				text += "\n" + scanner.Text()
+			}
+			value, _, _ = strings.Cut(text, "</text>")
+		}
+
+		switch tag {
+		case "PackageName":
+			flush()
+			pkg = &spdxPackage{Name: value}
+			inDocument = false
+			continue
+		case "FileName", "SnippetSPDXID":
+			flush()
+			inDocument = false
+			continue
+		case "LicenseID":
+			flush()
+			license = &spdxExtractedLicense{LicenseID: value}
+			inDocument = false
+			continue
+		case "Relationship":
+			fields := strings.Fields(value)
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: invalid relationship %q", lineNumber, value)
+			}
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID:      fields[0],
+				RelationshipType:   fields[1],
+				RelatedSPDXElement: fields[2],
+			})
+			continue
+		}
+
+		switch {
+		case license != nil:
+			switch tag {
+			case "ExtractedText":
+				license.ExtractedText = value
+			case "LicenseName":
+				license.Name = value
+			}
+		case pkg != nil:
+			switch tag {
+			case "SPDXID":
+				pkg.SPDXID = value
+			case "PackageVersion":
+				pkg.VersionInfo = value
+			case "PackageDownloadLocation":
+				pkg.DownloadLocation = value
+			case "FilesAnalyzed":
+				pkg.FilesAnalyzed = value == "true"
+			case "PackageChecksum":
+				algorithm, checksum, _ := strings.Cut(value, ":")
+				pkg.Checksums = append(pkg.Checksums, spdxChecksum{
+					Algorithm:     strings.TrimSpace(algorithm),
+					ChecksumValue: strings.TrimSpace(checksum),
+				})
+			case "PackageLicenseConcluded":
+				pkg.LicenseConcluded = value
+			case "PackageLicenseDeclared":
+				pkg.LicenseDeclared = value
+			case "PackageCopyrightText":
+				pkg.CopyrightText = value
+			case "PackageDescription":
+				pkg.Description = value
+			case "ExternalRef":
+				fields := strings.Fields(value)
+				if len(fields) == 3 {
+					pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{
+						ReferenceCategory: fields[0],
+						ReferenceType:     fields[1],
+						ReferenceLocator:  fields[2],
+					})
+				}
+			case "PrimaryPackagePurpose":
+				pkg.PrimaryPackagePurpose = value
+			case "PackageComment":
+				pkg.Comment = value
+			}
+		case inDocument:
+			switch tag {
+			case "SPDXVersion":
+				doc.SPDXVersion = value
+			case "DataLicense":
+				doc.DataLicense = value
+			case "SPDXID":
+				doc.SPDXID = value
+			case "DocumentName":
+				doc.Name = value
+			case "DocumentNamespace":
+				doc.DocumentNamespace = value
+			case "Creator":
+				doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, value)
+			case "Created":
+				doc.CreationInfo.Created = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	if doc.SPDXVersion == "" {
+		return nil, fmt.Errorf("missing SPDXVersion")
+	}
+	return doc, nil
+}
@@ -0,0 +1,278 @@
+package compliance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MChorfa/TraceSync/internal/vulndb"
+)
+
+// Kinds of changes between two SBOMs. A change can have several kinds,
+// such as an upgrade that also changes the license.
+const (
+	ChangeAdded      = "added"
+	ChangeRemoved    = "removed"
+	ChangeUpgraded   = "upgraded"
+	ChangeDowngraded = "downgraded"
+	// ChangeLicense is a different license on a component present in both.
+	ChangeLicense = "license"
+	// ChangeCopyleft is a copyleft license the component did not have
+	// before, from a new component or a license change.
+	ChangeCopyleft = "copyleft"
+)
+
+// ChangeKinds lists the kinds of SBOM changes.
+var ChangeKinds = []string{ChangeAdded, ChangeRemoved, ChangeUpgraded, ChangeDowngraded, ChangeLicense, ChangeCopyleft}
+
+// ParseChangeKinds validates a list of change kinds.
+func ParseChangeKinds(kinds []string) ([]string, error) {
+	var parsed []string
+	for _, kind := range kinds {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" {
+			continue
+		}
+		if !containsString(ChangeKinds, kind) {
+			return nil, fmt.Errorf("unknown change kind %q (supported: %v)", kind, ChangeKinds)
+		}
+		parsed = append(parsed, kind)
+	}
+	return parsed, nil
+}
+
+// SBOMDiff lists the component changes from one SBOM to another.
+type SBOMDiff struct {
+	Old     SBOMSummary       `json:"old" yaml:"old"`
+	New     SBOMSummary       `json:"new" yaml:"new"`
+	Changes []ComponentChange `json:"changes" yaml:"changes"`
+}
+
+// SBOMSummary identifies a compared SBOM.
+type SBOMSummary struct {
+	Name       string `json:"name" yaml:"name"`
+	Version    string `json:"version,omitempty" yaml:"version,omitempty"`
+	Components int    `json:"components" yaml:"components"`
+}
+
+// ComponentChange is a component that was added, removed or changed.
+type ComponentChange struct {
+	Name string `json:"name" yaml:"name"`
+	// PURL is the package URL of the new component, or of the old one if it
+	// was removed.
+	PURL       string   `json:"purl,omitempty" yaml:"purl,omitempty"`
+	Kinds      []string `json:"kinds" yaml:"kinds"`
+	OldVersion string   `json:"oldVersion,omitempty" yaml:"oldVersion,omitempty"`
+	NewVersion string   `json:"newVersion,omitempty" yaml:"newVersion,omitempty"`
+	OldLicense string   `json:"oldLicense,omitempty" yaml:"oldLicense,omitempty"`
+	NewLicense string   `json:"newLicense,omitempty" yaml:"newLicense,omitempty"`
+}
+
+// Is reports whether the change has a kind.
+func (c ComponentChange) Is(kind string) bool {
+	return containsString(c.Kinds, kind)
+}
+
+// Matching returns the changes that have any of the given kinds.
+func (d *SBOMDiff) Matching(kinds []string) []ComponentChange {
+	var matching []ComponentChange
+	for _, change := range d.Changes {
+		for _, kind := range kinds {
+			if change.Is(kind) {
+				matching = append(matching, change)
+				break
+			}
+		}
+	}
+	return matching
+}
+
+// DiffSBOMs compares the components of two SBOMs, including nested ones.
+// Components are matched by package URL without version, or by type and
+// name; a component present in one version on each side was upgraded or
+// downgraded, as ordered by its ecosystem's versioning scheme.
+func DiffSBOMs(old, new *SBOM) *SBOMDiff {
+	oldComponents, newComponents := flattenComponents(old.Components), flattenComponents(new.Components)
+	diff := &SBOMDiff{
+		Old:     SBOMSummary{Name: old.Name, Version: old.Version, Components: len(oldComponents)},
+		New:     SBOMSummary{Name: new.Name, Version: new.Version, Components: len(newComponents)},
+		Changes: []ComponentChange{},
+	}
+
+	oldByKey, newByKey := groupComponents(oldComponents), groupComponents(newComponents)
+	keys := make(map[string]bool)
+	for key := range oldByKey {
+		keys[key] = true
+	}
+	for key := range newByKey {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		before, after := versionSet(oldByKey[key]), versionSet(newByKey[key])
+		var removed, added []Component
+		for version, component := range before {
+			if other, ok := after[version]; ok {
+				if change, changed := licenseChange(component, other); changed {
+					diff.Changes = append(diff.Changes, change)
+				}
+				continue
+			}
+			removed = append(removed, component)
+		}
+		for version, component := range after {
+			if _, ok := before[version]; !ok {
+				added = append(added, component)
+			}
+		}
+
+		// A single version replaced by another is an upgrade or downgrade
+		if len(removed) == 1 && len(added) == 1 {
+			diff.Changes = append(diff.Changes, versionChange(removed[0], added[0]))
+			continue
+		}
+		for _, component := range removed {
+			diff.Changes = append(diff.Changes, ComponentChange{
+				Name: component.Name, PURL: component.PURL, Kinds: []string{ChangeRemoved},
+				OldVersion: component.Version, OldLicense: component.License,
+			})
+		}
+		for _, component := range added {
+			change := ComponentChange{
+				Name: component.Name, PURL: component.PURL, Kinds: []string{ChangeAdded},
+				NewVersion: component.Version, NewLicense: component.License,
+			}
+			if IsCopyleft(component.License) {
+				change.Kinds = append(change.Kinds, ChangeCopyleft)
+			}
+			diff.Changes = append(diff.Changes, change)
+		}
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		a, b := diff.Changes[i], diff.Changes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.OldVersion != b.OldVersion {
+			return a.OldVersion < b.OldVersion
+		}
+		return a.NewVersion < b.NewVersion
+	})
+	return diff
+}
+
+func versionChange(old, new Component) ComponentChange {
+	change := ComponentChange{
+		Name:       new.Name,
+		PURL:       new.PURL,
+		OldVersion: old.Version,
+		NewVersion: new.Version,
+		OldLicense: old.License,
+		NewLicense: new.License,
+	}
+	if compareComponentVersions(new, old.Version, new.Version) > 0 {
+		change.Kinds = append(change.Kinds, ChangeDowngraded)
+	} else {
+		change.Kinds = append(change.Kinds, ChangeUpgraded)
+	}
+	if licenseKinds := licenseChangeKinds(old.License, new.License); len(licenseKinds) > 0 {
+		change.Kinds = append(change.Kinds, licenseKinds...)
+	} else {
+		change.OldLicense = ""
+	}
+	return change
+}
+
+// licenseChange reports a license change of a component whose version
+// did not change.
+func licenseChange(old, new Component) (ComponentChange, bool) {
+	kinds := licenseChangeKinds(old.License, new.License)
+	if len(kinds) == 0 {
+		return ComponentChange{}, false
+	}
+	return ComponentChange{
+		Name: new.Name, PURL: new.PURL, Kinds: kinds,
+		OldVersion: old.Version, NewVersion: new.Version,
+		OldLicense: old.License, NewLicense: new.License,
+	}, true
+}
+
+func licenseChangeKinds(old, new string) []string {
+	if strings.EqualFold(strings.Join(strings.Fields(old), " "), strings.Join(strings.Fields(new), " ")) {
+		return nil
+	}
+	kinds := []string{ChangeLicense}
+	if IsCopyleft(new) && !IsCopyleft(old) {
+		kinds = append(kinds, ChangeCopyleft)
+	}
+	return kinds
+}
+
+// compareComponentVersions orders two versions of a component the way its
+// package ecosystem does, so that 1.10 is newer than 1.9.
+func compareComponentVersions(component Component, a, b string) int {
+	ecosystem := ""
+	if purl, err := parsePackageURL(component.PURL); err == nil {
+		switch purl.Type {
+		case "deb", "apk", "rpm":
+			ecosystem = distroEcosystems[purl.Namespace]
+		default:
+			ecosystem = purlEcosystems[purl.Type]
+		}
+	}
+	return vulndb.CompareVersions(ecosystem, a, b)
+}
+
+// flattenComponents lists components together with their nested parts.
+func flattenComponents(components []Component) []Component {
+	var flat []Component
+	for _, component := range components {
+		flat = append(flat, component)
+		flat = append(flat, flattenComponents(component.Components)...)
+	}
+	return flat
+}
+
+// groupComponents groups components by identity.
+func groupComponents(components []Component) map[string][]Component {
+	groups := make(map[string][]Component)
+	for _, component := range components {
+		key := componentIdentity(component)
+		groups[key] = append(groups[key], component)
+	}
+	return groups
+}
+
+// componentIdentity identifies a component across versions: its package
+// URL without version, qualifiers and subpath, or else its type and name.
+func componentIdentity(component Component) string {
+	if purl, err := parsePackageURL(component.PURL); err == nil {
+		return strings.ToLower(fmt.Sprintf("pkg:%s/%s/%s", purl.Type, purl.Namespace, purl.Name))
+	}
+	componentType := component.Type
+	if componentType == "" {
+		componentType = ComponentLibrary
+	}
+	return componentType + ":" + strings.ToLower(component.Name)
+}
+
+// versionSet keys components by version, keeping the first of duplicates.
+func versionSet(components []Component) map[string]Component {
+	set := make(map[string]Component, len(components))
+	for _, component := range components {
+		if _, ok := set[component.Version]; !ok {
+			set[component.Version] = component
+		}
+	}
+	return set
+}
+
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package compliance
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+)
+
+// propertyLocation records which file of the artifact a component was
+// discovered in.
+const propertyLocation = "tracesync:location"
+
+// discovery holds the components found in an artifact and the dependencies
+// between them, linked by bom-ref.
+type discovery struct {
+	components   []Component
+	dependencies []Dependency
+	// warnings describe parts of a manifest that could not be followed.
+	warnings []string
+}
+
+func (d *discovery) add(other *discovery) {
+	d.components = append(d.components, other.components...)
+	d.dependencies = append(d.dependencies, other.dependencies...)
+	d.warnings = append(d.warnings, other.warnings...)
+}
+
+// discoverer extracts components from one kind of file. The file is given as
+// a slash-separated path relative to root.
+type discoverer struct {
+	// pattern matches the base name of the files this discoverer reads
+	pattern string
+	// sniff, when set, recognizes files by their first bytes instead of
+	// their name
+	sniff    func(header []byte) bool
+	discover func(root, file string) (*discovery, error)
+}
+
+var discoverers = []discoverer{
+	{pattern: "go.mod", discover: discoverGoModules},
+	{pattern: "requirements*.txt", discover: discoverRequirements},
+	{pattern: "poetry.lock", discover: discoverPoetryLock},
+	{pattern: "Pipfile.lock", discover: discoverPipfileLock},
+	{pattern: "uv.lock", discover: discoverUVLock},
+	{pattern: "oci-layout", discover: discoverImageLayout},
+	{sniff: isExecutable, discover: discoverGoBinary},
+	{sniff: isTarArchive, discover: discoverImageArchive},
+}
+
+// headerSize is how much of a file is read to recognize its format.
+const headerSize = 512
+
+// discoverComponents looks for dependency manifests and binaries in an
+// artifact: in every file of a bundle directory, or in the artifact file
+// itself.
+func discoverComponents(artifactPath string) (*discovery, error) {
+	info, err := os.Stat(artifactPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat artifact: %w", err)
+	}
+
+	root := filepath.Dir(artifactPath)
+	files := []string{filepath.Base(artifactPath)}
+	if info.IsDir() {
+		root = artifactPath
+		if files, err = artifactmanager.BundleFiles(artifactPath); err != nil {
+			return nil, err
+		}
+	}
+
+	found := &discovery{}
+	for _, file := range files {
+		var header []byte
+		for _, d := range discoverers {
+			if d.sniff != nil {
+				if header == nil {
+					if header, err = readHeader(root, file); err != nil {
+						return nil, err
+					}
+				}
+				if !d.sniff(header) {
+					continue
+				}
+			} else if matched, _ := path.Match(d.pattern, path.Base(file)); !matched {
+				continue
+			}
+			result, err := d.discover(root, file)
+			if err != nil {
+				return nil, err
+			}
+			found.add(result)
+		}
+	}
+	found.deduplicate()
+	return found, nil
+}
+
+// readDiscovered reads a file found in an artifact.
+func readDiscovered(root, file string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return data, nil
+}
+
+// readHeader reads the first bytes of a file found in an artifact.
+func readHeader(root, file string) ([]byte, error) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	defer f.Close()
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return header[:n], nil
+}
+
+// deduplicate merges components that share a bom-ref, such as a module
+// required by several go.mod files of a bundle, and their dependencies.
+func (d *discovery) deduplicate() {
+	seen := make(map[string]int)
+	var components []Component
+	for _, component := range d.components {
+		if component.BOMRef == "" {
+			components = append(components, component)
+			continue
+		}
+		if i, ok := seen[component.BOMRef]; ok {
+			mergeHashes(&components[i], component)
+			continue
+		}
+		seen[component.BOMRef] = len(components)
+		components = append(components, component)
+	}
+	d.components = components
+
+	dependsOn := make(map[string]map[string]bool)
+	var refs []string
+	for _, dependency := range d.dependencies {
+		if dependsOn[dependency.Ref] == nil {
+			dependsOn[dependency.Ref] = make(map[string]bool)
+			refs = append(refs, dependency.Ref)
+		}
+		for _, ref := range dependency.DependsOn {
+			dependsOn[dependency.Ref][ref] = true
+		}
+	}
+	d.dependencies = nil
+	for _, ref := range refs {
+		dependency := Dependency{Ref: ref}
+		for target := range dependsOn[ref] {
+			dependency.DependsOn = append(dependency.DependsOn, target)
+		}
+		sort.Strings(dependency.DependsOn)
+		d.dependencies = append(d.dependencies, dependency)
+	}
+}
+
+// rootComponents returns the bom-refs of components no other component
+// depends on.
+func rootComponents(components []Component, dependencies []Dependency) []string {
+	dependedOn := make(map[string]bool)
+	for _, dependency := range dependencies {
+		for _, ref := range dependency.DependsOn {
+			dependedOn[ref] = true
+		}
+	}
+	var roots []string
+	for _, component := range components {
+		if !dependedOn[component.BOMRef] {
+			roots = append(roots, component.BOMRef)
+		}
+	}
+	return roots
+}
+
+func mergeHashes(into *Component, from Component) {
+	for algorithm, value := range from.Hashes {
+		if into.Hashes == nil {
+			into.Hashes = make(map[string]string)
+		}
+		if _, ok := into.Hashes[algorithm]; !ok {
+			into.Hashes[algorithm] = value
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package compliance
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LicenseExpression is a parsed SPDX license expression. Compound
+// expressions join Left and Right with Operator; simple ones name a license.
+type LicenseExpression struct {
+	// Operator is AND or OR, or empty for a simple expression.
+	Operator    string
+	Left, Right *LicenseExpression
+	// License is a license identifier or LicenseRef. OrLater is set by a
+	// trailing "+".
+	License string
+	OrLater bool
+	// Exception is the exception added with WITH, if any.
+	Exception string
+}
+
+// ParseLicenseExpression parses an SPDX license expression such as
+// "MIT OR (GPL-2.0-only WITH Classpath-exception-2.0)". WITH binds tighter
+// than AND, which binds tighter than OR; operators are accepted in either
+// case.
+func ParseLicenseExpression(expression string) (*LicenseExpression, error) {
+	p := &expressionParser{tokens: tokenizeLicenseExpression(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	parsed, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", expression, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", expression, p.tokens[p.pos])
+	}
+	return parsed, nil
+}
+
+// String renders the expression with parentheses only where needed.
+func (e *LicenseExpression) String() string {
+	if e.Operator == "" {
+		s := e.License
+		if e.OrLater {
+			s += "+"
+		}
+		if e.Exception != "" {
+			s += " WITH " + e.Exception
+		}
+		return s
+	}
+	operand := func(child *LicenseExpression) string {
+		// AND inside OR needs no parentheses, OR inside AND does
+		if child.Operator == "OR" && e.Operator == "AND" {
+			return "(" + child.String() + ")"
+		}
+		return child.String()
+	}
+	return operand(e.Left) + " " + e.Operator + " " + operand(e.Right)
+}
+
+// Licenses returns the simple expressions, such as "GPL-2.0-only WITH
+// Classpath-exception-2.0", in the order they appear.
+func (e *LicenseExpression) Licenses() []string {
+	if e.Operator == "" {
+		return []string{e.String()}
+	}
+	return append(e.Left.Licenses(), e.Right.Licenses()...)
+}
+
+func tokenizeLicenseExpression(expression string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(expression) {
+		for field != "" {
+			i := strings.IndexAny(field, "()")
+			switch {
+			case i < 0:
+				tokens = append(tokens, field)
+				field = ""
+			case i > 0:
+				tokens = append(tokens, field[:i])
+				field = field[i:]
+			default:
+				tokens = append(tokens, field[:1])
+				field = field[1:]
+			}
+		}
+	}
+	return tokens
+}
+
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *expressionParser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *expressionParser) or() (*LicenseExpression, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("OR") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &LicenseExpression{Operator: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) and() (*LicenseExpression, error) {
+	left, err := p.with()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("AND") {
+		p.pos++
+		right, err := p.with()
+		if err != nil {
+			return nil, err
+		}
+		left = &LicenseExpression{Operator: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) with() (*LicenseExpression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("expected a license")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+
+	if token == "(" {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	if !isLicenseToken(token) {
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+
+	simple := &LicenseExpression{License: strings.TrimSuffix(token, "+"), OrLater: strings.HasSuffix(token, "+")}
+	if p.peekOperator("WITH") {
+		p.pos++
+		if p.pos >= len(p.tokens) || !isLicenseToken(p.tokens[p.pos]) {
+			return nil, fmt.Errorf("expected an exception after WITH")
+		}
+		simple.Exception = p.tokens[p.pos]
+		p.pos++
+	}
+	return simple, nil
+}
+
+// isLicenseToken reports whether a token can be a license or exception id:
+// letters, digits, "-", ".", a trailing "+" and the ":" of DocumentRef-
+// references.
+func isLicenseToken(token string) bool {
+	switch strings.ToUpper(token) {
+	case "AND", "OR", "WITH", "(", ")", "", "+":
+		return false
+	}
+	for i, c := range token {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.', c == ':':
+		case c == '+' && i == len(token)-1:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package compliance
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"path/filepath"
+)
+
+// executableMagic holds the signatures of the executable formats Go builds:
+// ELF, PE and Mach-O (32 and 64 bit, both byte orders).
+var executableMagic = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("MZ"),
+	[]byte("\xfe\xed\xfa\xce"),
+	[]byte("\xfe\xed\xfa\xcf"),
+	[]byte("\xce\xfa\xed\xfe"),
+	[]byte("\xcf\xfa\xed\xfe"),
+}
+
+func isExecutable(header []byte) bool {
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverGoBinary lists the main module and the dependencies embedded in a
+// Go executable. The Go version and the build settings, such as the VCS
+// revision and the cgo flags, are recorded as properties of the main module
+// so the binary can be traced back to its source. Executables without Go
+// build info yield no components.
+func discoverGoBinary(root, file string) (*discovery, error) {
+	found := &discovery{}
+	info, err := buildinfo.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return found, nil
+	}
+
+	main := Component{
+		Type:       ComponentApplication,
+		Name:       info.Main.Path,
+		Properties: map[string]string{propertyLocation: file},
+	}
+	if main.Name == "" {
+		// Binaries built from files outside a module only know their package
+		main.Name = info.Path
+	}
+	if info.Main.Version != "(devel)" {
+		main.Version = info.Main.Version
+	}
+	if info.Main.Sum != "" {
+		main.Hashes = map[string]string{"h1": info.Main.Sum}
+	}
+	main.Properties["tracesync:go:version"] = info.GoVersion
+	if info.Path != "" && info.Path != main.Name {
+		main.Properties["tracesync:go:package"] = info.Path
+	}
+	for _, setting := range info.Settings {
+		main.Properties["tracesync:go:build:"+setting.Key] = setting.Value
+	}
+	main.PURL = goModulePURL(main.Name, main.Version)
+	main.BOMRef = main.PURL
+
+	mainDependency := Dependency{Ref: main.BOMRef}
+	for _, module := range info.Deps {
+		component := Component{
+			Type:       ComponentLibrary,
+			Name:       module.Path,
+			Version:    module.Version,
+			Properties: map[string]string{propertyLocation: file},
+		}
+		sum := module.Sum
+		if replace := module.Replace; replace != nil {
+			component.Properties["tracesync:go:replaces"] = module.Path + "@" + module.Version
+			if replace.Version == "" {
+				// Local directories have no version or checksum
+				component.Version = ""
+				component.Properties["tracesync:go:replace-dir"] = replace.Path
+			} else {
+				component.Name = replace.Path
+				component.Version = replace.Version
+			}
+			sum = replace.Sum
+		}
+		if sum != "" {
+			component.Hashes = map[string]string{"h1": sum}
+		}
+		component.PURL = goModulePURL(component.Name, component.Version)
+		component.BOMRef = component.PURL
+		found.components = append(found.components, component)
+		mainDependency.DependsOn = append(mainDependency.DependsOn, component.BOMRef)
+	}
+
+	found.components = append([]Component{main}, found.components...)
+	found.dependencies = append(found.dependencies, mainDependency)
+	return found, nil
+}
@@ -0,0 +1,272 @@
+package compliance
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// goModFile is the parsed content of a go.mod file.
+type goModFile struct {
+	Module    string
+	GoVersion string
+	Requires  []goRequire
+	Replaces  []goReplace
+}
+
+type goRequire struct {
+	Path     string
+	Version  string
+	Indirect bool
+}
+
+// goReplace is a replace directive. An empty OldVersion replaces every
+// version; an empty NewVersion means NewPath is a local directory.
+type goReplace struct {
+	OldPath    string
+	OldVersion string
+	NewPath    string
+	NewVersion string
+}
+
+// parseGoMod parses the directives of a go.mod file that matter for an
+// SBOM: module, go, require and replace.
+func parseGoMod(data []byte) (*goModFile, error) {
+	mod := &goModFile{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	block := ""
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment = strings.TrimSpace(line[i+2:])
+			line = line[:i]
+		}
+		fields, err := goModFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("go.mod:%d: %w", lineNumber, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Directives are either on one line or grouped in a "verb (" block
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		switch verb {
+		case "module":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("go.mod:%d: usage: module path", lineNumber)
+			}
+			mod.Module = fields[0]
+		case "go":
+			if len(fields) != 1 {
+				return nil, fmt.Errorf("go.mod:%d: usage: go 1.23", lineNumber)
+			}
+			mod.GoVersion = fields[0]
+		case "require":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("go.mod:%d: usage: require module/path v1.2.3", lineNumber)
+			}
+			mod.Requires = append(mod.Requires, goRequire{
+				Path:     fields[0],
+				Version:  fields[1],
+				Indirect: comment == "indirect" || strings.HasPrefix(comment, "indirect;"),
+			})
+		case "replace":
+			replace, err := parseGoReplace(fields)
+			if err != nil {
+				return nil, fmt.Errorf("go.mod:%d: %w", lineNumber, err)
+			}
+			mod.Replaces = append(mod.Replaces, replace)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != "" {
+		return nil, fmt.Errorf("go.mod: unterminated %s block", block)
+	}
+	return mod, nil
+}
+
+func parseGoReplace(fields []string) (goReplace, error) {
+	arrow := -1
+	for i, field := range fields {
+		if field == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 || arrow > 2 || len(fields)-arrow-1 < 1 || len(fields)-arrow-1 > 2 {
+		return goReplace{}, fmt.Errorf("usage: replace module/path [v1.2.3] => other/module v1.4.5 or ./local/dir")
+	}
+	replace := goReplace{OldPath: fields[0], NewPath: fields[arrow+1]}
+	if arrow == 2 {
+		replace.OldVersion = fields[1]
+	}
+	if len(fields)-arrow-1 == 2 {
+		replace.NewVersion = fields[arrow+2]
+	}
+	if replace.NewVersion == "" && !isLocalGoPath(replace.NewPath) {
+		return goReplace{}, fmt.Errorf("replacement module %s needs a version", replace.NewPath)
+	}
+	return replace, nil
+}
+
+// goModFields splits a go.mod line into fields, unquoting quoted strings.
+func goModFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return fields, nil
+		}
+		if line[0] == '"' || line[0] == '`' {
+			end := strings.IndexByte(line[1:], line[0])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted string")
+			}
+			quoted := line[:end+2]
+			unquoted, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string %s", quoted)
+			}
+			fields = append(fields, unquoted)
+			line = line[end+2:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+}
+
+func isLocalGoPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") || filepath.IsAbs(p) || p == "." || p == ".."
+}
+
+// parseGoSum returns the h1 hashes of module content from go.sum, keyed by
+// "path@version". Hashes of go.mod files only are skipped.
+func parseGoSum(data []byte) map[string]string {
+	hashes := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		hashes[fields[0]+"@"+fields[1]] = fields[2]
+	}
+	return hashes
+}
+
+// goModulePURL returns the purl of a Go module.
+func goModulePURL(modulePath, version string) string {
+	namespace, name := path.Split(modulePath)
+	return packageURL("golang", strings.TrimSuffix(namespace, "/"), name, version, nil)
+}
+
+// discoverGoModules lists the main module and every required module of a
+// go.mod file, applying replace directives and taking hashes from the
+// go.sum file next to it.
+func discoverGoModules(root, file string) (*discovery, error) {
+	data, err := readDiscovered(root, file)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := parseGoMod(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	sums := map[string]string{}
+	sumFile := path.Join(path.Dir(file), "go.sum")
+	if data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(sumFile))); err == nil {
+		sums = parseGoSum(data)
+	}
+
+	main := Component{
+		Type:       ComponentApplication,
+		Name:       mod.Module,
+		PURL:       goModulePURL(mod.Module, ""),
+		Properties: map[string]string{propertyLocation: file},
+	}
+	if mod.GoVersion != "" {
+		main.Properties["tracesync:go:version"] = mod.GoVersion
+	}
+	main.BOMRef = main.PURL
+	found := &discovery{}
+
+	mainDependency := Dependency{Ref: main.BOMRef}
+	for _, require := range mod.Requires {
+		component := Component{
+			Type:       ComponentLibrary,
+			Name:       require.Path,
+			Version:    require.Version,
+			Properties: map[string]string{propertyLocation: file},
+		}
+		if require.Indirect {
+			component.Properties["tracesync:go:indirect"] = "true"
+		}
+
+		if replace, ok := findGoReplace(mod.Replaces, require); ok {
+			component.Properties["tracesync:go:replaces"] = require.Path + "@" + require.Version
+			if replace.NewVersion == "" {
+				// Local directories have no version or checksum
+				component.Version = ""
+				component.Properties["tracesync:go:replace-dir"] = replace.NewPath
+			} else {
+				component.Name = replace.NewPath
+				component.Version = replace.NewVersion
+			}
+		}
+
+		if h1, ok := sums[component.Name+"@"+component.Version]; ok {
+			component.Hashes = map[string]string{"h1": h1}
+		}
+		component.PURL = goModulePURL(component.Name, component.Version)
+		component.BOMRef = component.PURL
+		found.components = append(found.components, component)
+		mainDependency.DependsOn = append(mainDependency.DependsOn, component.BOMRef)
+	}
+
+	found.components = append([]Component{main}, found.components...)
+	found.dependencies = append(found.dependencies, mainDependency)
+	return found, nil
+}
+
+// findGoReplace returns the replace directive that applies to a requirement;
+// a directive for the exact version wins over one for all versions.
+func findGoReplace(replaces []goReplace, require goRequire) (goReplace, bool) {
+	var match goReplace
+	found := false
+	for _, replace := range replaces {
+		if replace.OldPath != require.Path {
+			continue
+		}
+		if replace.OldVersion == require.Version {
+			return replace, true
+		}
+		if replace.OldVersion == "" {
+			match, found = replace, true
+		}
+	}
+	return match, found
+}
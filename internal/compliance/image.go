@@ -0,0 +1,479 @@
+package compliance
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// propertyImageLayer records the layer of a container image, by diff id, that
+// an OS package was installed in.
+const propertyImageLayer = "tracesync:image:layer"
+
+// imageFiles opens the files of a container image in OCI layout or docker
+// save format, by slash-separated path.
+type imageFiles interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// layoutFiles reads an image from an OCI layout directory.
+type layoutFiles string
+
+func (dir layoutFiles) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
+// archiveFiles reads an image from a tarball, using the offset of each file
+// so layers can be read in any order without extracting the archive.
+type archiveFiles struct {
+	file    *os.File
+	entries map[string][2]int64
+}
+
+func (a *archiveFiles) Open(name string) (io.ReadCloser, error) {
+	entry, ok := a.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	return io.NopCloser(io.NewSectionReader(a.file, entry[0], entry[1])), nil
+}
+
+// isTarArchive recognizes POSIX and GNU tar archives.
+func isTarArchive(header []byte) bool {
+	return len(header) >= 262 && bytes.HasPrefix(header[257:], []byte("ustar"))
+}
+
+// discoverImageArchive lists the OS packages of a container image saved as a
+// tarball by docker save or as an OCI layout. Other tar archives yield no
+// components.
+func discoverImageArchive(root, file string) (*discovery, error) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	defer f.Close()
+
+	// archive/tar reads headers without buffering, so the file offset after
+	// Next is where the entry's content starts
+	archive := &archiveFiles{file: f, entries: make(map[string][2]int64)}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		archive.entries[path.Clean(hdr.Name)] = [2]int64{offset, hdr.Size}
+	}
+
+	_, docker := archive.entries["manifest.json"]
+	_, oci := archive.entries["index.json"]
+	if !docker && !oci {
+		return &discovery{}, nil
+	}
+	found, err := discoverImage(archive, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", file, err)
+	}
+	return found, nil
+}
+
+// discoverImageLayout lists the OS packages of a container image stored as
+// an OCI layout directory, recognized by its oci-layout file.
+func discoverImageLayout(root, file string) (*discovery, error) {
+	dir := path.Dir(file)
+	location := dir
+	if dir == "." {
+		// The layout is the artifact itself
+		location = filepath.Base(root)
+	}
+	found, err := discoverImage(layoutFiles(filepath.Join(root, filepath.FromSlash(dir))), location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", dir, err)
+	}
+	return found, nil
+}
+
+// imageManifest is an image of an archive or layout, with the paths of its
+// config and its layers, base layer first. It is also the entry format of a
+// docker save manifest.json.
+type imageManifest struct {
+	Tags   []string `json:"RepoTags"`
+	Config string   `json:"Config"`
+	Layers []string `json:"Layers"`
+}
+
+// imageConfig is the part of an image configuration TraceSync uses.
+type imageConfig struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	RootFS       struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// ociDescriptor points to a blob of an OCI layout.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// discoverImage lists the components of every image in an archive or
+// layout: the image itself, its operating system and its OS packages.
+func discoverImage(files imageFiles, location string) (*discovery, error) {
+	manifests, err := readImageManifests(files)
+	if err != nil {
+		return nil, err
+	}
+
+	found := &discovery{}
+	for _, manifest := range manifests {
+		var config imageConfig
+		if err := readJSON(files, manifest.Config, &config); err != nil {
+			return nil, err
+		}
+
+		image := imageComponent(manifest, config, location)
+		release, packages, err := readImageLayers(files, manifest.Layers)
+		if err != nil {
+			return nil, err
+		}
+
+		imageDependency := Dependency{Ref: image.BOMRef}
+		found.components = append(found.components, image)
+		if release != nil && release.ID != "" {
+			system := Component{
+				BOMRef:      "os:" + release.ID + "@" + release.VersionID,
+				Type:        ComponentOperatingSystem,
+				Name:        release.ID,
+				Version:     release.VersionID,
+				Description: release.PrettyName,
+				Properties:  map[string]string{propertyLocation: location},
+			}
+			found.components = append(found.components, system)
+			imageDependency.DependsOn = append(imageDependency.DependsOn, system.BOMRef)
+		}
+
+		for _, pkg := range packages {
+			component := Component{
+				Type:    ComponentLibrary,
+				Name:    pkg.Name,
+				Version: pkg.Version,
+				License: pkg.License,
+				PURL:    osPackagePURL(pkg, release),
+				Properties: map[string]string{
+					propertyLocation:   location,
+					propertyImageLayer: layerID(config, manifest, pkg.Layer),
+				},
+			}
+			if pkg.Source != "" {
+				component.Properties["tracesync:os:source"] = pkg.Source
+			}
+			component.BOMRef = component.PURL
+			found.components = append(found.components, component)
+			imageDependency.DependsOn = append(imageDependency.DependsOn, component.BOMRef)
+		}
+		found.dependencies = append(found.dependencies, imageDependency)
+	}
+	return found, nil
+}
+
+// readImageManifests lists the images of an archive or layout. A docker save
+// manifest.json is preferred when present; otherwise the OCI index is
+// followed, including nested indexes of multi-platform images.
+func readImageManifests(files imageFiles) ([]imageManifest, error) {
+	var manifests []imageManifest
+	if err := readJSON(files, "manifest.json", &manifests); err == nil {
+		return manifests, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var index struct {
+		Manifests []ociDescriptor `json:"manifests"`
+	}
+	if err := readJSON(files, "index.json", &index); err != nil {
+		return nil, err
+	}
+	return readOCIManifests(files, index.Manifests, nil)
+}
+
+func readOCIManifests(files imageFiles, descriptors []ociDescriptor, tags []string) ([]imageManifest, error) {
+	var manifests []imageManifest
+	for _, descriptor := range descriptors {
+		descriptorTags := tags
+		if name := descriptor.Annotations["io.containerd.image.name"]; name != "" {
+			descriptorTags = []string{name}
+		} else if name := descriptor.Annotations["org.opencontainers.image.ref.name"]; name != "" {
+			// The reference name is often only a tag
+			if !strings.ContainsAny(name, ":/") {
+				name = ":" + name
+			}
+			descriptorTags = []string{name}
+		}
+
+		var manifest struct {
+			Manifests []ociDescriptor `json:"manifests"`
+			Config    ociDescriptor   `json:"config"`
+			Layers    []ociDescriptor `json:"layers"`
+		}
+		if err := readJSON(files, blobPath(descriptor.Digest), &manifest); err != nil {
+			return nil, err
+		}
+		if len(manifest.Manifests) > 0 {
+			nested, err := readOCIManifests(files, manifest.Manifests, descriptorTags)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, nested...)
+			continue
+		}
+
+		// Attestation manifests in an index point to no image config
+		if manifest.Config.Digest == "" || descriptor.Annotations["vnd.docker.reference.type"] != "" {
+			continue
+		}
+		image := imageManifest{Tags: descriptorTags, Config: blobPath(manifest.Config.Digest)}
+		for _, layer := range manifest.Layers {
+			image.Layers = append(image.Layers, blobPath(layer.Digest))
+		}
+		manifests = append(manifests, image)
+	}
+	return manifests, nil
+}
+
+// blobPath returns where an OCI layout stores the blob with a digest.
+func blobPath(digest string) string {
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	return path.Join("blobs", algorithm, hex)
+}
+
+func readJSON(files imageFiles, name string, v interface{}) error {
+	r, err := files.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// imageComponent describes an image by its first tag and its image id, the
+// digest of its config.
+func imageComponent(manifest imageManifest, config imageConfig, location string) Component {
+	id := configDigest(manifest.Config)
+	component := Component{
+		Type:       ComponentContainer,
+		Name:       location,
+		Properties: map[string]string{propertyLocation: location, "tracesync:image:id": id},
+	}
+	qualifiers := map[string]string{}
+	if len(manifest.Tags) > 0 {
+		repository, tag := splitImageReference(manifest.Tags[0])
+		if repository != "" {
+			component.Name = repository
+		}
+		component.Version = tag
+		qualifiers["tag"] = tag
+		if strings.Contains(repository, "/") {
+			qualifiers["repository_url"] = repository
+		}
+		tags := make([]string, len(manifest.Tags))
+		for i, reference := range manifest.Tags {
+			tags[i] = strings.TrimPrefix(reference, ":")
+		}
+		component.Properties["tracesync:image:tags"] = strings.Join(tags, ",")
+	}
+	if config.OS != "" {
+		component.Properties["tracesync:image:platform"] = config.OS + "/" + config.Architecture
+	}
+	component.PURL = packageURL("oci", "", path.Base(component.Name), id, qualifiers)
+	component.BOMRef = component.PURL
+	return component
+}
+
+// splitImageReference splits "registry/repository:tag" at the tag. A colon
+// before the last slash belongs to a registry port.
+func splitImageReference(reference string) (string, string) {
+	i := strings.LastIndex(reference, ":")
+	if i < 0 || strings.Contains(reference[i:], "/") {
+		return reference, ""
+	}
+	return reference[:i], reference[i+1:]
+}
+
+// configDigest derives the image id from the path of its config: a blob of
+// an OCI layout or "<hex>.json" in older docker save archives.
+func configDigest(config string) string {
+	if parts := strings.Split(config, "/"); len(parts) == 3 && parts[0] == "blobs" {
+		return parts[1] + ":" + parts[2]
+	}
+	return "sha256:" + strings.TrimSuffix(path.Base(config), ".json")
+}
+
+// layerID identifies a layer by its diff id, or by its path when the config
+// does not list diff ids.
+func layerID(config imageConfig, manifest imageManifest, layer int) string {
+	if layer < len(config.RootFS.DiffIDs) {
+		return config.RootFS.DiffIDs[layer]
+	}
+	return manifest.Layers[layer]
+}
+
+// imageFile is a package database or os-release file found in a layer.
+type imageFile struct {
+	packages []osPackage
+	release  *osRelease
+}
+
+// readImageLayers applies the layers of an image in order and returns the
+// distribution and the OS packages of the resulting file system. Each
+// package records the layer it was installed in: the earliest layer from
+// which its database entry stayed unchanged.
+func readImageLayers(files imageFiles, layers []string) (*osRelease, []osPackage, error) {
+	state := make(map[string]imageFile)
+	for i, layer := range layers {
+		changes, whiteouts, err := readLayer(files, layer, i)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read layer %s: %w", layer, err)
+		}
+
+		// Whiteouts hide files of the layers below
+		for _, whiteout := range whiteouts {
+			for name := range state {
+				if name == whiteout || strings.HasPrefix(name, whiteout+"/") {
+					delete(state, name)
+				}
+			}
+		}
+
+		for name, file := range changes {
+			if previous, ok := state[name]; ok {
+				installed := make(map[string]int)
+				for _, pkg := range previous.packages {
+					installed[pkg.key()] = pkg.Layer
+				}
+				for j, pkg := range file.packages {
+					if layer, ok := installed[pkg.key()]; ok {
+						file.packages[j].Layer = layer
+					}
+				}
+			}
+			state[name] = file
+		}
+	}
+
+	var release *osRelease
+	for _, name := range []string{"usr/lib/os-release", "etc/os-release"} {
+		if file, ok := state[name]; ok {
+			release = file.release
+		}
+	}
+
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var packages []osPackage
+	for _, name := range names {
+		packages = append(packages, state[name].packages...)
+	}
+	return release, packages, nil
+}
+
+// readLayer reads the package databases and os-release files a layer adds
+// and the paths its whiteouts remove. Layers may be gzip compressed.
+func readLayer(files imageFiles, layer string, index int) (map[string]imageFile, []string, error) {
+	blob, err := files.Open(layer)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer blob.Close()
+
+	r := bufio.NewReader(blob)
+	magic, _ := r.Peek(4)
+	var content io.Reader = r
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+		content = gz
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return nil, nil, fmt.Errorf("zstd compressed layers are not supported")
+	}
+
+	changes := make(map[string]imageFile)
+	var whiteouts []string
+	tr := tar.NewReader(content)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		dir, base := path.Split(name)
+		dir = path.Clean(dir)
+
+		switch {
+		case base == ".wh..wh..opq":
+			whiteouts = append(whiteouts, dir)
+			continue
+		case strings.HasPrefix(base, ".wh."):
+			whiteouts = append(whiteouts, path.Join(dir, strings.TrimPrefix(base, ".wh.")))
+			continue
+		case hdr.Typeflag != tar.TypeReg:
+			continue
+		}
+
+		if name == "etc/os-release" || name == "usr/lib/os-release" {
+			release, err := readOSRelease(tr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			changes[name] = imageFile{release: release}
+			continue
+		}
+		read, ok := osPackageReader(name)
+		if !ok {
+			continue
+		}
+		packages, err := read(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		for i := range packages {
+			packages[i].Layer = index
+		}
+		changes[name] = imageFile{packages: packages}
+	}
+	return changes, whiteouts, nil
+}
@@ -0,0 +1,600 @@
+package compliance
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/schema"
+)
+
+// Reduced CycloneDX and SPDX specification schemas that imported SBOMs are
+// validated against before TraceSync relies on their content. The schemas
+// cover every supported version; documents of a known version are checked
+// against a copy narrowed to that version.
+var (
+	//go:embed schemas/cyclonedx.yaml
+	cycloneDXSchemaData []byte
+	//go:embed schemas/cyclonedx-component.yaml
+	cycloneDXComponentSchemaData []byte
+	//go:embed schemas/spdx.yaml
+	spdxSchemaData []byte
+
+	cycloneDXSchema          = mustParseSchema(cycloneDXSchemaData)
+	cycloneDXComponentSchema = mustParseSchema(cycloneDXComponentSchemaData)
+	spdxSchema               = mustParseSchema(spdxSchemaData)
+)
+
+// cycloneDXComponentTypes lists the component types each CycloneDX version
+// defines.
+var cycloneDXComponentTypes = func() map[string][]string {
+	types := []string{"application", "framework", "library", "container", "operating-system", "device", "firmware", "file"}
+	v15 := append(append([]string(nil), types...), "platform", "device-driver", "machine-learning-model", "data")
+	return map[string][]string{
+		"1.2": types,
+		"1.3": types,
+		"1.4": types,
+		"1.5": v15,
+		"1.6": append(append([]string(nil), v15...), "cryptographic-asset"),
+	}
+}()
+
+// cycloneDXSchemas holds, by specVersion, the document and component
+// schemas of each CycloneDX version. Before 1.4 components need a version,
+// and before 1.5 so does the document.
+var cycloneDXSchemas = func() map[string][2]*schema.Schema {
+	schemas := make(map[string][2]*schema.Schema)
+	for version, types := range cycloneDXComponentTypes {
+		document := mustParseSchema(cycloneDXSchemaData)
+		component := mustParseSchema(cycloneDXComponentSchemaData)
+		if version < "1.5" {
+			document.Required = append(document.Required, "version")
+		}
+		if version < "1.4" {
+			component.Required = append(component.Required, "version")
+		}
+		component.Properties["type"].Enum = make([]interface{}, len(types))
+		for i, componentType := range types {
+			component.Properties["type"].Enum[i] = componentType
+		}
+		schemas[version] = [2]*schema.Schema{document, component}
+	}
+	return schemas
+}()
+
+// spdxSchemas holds, by spdxVersion, the schema of each SPDX version. SPDX
+// 2.2 packages must have licenses and a copyright text, which 2.3 made
+// optional.
+var spdxSchemas = func() map[string]*schema.Schema {
+	v22 := mustParseSchema(spdxSchemaData)
+	pkg := v22.Properties["packages"].Items
+	pkg.Required = append(pkg.Required, "licenseConcluded", "licenseDeclared", "copyrightText")
+	return map[string]*schema.Schema{"SPDX-2.2": v22, "SPDX-2.3": spdxSchema}
+}()
+
+// spdx23PackageFields are the package fields SPDX 2.3 introduced.
+var spdx23PackageFields = []string{"primaryPackagePurpose", "releaseDate", "builtDate", "validUntilDate"}
+
+func mustParseSchema(data []byte) *schema.Schema {
+	s, err := schema.Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// propertySBOMSource names the subject property recording the file an
+// imported SBOM came from.
+const propertySBOMSource = "tracesync:sbom:source"
+
+// SBOMValidationError lists every way an SBOM violates its specification.
+type SBOMValidationError struct {
+	Path       string
+	Format     Format
+	Violations []schema.Violation
+}
+
+func (e *SBOMValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return fmt.Sprintf("%s is not a valid %s SBOM: %s", e.Path, e.Format, strings.Join(messages, "; "))
+}
+
+// ValidateSBOM checks a CycloneDX or SPDX document against its
+// specification: the structure required by the spec schema, and the
+// consistency of references, package URLs and license expressions. It
+// returns the detected format and the violations found, ordered by path. An
+// error is returned for documents in neither format.
+func ValidateSBOM(data []byte) (Format, []schema.Violation, error) {
+	_, format, err := DecodeSBOM(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var document map[string]interface{}
+	switch format {
+	case FormatCycloneDX, FormatSPDXJSON:
+		if err := json.Unmarshal(data, &document); err != nil {
+			return "", nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case FormatSPDXTagValue:
+		// Tag-value documents are validated in their JSON form
+		doc, err := parseSPDXTagValue(bytes.TrimSpace(data))
+		if err != nil {
+			return "", nil, err
+		}
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to convert SPDX document: %w", err)
+		}
+		if err := json.Unmarshal(encoded, &document); err != nil {
+			return "", nil, fmt.Errorf("failed to convert SPDX document: %w", err)
+		}
+		pruneEmpty(document)
+	default:
+		return "", nil, fmt.Errorf("%s SBOMs cannot be imported, only CycloneDX and SPDX", format)
+	}
+
+	var violations []schema.Violation
+	if format == FormatCycloneDX {
+		violations = validateCycloneDX(document)
+	} else {
+		violations = validateSPDX(document, spdxTagValueIDs(data, format))
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return format, violations, nil
+}
+
+func validateCycloneDX(document map[string]interface{}) []schema.Violation {
+	documentSchema, componentSchema := cycloneDXSchema, cycloneDXComponentSchema
+	if version, ok := document["specVersion"].(string); ok {
+		if schemas, ok := cycloneDXSchemas[version]; ok {
+			documentSchema, componentSchema = schemas[0], schemas[1]
+		}
+	}
+	violations := documentSchema.Validate(document)
+	report := func(path, format string, args ...interface{}) {
+		violations = append(violations, schema.Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Every component is checked wherever it is nested, and its bom-ref
+	// must be unique in the document
+	refs := make(map[string]bool)
+	var visit func(path string, value interface{})
+	visit = func(path string, value interface{}) {
+		component, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, violation := range componentSchema.Validate(component) {
+			violation.Path = path + strings.TrimSuffix(violation.Path, "/")
+			violations = append(violations, violation)
+		}
+		if ref, ok := component["bom-ref"].(string); ok && ref != "" {
+			if refs[ref] {
+				report(path+"/bom-ref", "duplicate bom-ref %q", ref)
+			}
+			refs[ref] = true
+		}
+		if purl, ok := component["purl"].(string); ok && purl != "" {
+			if _, err := parsePackageURL(purl); err != nil {
+				report(path+"/purl", "%v", err)
+			}
+		}
+		licenses, _ := component["licenses"].([]interface{})
+		for i, choice := range licenses {
+			choice, _ := choice.(map[string]interface{})
+			choicePath := fmt.Sprintf("%s/licenses/%d", path, i)
+			license, _ := choice["license"].(map[string]interface{})
+			expression, hasExpression := choice["expression"].(string)
+			switch {
+			case hasExpression && license != nil:
+				report(choicePath, "must have either a license or an expression")
+			case hasExpression:
+				if _, err := ParseLicenseExpression(expression); err != nil {
+					report(choicePath+"/expression", "%v", err)
+				}
+			case license != nil:
+				id, hasID := license["id"].(string)
+				_, hasName := license["name"].(string)
+				if hasID == hasName {
+					report(choicePath+"/license", "must have either an id or a name")
+				} else if hasID && !isLicenseToken(id) {
+					report(choicePath+"/license/id", "invalid license id %q", id)
+				}
+			default:
+				report(choicePath, "must have a license or an expression")
+			}
+		}
+		children, _ := component["components"].([]interface{})
+		for i, child := range children {
+			visit(fmt.Sprintf("%s/components/%d", path, i), child)
+		}
+	}
+	if metadata, ok := document["metadata"].(map[string]interface{}); ok {
+		if component, ok := metadata["component"]; ok {
+			visit("/metadata/component", component)
+		}
+	}
+	components, _ := document["components"].([]interface{})
+	for i, component := range components {
+		visit(fmt.Sprintf("/components/%d", i), component)
+	}
+
+	// Dependencies may only reference components of the document
+	dependencies, _ := document["dependencies"].([]interface{})
+	for i, dependency := range dependencies {
+		dependency, _ := dependency.(map[string]interface{})
+		path := fmt.Sprintf("/dependencies/%d", i)
+		if ref, ok := dependency["ref"].(string); ok && ref != "" && !refs[ref] {
+			report(path+"/ref", "unknown bom-ref %q", ref)
+		}
+		dependsOn, _ := dependency["dependsOn"].([]interface{})
+		for j, ref := range dependsOn {
+			if ref, ok := ref.(string); ok && ref != "" && !refs[ref] {
+				report(fmt.Sprintf("%s/dependsOn/%d", path, j), "unknown bom-ref %q", ref)
+			}
+		}
+	}
+	return violations
+}
+
+func validateSPDX(document map[string]interface{}, tagValueIDs []string) []schema.Violation {
+	documentSchema := spdxSchema
+	version, _ := document["spdxVersion"].(string)
+	if versionSchema, ok := spdxSchemas[version]; ok {
+		documentSchema = versionSchema
+	}
+	violations := documentSchema.Validate(document)
+	report := func(path, format string, args ...interface{}) {
+		violations = append(violations, schema.Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Relationships may reference the document, its packages, files and
+	// snippets, and elements of external documents
+	documentID, _ := document["SPDXID"].(string)
+	ids := map[string]bool{documentID: true}
+	for _, id := range tagValueIDs {
+		ids[id] = true
+	}
+	for _, section := range []string{"files", "snippets"} {
+		elements, _ := document[section].([]interface{})
+		for _, element := range elements {
+			element, _ := element.(map[string]interface{})
+			if id, ok := element["SPDXID"].(string); ok {
+				ids[id] = true
+			}
+		}
+	}
+
+	declared := make(map[string]bool)
+	extracted, _ := document["hasExtractedLicensingInfos"].([]interface{})
+	for _, license := range extracted {
+		license, _ := license.(map[string]interface{})
+		if id, ok := license["licenseId"].(string); ok {
+			declared[id] = true
+		}
+	}
+
+	packageIDs := make(map[string]bool)
+	packages, _ := document["packages"].([]interface{})
+	for i, pkg := range packages {
+		pkg, _ := pkg.(map[string]interface{})
+		path := fmt.Sprintf("/packages/%d", i)
+		if id, ok := pkg["SPDXID"].(string); ok && id != "" {
+			if packageIDs[id] || id == documentID {
+				report(path+"/SPDXID", "duplicate SPDXID %q", id)
+			}
+			packageIDs[id] = true
+			ids[id] = true
+		}
+		for _, field := range []string{"licenseConcluded", "licenseDeclared"} {
+			license, ok := pkg[field].(string)
+			if !ok || license == "" || license == noAssertion || license == "NONE" {
+				continue
+			}
+			expression, err := ParseLicenseExpression(license)
+			if err != nil {
+				report(path+"/"+field, "%v", err)
+				continue
+			}
+			for _, id := range expression.Licenses() {
+				if strings.HasPrefix(id, "LicenseRef-") && !declared[id] {
+					report(path+"/"+field, "%s is not declared in hasExtractedLicensingInfos", id)
+				}
+			}
+		}
+		if version == "SPDX-2.2" {
+			for _, field := range spdx23PackageFields {
+				if _, ok := pkg[field]; ok {
+					report(path+"/"+field, "is not defined before SPDX-2.3")
+				}
+			}
+		}
+		refs, _ := pkg["externalRefs"].([]interface{})
+		for j, ref := range refs {
+			ref, _ := ref.(map[string]interface{})
+			locator, _ := ref["referenceLocator"].(string)
+			if ref["referenceType"] == "purl" && locator != "" {
+				if _, err := parsePackageURL(locator); err != nil {
+					report(fmt.Sprintf("%s/externalRefs/%d/referenceLocator", path, j), "%v", err)
+				}
+			}
+		}
+	}
+
+	describes, _ := document["documentDescribes"].([]interface{})
+	for i, id := range describes {
+		if id, ok := id.(string); ok && !ids[id] {
+			report(fmt.Sprintf("/documentDescribes/%d", i), "unknown SPDXID %q", id)
+		}
+	}
+	described := len(describes) > 0
+	relationships, _ := document["relationships"].([]interface{})
+	for i, relationship := range relationships {
+		relationship, _ := relationship.(map[string]interface{})
+		path := fmt.Sprintf("/relationships/%d", i)
+		from, _ := relationship["spdxElementId"].(string)
+		to, _ := relationship["relatedSpdxElement"].(string)
+		for field, id := range map[string]string{"spdxElementId": from, "relatedSpdxElement": to} {
+			if !knownSPDXElement(ids, id) {
+				report(path+"/"+field, "unknown SPDXID %q", id)
+			}
+		}
+		switch relationship["relationshipType"] {
+		case "DESCRIBES":
+			described = described || from == documentID
+		case "DESCRIBED_BY":
+			described = described || to == documentID
+		}
+	}
+	if len(packages) > 0 && !described {
+		report("/relationships", "the document must describe at least one package")
+	}
+	return violations
+}
+
+// knownSPDXElement reports whether a relationship may reference an element:
+// one defined in the document, one of an external document, or NONE or
+// NOASSERTION.
+func knownSPDXElement(ids map[string]bool, id string) bool {
+	return id == "" || ids[id] || id == noAssertion || id == "NONE" || strings.HasPrefix(id, "DocumentRef-")
+}
+
+// spdxTagValueIDs lists the identifiers a tag-value document defines,
+// including those of the file and snippet sections the parser skips.
+func spdxTagValueIDs(data []byte, format Format) []string {
+	if format != FormatSPDXTagValue {
+		return nil
+	}
+	var ids []string
+	for _, line := range strings.Split(string(data), "\n") {
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(tag) {
+		case "SPDXID", "SnippetSPDXID":
+			ids = append(ids, strings.TrimSpace(value))
+		}
+	}
+	return ids
+}
+
+// pruneEmpty removes empty strings and null values from a decoded
+// document, so that "required" treats them as missing.
+func pruneEmpty(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if child == nil || child == "" {
+				delete(v, key)
+				continue
+			}
+			pruneEmpty(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			pruneEmpty(child)
+		}
+	}
+}
+
+// ImportedSBOMPath returns where the copy of an SBOM imported for an
+// artifact is kept: <name>-sbom.imported.json for CycloneDX,
+// <name>-sbom.imported.spdx.json for SPDX JSON and
+// <name>-sbom.imported.spdx for SPDX tag-value.
+func ImportedSBOMPath(artifactPath, name string, format Format) string {
+	suffix := strings.TrimPrefix(filepath.Base(SBOMPath(artifactPath, name, format)), name+"-sbom")
+	return filepath.Join(filepath.Dir(artifactPath), name+"-sbom.imported"+suffix)
+}
+
+// ImportSBOM validates a CycloneDX or SPDX SBOM supplied for an artifact,
+// such as one from the vendor of a third-party artifact, and attaches it to
+// the artifact: a copy is kept next to the artifact and recorded in its
+// descriptor entry, and from then on the SBOM is used in place of the one
+// TraceSync would generate. Invalid SBOMs are rejected with an
+// *SBOMValidationError. It returns the path of the copy.
+func ImportSBOM(artifactPath, sbomPath string) (string, error) {
+	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SBOM: %w", err)
+	}
+
+	format, violations, err := ValidateSBOM(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to import SBOM %s: %w", sbomPath, err)
+	}
+	if len(violations) > 0 {
+		return "", &SBOMValidationError{Path: sbomPath, Format: format, Violations: violations}
+	}
+	sbom, _, err := DecodeSBOM(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode SBOM %s: %w", sbomPath, err)
+	}
+	if err := checkSubject(sbom, metadata); err != nil {
+		return "", fmt.Errorf("failed to import SBOM %s: %w", sbomPath, err)
+	}
+
+	// Keep the document as supplied, so that vendor signatures over it
+	// remain verifiable
+	importedPath := ImportedSBOMPath(artifactPath, metadata.Name, format)
+	if err := os.WriteFile(importedPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write SBOM file: %w", err)
+	}
+	if previous := metadata.SBOM; previous != nil && previous.File != filepath.Base(importedPath) {
+		if err := os.Remove(filepath.Join(filepath.Dir(artifactPath), previous.File)); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to remove previously imported SBOM: %w", err)
+		}
+	}
+	_, digests, err := artifactmanager.ComputeDigests(importedPath, artifactmanager.DigestSHA256)
+	if err != nil {
+		return "", err
+	}
+	if err := artifactmanager.SetImportedSBOM(artifactPath, artifactmanager.ImportedSBOM{
+		File:       filepath.Base(importedPath),
+		Source:     sbomPath,
+		Format:     string(format),
+		Digests:    digests,
+		ImportedAt: time.Now().UTC(),
+	}); err != nil {
+		return "", err
+	}
+	return importedPath, nil
+}
+
+// ArtifactSBOM returns the SBOM of an artifact: the imported SBOM,
+// normalized into TraceSync's model, if one was imported, otherwise the SBOM
+// built from the artifact's content.
+func ArtifactSBOM(artifactPath string, metadata artifactmanager.ArtifactMetadata) (*SBOM, error) {
+	if metadata.SBOM == nil {
+		return BuildSBOM(artifactPath, metadata)
+	}
+
+	imported := metadata.SBOM
+	path := filepath.Join(filepath.Dir(artifactPath), imported.File)
+	_, digests, err := artifactmanager.ComputeDigests(path, artifactmanager.DigestSHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read imported SBOM: %w", err)
+	}
+	if digests[artifactmanager.DigestSHA256] != imported.Digests[artifactmanager.DigestSHA256] {
+		return nil, fmt.Errorf("imported SBOM %s was modified after it was imported, import it again", path)
+	}
+	sbom, err := LoadSBOM(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubject(sbom, metadata); err != nil {
+		return nil, fmt.Errorf("imported SBOM %s: %w", path, err)
+	}
+	if err := normalizeSBOM(sbom, metadata); err != nil {
+		return nil, err
+	}
+	return sbom, nil
+}
+
+// checkSubject makes sure an SBOM describes the artifact's content, where
+// its subject has a hash the artifact also has a digest for.
+func checkSubject(sbom *SBOM, metadata artifactmanager.ArtifactMetadata) error {
+	for algorithm, digest := range metadata.Digests {
+		if hash, ok := sbom.Subject.Hashes[algorithm]; ok && !strings.EqualFold(hash, digest) {
+			return fmt.Errorf("the SBOM describes different content: its %s hash %s does not match the artifact's %s", algorithm, hash, digest)
+		}
+	}
+	return nil
+}
+
+// normalizeSBOM completes an imported SBOM the way TraceSync generates
+// SBOMs: it is named after the artifact, whose digests, license and tags
+// are added to the subject, every component has a bom-ref, the subject
+// depends on the top-level components, and library components without a
+// version are marked as not pinned.
+func normalizeSBOM(sbom *SBOM, metadata artifactmanager.ArtifactMetadata) error {
+	if sbom.SerialNumber == "" {
+		serial, err := newSerialNumber()
+		if err != nil {
+			return err
+		}
+		sbom.SerialNumber = serial
+	}
+	if sbom.CreatedAt.IsZero() {
+		sbom.CreatedAt = metadata.SBOM.ImportedAt
+	}
+	sbom.Name = metadata.Name
+	sbom.Version = metadata.Version
+	if sbom.Description == "" {
+		sbom.Description = fmt.Sprintf("SBOM for %s", metadata.Name)
+	}
+
+	subject := &sbom.Subject
+	if subject.Name == "" {
+		subject.Type = ComponentFile
+		subject.Name = metadata.Name
+		subject.Version = metadata.Version
+	}
+	if subject.BOMRef == "" {
+		subject.BOMRef = fmt.Sprintf("%s@%s", metadata.Name, metadata.Version)
+	}
+	if subject.License == "" {
+		subject.License = metadata.Tags["license"]
+	}
+	mergeHashes(subject, Component{Hashes: metadata.Digests})
+	if subject.Properties == nil {
+		subject.Properties = make(map[string]string)
+	}
+	for name, value := range artifactProperties(metadata) {
+		if _, ok := subject.Properties[name]; !ok {
+			subject.Properties[name] = value
+		}
+	}
+	subject.Properties[propertySBOMSource] = metadata.SBOM.Source
+
+	markUnversioned(sbom.Components, metadata.SBOM.File)
+	assignBOMRefs(sbom)
+	for _, dependency := range sbom.Dependencies {
+		if dependency.Ref == subject.BOMRef {
+			return nil
+		}
+	}
+	sbom.Dependencies = append([]Dependency{{
+		Ref:       subject.BOMRef,
+		DependsOn: rootComponents(sbom.Components, sbom.Dependencies),
+	}}, sbom.Dependencies...)
+	return nil
+}
+
+// markUnversioned marks libraries and frameworks that have no version as
+// not pinned, so the pinning check reports them as it does for dependency
+// manifests.
+func markUnversioned(components []Component, file string) {
+	for i := range components {
+		component := &components[i]
+		if component.Version == "" && (component.Type == ComponentLibrary || component.Type == "framework") {
+			if component.Properties == nil {
+				component.Properties = make(map[string]string)
+			}
+			if _, ok := component.Properties[propertyUnpinned]; !ok {
+				component.Properties[propertyUnpinned] = "no version"
+			}
+			if _, ok := component.Properties[propertyLocation]; !ok {
+				component.Properties[propertyLocation] = file
+			}
+		}
+		markUnversioned(component.Components, file)
+	}
+}
@@ -0,0 +1,49 @@
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// legacySBOM is the original TraceSync JSON format, kept for consumers that
+// have not moved to CycloneDX or SPDX.
+type legacySBOM struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Description string            `json:"description"`
+	Components  []legacyComponent `json:"components"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type legacyComponent struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+	License string `json:"license"`
+}
+
+func encodeLegacy(sbom *SBOM) ([]byte, error) {
+	legacy := legacySBOM{
+		Name:        sbom.Name,
+		Version:     sbom.Version,
+		Description: sbom.Description,
+		Components:  []legacyComponent{},
+		CreatedAt:   sbom.CreatedAt,
+	}
+	for _, component := range sbom.Components {
+		legacy.Components = append(legacy.Components, legacyComponent{
+			Name:    component.Name,
+			Version: component.Version,
+			Type:    component.Type,
+			License: component.License,
+		})
+	}
+
+	// Convert SBOM to JSON
+	data, err := json.MarshalIndent(legacy, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SBOM to JSON: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,241 @@
+package compliance
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Strictness levels of a license policy. They decide how licenses that
+// need review, or that are not on any list, are reported; denied licenses
+// always fail.
+const (
+	// StrictnessStrict fails on review-required and unknown licenses.
+	StrictnessStrict = "strict"
+	// StrictnessStandard warns about review-required and unknown licenses.
+	StrictnessStandard = "standard"
+	// StrictnessPermissive warns about review-required licenses only.
+	StrictnessPermissive = "permissive"
+)
+
+// Severities of license findings.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LicensePolicy lists allowed, denied and review-required licenses. Entries
+// are SPDX license ids, optionally with "+" or a WITH exception, or license
+// names; they match case-insensitively. When Allowed is empty, licenses on
+// no list are allowed; otherwise they are unknown, like missing licenses.
+type LicensePolicy struct {
+	Allowed []string `yaml:"allowed" json:"allowed"`
+	Denied  []string `yaml:"denied" json:"denied"`
+	Review  []string `yaml:"review" json:"review"`
+	// Strictness maps environments, as set with --env, to a strictness
+	// level. Other environments use DefaultStrictness, or standard.
+	Strictness        map[string]string `yaml:"strictness" json:"strictness"`
+	DefaultStrictness string            `yaml:"default_strictness" json:"default_strictness"`
+}
+
+// LicenseFinding reports a component whose license the policy does not
+// allow outright.
+type LicenseFinding struct {
+	// Component is the component name and version.
+	Component string
+	License   string
+	Severity  string
+	Reason    string
+}
+
+func (f LicenseFinding) String() string {
+	if f.License == "" {
+		return fmt.Sprintf("%s: %s", f.Component, f.Reason)
+	}
+	return fmt.Sprintf("%s: %s %s", f.Component, f.License, f.Reason)
+}
+
+var (
+	licensePolicyMu    sync.RWMutex
+	licensePolicy      *LicensePolicy
+	licenseEnvironment string
+)
+
+// SetLicensePolicy installs the license policy PerformComplianceCheck
+// applies, with the strictness of the given environment. A nil policy turns
+// license checks off, which is the default.
+func SetLicensePolicy(policy *LicensePolicy, environment string) {
+	licensePolicyMu.Lock()
+	defer licensePolicyMu.Unlock()
+	licensePolicy = policy
+	licenseEnvironment = environment
+}
+
+func currentLicensePolicy() (*LicensePolicy, string) {
+	licensePolicyMu.RLock()
+	defer licensePolicyMu.RUnlock()
+	return licensePolicy, licenseEnvironment
+}
+
+// LoadLicensePolicy reads a license policy from a YAML or JSON file.
+func LoadLicensePolicy(path string) (*LicensePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read license policy: %w", err)
+	}
+	var policy LicensePolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse license policy %s: %w", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("license policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+func (p *LicensePolicy) validate() error {
+	levels := []string{p.DefaultStrictness}
+	for _, level := range p.Strictness {
+		levels = append(levels, level)
+	}
+	for _, level := range levels {
+		switch level {
+		case "", StrictnessStrict, StrictnessStandard, StrictnessPermissive:
+		default:
+			return fmt.Errorf("unknown strictness %q (supported: strict, standard, permissive)", level)
+		}
+	}
+	return nil
+}
+
+// StrictnessFor returns the strictness level of an environment.
+func (p *LicensePolicy) StrictnessFor(environment string) string {
+	if level := p.Strictness[environment]; level != "" {
+		return level
+	}
+	if p.DefaultStrictness != "" {
+		return p.DefaultStrictness
+	}
+	return StrictnessStandard
+}
+
+// licenseDecision is the policy's verdict on a license, from best to worst.
+type licenseDecision int
+
+const (
+	licenseAllowed licenseDecision = iota
+	licenseReview
+	licenseUnknown
+	licenseDenied
+)
+
+// Check evaluates the license of every component under the strictness of
+// an environment and returns the findings, in component order.
+func (p *LicensePolicy) Check(components []Component, environment string) []LicenseFinding {
+	strictness := p.StrictnessFor(environment)
+	var findings []LicenseFinding
+	for _, component := range components {
+		name := component.Name
+		if component.Version != "" {
+			name += "@" + component.Version
+		}
+		decision := p.decideLicense(component.License)
+		severity := decisionSeverity(decision, strictness)
+		if severity == "" {
+			continue
+		}
+		finding := LicenseFinding{Component: name, License: component.License, Severity: severity}
+		switch {
+		case component.License == "":
+			finding.Reason = "has no license"
+		case decision == licenseDenied:
+			finding.Reason = "is denied"
+		case decision == licenseReview:
+			finding.Reason = "requires review"
+		default:
+			finding.Reason = "is not on the allowed list"
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// decideLicense decides a license or license expression. A choice (OR)
+// takes the best branch and a conjunction (AND) the worst, so "MIT OR
+// GPL-3.0-only" is allowed when MIT is. Licenses that are not valid
+// expressions are matched as a whole against the lists.
+func (p *LicensePolicy) decideLicense(license string) licenseDecision {
+	if strings.TrimSpace(license) == "" {
+		return licenseUnknown
+	}
+	expression, err := ParseLicenseExpression(license)
+	if err != nil {
+		return p.decide([]string{license})
+	}
+	return p.evaluate(expression)
+}
+
+func (p *LicensePolicy) evaluate(e *LicenseExpression) licenseDecision {
+	switch e.Operator {
+	case "OR":
+		return min(p.evaluate(e.Left), p.evaluate(e.Right))
+	case "AND":
+		return max(p.evaluate(e.Left), p.evaluate(e.Right))
+	}
+
+	// The most specific entry wins: "GPL-2.0-only WITH Classpath-exception-2.0"
+	// before "GPL-2.0-only"
+	var candidates []string
+	if e.Exception != "" {
+		candidates = append(candidates, e.String())
+	}
+	if e.OrLater {
+		candidates = append(candidates, e.License+"+")
+	}
+	return p.decide(append(candidates, e.License))
+}
+
+func (p *LicensePolicy) decide(candidates []string) licenseDecision {
+	for _, candidate := range candidates {
+		switch {
+		case containsFold(p.Denied, candidate):
+			return licenseDenied
+		case containsFold(p.Review, candidate):
+			return licenseReview
+		case containsFold(p.Allowed, candidate):
+			return licenseAllowed
+		}
+	}
+	if len(p.Allowed) == 0 {
+		return licenseAllowed
+	}
+	return licenseUnknown
+}
+
+// decisionSeverity returns how a decision is reported under a strictness
+// level, or "" when it is not reported.
+func decisionSeverity(decision licenseDecision, strictness string) string {
+	switch {
+	case decision == licenseDenied:
+		return SeverityError
+	case decision == licenseAllowed:
+		return ""
+	case strictness == StrictnessStrict:
+		return SeverityError
+	case strictness == StrictnessPermissive && decision == licenseUnknown:
+		return ""
+	}
+	return SeverityWarning
+}
+
+func containsFold(list []string, value string) bool {
+	for _, entry := range list {
+		if strings.EqualFold(strings.Join(strings.Fields(entry), " "), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package compliance
+
+import "strings"
+
+// spdxLicenseIDs holds the SPDX license identifiers that are commonly found
+// in dependency metadata. Identifiers outside this list are written as
+// license names rather than ids.
+var spdxLicenseIDs = map[string]bool{
+	"0BSD": true, "AFL-3.0": true, "AGPL-1.0-only": true, "AGPL-1.0-or-later": true,
+	"AGPL-3.0-only": true, "AGPL-3.0-or-later": true, "Apache-1.1": true, "Apache-2.0": true,
+	"APSL-2.0": true, "Artistic-1.0": true, "Artistic-2.0": true, "BlueOak-1.0.0": true,
+	"BSD-1-Clause": true, "BSD-2-Clause": true, "BSD-2-Clause-Patent": true, "BSD-3-Clause": true,
+	"BSD-3-Clause-Clear": true, "BSD-4-Clause": true, "BSL-1.0": true, "bzip2-1.0.6": true,
+	"CAL-1.0": true, "CC-BY-3.0": true, "CC-BY-4.0": true, "CC-BY-NC-4.0": true,
+	"CC-BY-NC-SA-4.0": true, "CC-BY-ND-4.0": true, "CC-BY-SA-3.0": true, "CC-BY-SA-4.0": true,
+	"CC0-1.0": true, "CDDL-1.0": true, "CDDL-1.1": true, "CPL-1.0": true,
+	"curl": true, "ECL-2.0": true, "EPL-1.0": true, "EPL-2.0": true,
+	"EUPL-1.1": true, "EUPL-1.2": true, "FTL": true, "GFDL-1.3-only": true,
+	"GFDL-1.3-or-later": true, "GPL-1.0-only": true, "GPL-1.0-or-later": true, "GPL-2.0-only": true,
+	"GPL-2.0-or-later": true, "GPL-3.0-only": true, "GPL-3.0-or-later": true, "HPND": true,
+	"ICU": true, "IJG": true, "ISC": true, "LGPL-2.0-only": true,
+	"LGPL-2.0-or-later": true, "LGPL-2.1-only": true, "LGPL-2.1-or-later": true, "LGPL-3.0-only": true,
+	"LGPL-3.0-or-later": true, "Libpng": true, "libtiff": true, "MIT": true,
+	"MIT-0": true, "MPL-1.1": true, "MPL-2.0": true, "MPL-2.0-no-copyleft-exception": true,
+	"MS-PL": true, "MS-RL": true, "NCSA": true, "ODbL-1.0": true,
+	"OFL-1.1": true, "OpenSSL": true, "OSL-3.0": true, "PHP-3.01": true,
+	"PostgreSQL": true, "PSF-2.0": true, "Python-2.0": true, "Ruby": true,
+	"SSPL-1.0": true, "Unicode-3.0": true, "Unicode-DFS-2016": true, "Unlicense": true,
+	"UPL-1.0": true, "Vim": true, "W3C": true, "WTFPL": true,
+	"X11": true, "Zlib": true, "ZPL-2.1": true,
+	// Deprecated identifiers that are still widespread
+	"AGPL-3.0": true, "GPL-2.0": true, "GPL-3.0": true, "LGPL-2.1": true, "LGPL-3.0": true,
+}
+
+// IsSPDXLicenseID reports whether license is a known SPDX license identifier.
+func IsSPDXLicenseID(license string) bool {
+	return spdxLicenseIDs[license]
+}
+
+// spdxExpression parses license as an SPDX license expression whose licenses
+// are all known identifiers or LicenseRef- references, and returns it in
+// canonical form.
+func spdxExpression(license string) (string, bool) {
+	expression, err := ParseLicenseExpression(license)
+	if err != nil {
+		return "", false
+	}
+	var check func(*LicenseExpression) bool
+	check = func(e *LicenseExpression) bool {
+		if e.Operator != "" {
+			return check(e.Left) && check(e.Right)
+		}
+		return spdxLicenseIDs[e.License] || strings.HasPrefix(e.License, "LicenseRef-") ||
+			strings.HasPrefix(e.License, "DocumentRef-")
+	}
+	if !check(expression) {
+		return "", false
+	}
+	return expression.String(), true
+}
+
+// copyleftLicenses holds the licenses that require derived works, or
+// modified files for weak copyleft licenses, to be shared under the same
+// terms.
+var copyleftLicenses = map[string]bool{
+	"AGPL-1.0-only": true, "AGPL-1.0-or-later": true, "AGPL-3.0": true, "AGPL-3.0-only": true,
+	"AGPL-3.0-or-later": true, "CC-BY-NC-SA-4.0": true, "CC-BY-SA-3.0": true, "CC-BY-SA-4.0": true,
+	"CDDL-1.0": true, "CDDL-1.1": true, "CPL-1.0": true, "EPL-1.0": true,
+	"EPL-2.0": true, "EUPL-1.1": true, "EUPL-1.2": true, "GFDL-1.3-only": true,
+	"GFDL-1.3-or-later": true, "GPL-1.0-only": true, "GPL-1.0-or-later": true, "GPL-2.0": true,
+	"GPL-2.0-only": true, "GPL-2.0-or-later": true, "GPL-3.0": true, "GPL-3.0-only": true,
+	"GPL-3.0-or-later": true, "LGPL-2.0-only": true, "LGPL-2.0-or-later": true, "LGPL-2.1": true,
+	"LGPL-2.1-only": true, "LGPL-2.1-or-later": true, "LGPL-3.0": true, "LGPL-3.0-only": true,
+	"LGPL-3.0-or-later": true, "MPL-1.1": true, "MPL-2.0": true, "MS-RL": true,
+	"ODbL-1.0": true, "OSL-3.0": true, "SSPL-1.0": true,
+}
+
+// IsCopyleft reports whether a license or license expression imposes a
+// copyleft license: a choice (OR) does only if every alternative does, a
+// conjunction (AND) if any part does. Licenses that are not SPDX
+// expressions are not classified.
+func IsCopyleft(license string) bool {
+	expression, err := ParseLicenseExpression(license)
+	if err != nil {
+		return false
+	}
+	return expression.isCopyleft()
+}
+
+func (e *LicenseExpression) isCopyleft() bool {
+	switch e.Operator {
+	case "OR":
+		return e.Left.isCopyleft() && e.Right.isCopyleft()
+	case "AND":
+		return e.Left.isCopyleft() || e.Right.isCopyleft()
+	}
+	return copyleftLicenses[e.License]
+}
@@ -0,0 +1,289 @@
+package compliance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VersionConflict reports a component that the merged SBOMs include in
+// different versions.
+type VersionConflict struct {
+	Name string `json:"name" yaml:"name"`
+	// Versions maps each version to the names of the SBOMs including it.
+	Versions map[string][]string `json:"versions" yaml:"versions"`
+}
+
+func (c VersionConflict) String() string {
+	versions := make([]string, 0, len(c.Versions))
+	for version := range c.Versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	parts := make([]string, 0, len(versions))
+	for _, version := range versions {
+		parts = append(parts, fmt.Sprintf("%s (%s)", version, strings.Join(c.Versions[version], ", ")))
+	}
+	return fmt.Sprintf("%s: %s", c.Name, strings.Join(parts, ", "))
+}
+
+// MergeSBOMs combines the SBOMs of the artifacts of a release into one SBOM
+// describing the release. The subject of each SBOM becomes a component the
+// release depends on. Components with the same package URL, or sharing a
+// hash, are merged into one, keeping every dependency relationship;
+// bom-refs are renamed where the SBOMs use the same one for different
+// components. Components included in several versions are returned as
+// conflicts.
+func MergeSBOMs(name, version string, sboms []*SBOM) (*SBOM, []VersionConflict, error) {
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := &SBOM{
+		SerialNumber: serial,
+		Name:         name,
+		Version:      version,
+		Description:  fmt.Sprintf("SBOM for release %s", name),
+		CreatedAt:    time.Now().UTC(),
+		Subject: Component{
+			BOMRef:  fmt.Sprintf("%s@%s", name, version),
+			Type:    ComponentApplication,
+			Name:    name,
+			Version: version,
+		},
+	}
+
+	m := &merger{
+		sbom:     merged,
+		byPURL:   make(map[string]int),
+		byHash:   make(map[string]int),
+		usedRefs: map[string]bool{merged.Subject.BOMRef: true},
+		sources:  make(map[int][]string),
+	}
+	releaseDependsOn := []string{}
+	for _, sbom := range sboms {
+		source := sbom.Name
+		if sbom.Version != "" {
+			source += "@" + sbom.Version
+		}
+		refs := make(map[string]string)
+		subject := m.add(sbom.Subject, source, refs)
+		for _, component := range sbom.Components {
+			m.add(component, source, refs)
+		}
+		if subject != "" {
+			releaseDependsOn = appendUnique(releaseDependsOn, subject)
+		}
+		for _, dependency := range sbom.Dependencies {
+			from, ok := refs[dependency.Ref]
+			if !ok {
+				continue
+			}
+			for _, ref := range dependency.DependsOn {
+				if to, ok := refs[ref]; ok && to != from {
+					m.dependsOn(from, to)
+				}
+			}
+		}
+	}
+	merged.Dependencies = append([]Dependency{{Ref: merged.Subject.BOMRef, DependsOn: releaseDependsOn}}, m.dependencies...)
+	return merged, m.conflicts(), nil
+}
+
+type merger struct {
+	sbom *SBOM
+	// byPURL and byHash index the merged components; hashes are keyed as
+	// algorithm:value.
+	byPURL   map[string]int
+	byHash   map[string]int
+	usedRefs map[string]bool
+	// sources lists the SBOMs each merged component came from.
+	sources         map[int][]string
+	dependencies    []Dependency
+	dependencyIndex map[string]int
+}
+
+// add merges a component of an SBOM and records the bom-refs it and its
+// nested components have in the merged SBOM. It returns the component's
+// merged bom-ref.
+func (m *merger) add(component Component, source string, refs map[string]string) string {
+	if i, ok := m.find(component); ok {
+		existing := &m.sbom.Components[i]
+		mergeHashes(existing, component)
+		if existing.License == "" {
+			existing.License = component.License
+		}
+		for name, value := range component.Properties {
+			if existing.Properties == nil {
+				existing.Properties = make(map[string]string)
+			}
+			if _, ok := existing.Properties[name]; !ok {
+				existing.Properties[name] = value
+			}
+		}
+		m.index(*existing, i)
+		m.sources[i] = appendUnique(m.sources[i], source)
+		if component.BOMRef != "" {
+			refs[component.BOMRef] = existing.BOMRef
+		}
+		m.mapNested(existing, component.Components, source, refs)
+		return existing.BOMRef
+	}
+
+	component = m.rename(component, source, refs)
+	i := len(m.sbom.Components)
+	m.sbom.Components = append(m.sbom.Components, component)
+	m.index(component, i)
+	m.sources[i] = []string{source}
+	return component.BOMRef
+}
+
+// find looks up a merged component with the same package URL or a common
+// hash.
+func (m *merger) find(component Component) (int, bool) {
+	if component.PURL != "" {
+		if i, ok := m.byPURL[component.PURL]; ok {
+			return i, true
+		}
+	}
+	for algorithm, value := range component.Hashes {
+		if i, ok := m.byHash[strings.ToLower(algorithm)+":"+value]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (m *merger) index(component Component, i int) {
+	if component.PURL != "" {
+		if _, ok := m.byPURL[component.PURL]; !ok {
+			m.byPURL[component.PURL] = i
+		}
+	}
+	for algorithm, value := range component.Hashes {
+		key := strings.ToLower(algorithm) + ":" + value
+		if _, ok := m.byHash[key]; !ok && value != "" {
+			m.byHash[key] = i
+		}
+	}
+}
+
+// rename gives a component and its nested components bom-refs that are
+// unique in the merged SBOM, prefixing taken ones with the source SBOM.
+func (m *merger) rename(component Component, source string, refs map[string]string) Component {
+	if component.BOMRef != "" {
+		ref := component.BOMRef
+		for n := 1; m.usedRefs[ref]; n++ {
+			ref = fmt.Sprintf("%s:%s", source, component.BOMRef)
+			if n > 1 {
+				ref = fmt.Sprintf("%s:%s-%d", source, component.BOMRef, n)
+			}
+		}
+		m.usedRefs[ref] = true
+		refs[component.BOMRef] = ref
+		component.BOMRef = ref
+	}
+	nested := component.Components
+	component.Components = nil
+	for _, child := range nested {
+		component.Components = append(component.Components, m.rename(child, source, refs))
+	}
+	return component
+}
+
+// mapNested maps the bom-refs of the nested components of a merged
+// duplicate to the nested components of the existing component that match
+// them, by package URL or hash, or else by identity and version. Nested
+// components the existing one lacks are added to it with unique bom-refs.
+func (m *merger) mapNested(existing *Component, nested []Component, source string, refs map[string]string) {
+	for _, child := range nested {
+		i, ok := matchNested(existing.Components, child)
+		if !ok {
+			existing.Components = append(existing.Components, m.rename(child, source, refs))
+			continue
+		}
+		if child.BOMRef != "" {
+			refs[child.BOMRef] = existing.Components[i].BOMRef
+		}
+		m.mapNested(&existing.Components[i], child.Components, source, refs)
+	}
+}
+
+// matchNested finds the component among components that child duplicates.
+func matchNested(components []Component, child Component) (int, bool) {
+	for i, component := range components {
+		if child.PURL != "" && component.PURL == child.PURL {
+			return i, true
+		}
+		for algorithm, value := range child.Hashes {
+			for existing, other := range component.Hashes {
+				if value != "" && other == value && strings.EqualFold(existing, algorithm) {
+					return i, true
+				}
+			}
+		}
+	}
+	identity := componentIdentity(child)
+	for i, component := range components {
+		if componentIdentity(component) == identity && component.Version == child.Version {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (m *merger) dependsOn(from, to string) {
+	if m.dependencyIndex == nil {
+		m.dependencyIndex = make(map[string]int)
+	}
+	i, ok := m.dependencyIndex[from]
+	if !ok {
+		i = len(m.dependencies)
+		m.dependencyIndex[from] = i
+		m.dependencies = append(m.dependencies, Dependency{Ref: from})
+	}
+	m.dependencies[i].DependsOn = appendUnique(m.dependencies[i].DependsOn, to)
+}
+
+// conflicts finds components merged in from several SBOMs in different
+// versions, matched as sbom diff matches them.
+func (m *merger) conflicts() []VersionConflict {
+	type group struct {
+		name     string
+		versions map[string][]string
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for i, component := range m.sbom.Components {
+		if component.Version == "" {
+			continue
+		}
+		key := componentIdentity(component)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{name: component.Name, versions: make(map[string][]string)}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		for _, source := range m.sources[i] {
+			g.versions[component.Version] = appendUnique(g.versions[component.Version], source)
+		}
+	}
+
+	var conflicts []VersionConflict
+	for _, key := range keys {
+		if g := groups[key]; len(g.versions) > 1 {
+			conflicts = append(conflicts, VersionConflict{Name: g.name, Versions: g.versions})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Name < conflicts[j].Name })
+	return conflicts
+}
+
+func appendUnique(list []string, value string) []string {
+	if containsString(list, value) {
+		return list
+	}
+	return append(list, value)
+}
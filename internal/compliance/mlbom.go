@@ -0,0 +1,180 @@
+package compliance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+)
+
+// Tags describing a model. Hyperparameters and metrics are tags with the
+// "hyperparameter." and "metric." prefixes, such as metric.accuracy=0.93.
+const (
+	TagTask               = "task"
+	TagApproach           = "approach"
+	TagArchitecture       = "architecture"
+	TagArchitectureFamily = "architecture_family"
+	// TagDatasets lists training datasets, separated by commas, as paths
+	// relative to the model or as names of external datasets.
+	TagDatasets = "datasets"
+
+	hyperparameterTagPrefix = "hyperparameter."
+	metricTagPrefix         = "metric."
+)
+
+// ModelCard describes a machine learning model after the CycloneDX model
+// card.
+type ModelCard struct {
+	Task               string
+	Approach           string
+	Architecture       string
+	ArchitectureFamily string
+	// Datasets are the bom-refs of the data components the model was
+	// trained on.
+	Datasets        []string
+	Hyperparameters map[string]string
+	Metrics         map[string]string
+}
+
+// Data describes the dataset of a data component.
+type Data struct {
+	// Type is the CycloneDX data type, such as dataset.
+	Type           string
+	Name           string
+	Classification string
+	Description    string
+}
+
+// isModel reports whether an artifact is a machine learning model: its type
+// is model or it has model tags.
+func isModel(metadata artifactmanager.ArtifactMetadata) bool {
+	return artifactmanager.ArtifactType(metadata) == "model" ||
+		metadata.Tags[TagTask] != "" || metadata.Tags[TagArchitecture] != ""
+}
+
+// isDataset reports whether an artifact is a dataset: its type is dataset or
+// it declares a dataset spec.
+func isDataset(metadata artifactmanager.ArtifactMetadata) bool {
+	return artifactmanager.ArtifactType(metadata) == "dataset" || metadata.Dataset != nil
+}
+
+// modelCard builds the model card of a model from its tags.
+func modelCard(metadata artifactmanager.ArtifactMetadata, datasets []Component) *ModelCard {
+	card := &ModelCard{
+		Task:               metadata.Tags[TagTask],
+		Approach:           metadata.Tags[TagApproach],
+		Architecture:       metadata.Tags[TagArchitecture],
+		ArchitectureFamily: metadata.Tags[TagArchitectureFamily],
+	}
+	for _, dataset := range datasets {
+		card.Datasets = append(card.Datasets, dataset.BOMRef)
+	}
+	for key, value := range metadata.Tags {
+		if name, ok := strings.CutPrefix(key, hyperparameterTagPrefix); ok {
+			if card.Hyperparameters == nil {
+				card.Hyperparameters = make(map[string]string)
+			}
+			card.Hyperparameters[name] = value
+		}
+		if name, ok := strings.CutPrefix(key, metricTagPrefix); ok {
+			if card.Metrics == nil {
+				card.Metrics = make(map[string]string)
+			}
+			card.Metrics[name] = value
+		}
+	}
+	return card
+}
+
+// datasetData describes a dataset artifact.
+func datasetData(metadata artifactmanager.ArtifactMetadata) *Data {
+	return &Data{
+		Type:           "dataset",
+		Name:           metadata.Name,
+		Classification: metadata.Tags["classification"],
+		Description:    metadata.Tags["description"],
+	}
+}
+
+// trainingDatasets returns the data components of the datasets a model
+// consumed, from its datasets tag and the "dataset" and "datasets" details
+// of its lineage. Tagged datasets are described by their metadata; other
+// files are digested; names that are not paths become components without
+// digests.
+func trainingDatasets(artifactPath string, metadata artifactmanager.ArtifactMetadata) ([]Component, error) {
+	var references []string
+	seen := make(map[string]bool)
+	collect := func(list string) {
+		for _, reference := range strings.Split(list, ",") {
+			reference = strings.TrimSpace(reference)
+			if reference != "" && !seen[reference] {
+				seen[reference] = true
+				references = append(references, reference)
+			}
+		}
+	}
+	collect(metadata.Tags[TagDatasets])
+	for _, entry := range metadata.Lineage {
+		collect(entry.Details["dataset"])
+		collect(entry.Details["datasets"])
+	}
+
+	var datasets []Component
+	for _, reference := range references {
+		dataset, err := datasetComponent(artifactPath, reference)
+		if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, dataset)
+	}
+	return datasets, nil
+}
+
+func datasetComponent(artifactPath, reference string) (Component, error) {
+	path := reference
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(artifactPath), filepath.FromSlash(reference))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		// An external dataset known by name only
+		return Component{
+			BOMRef: reference,
+			Type:   ComponentData,
+			Name:   reference,
+			Data:   &Data{Type: "dataset", Name: reference},
+		}, nil
+	}
+
+	if metadata, err := artifactmanager.GetArtifactMetadata(path); err == nil {
+		dataset := Component{
+			BOMRef:     fmt.Sprintf("%s@%s", metadata.Name, metadata.Version),
+			Type:       ComponentData,
+			Name:       metadata.Name,
+			Version:    metadata.Version,
+			License:    metadata.Tags["license"],
+			Hashes:     metadata.Digests,
+			Properties: map[string]string{propertyLocation: reference},
+			Data:       datasetData(metadata),
+		}
+		return dataset, nil
+	}
+
+	dataset := Component{
+		BOMRef:     reference,
+		Type:       ComponentData,
+		Name:       filepath.Base(path),
+		Properties: map[string]string{propertyLocation: reference},
+		Data:       &Data{Type: "dataset", Name: filepath.Base(path)},
+	}
+	if info.Mode().IsRegular() {
+		_, digests, err := artifactmanager.ComputeDigests(path, artifactmanager.DigestSHA256)
+		if err != nil {
+			return Component{}, err
+		}
+		dataset.Hashes = digests
+	}
+	return dataset, nil
+}
@@ -0,0 +1,255 @@
+package compliance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	_ "github.com/glebarez/go-sqlite" // registers the "sqlite" driver rpm databases are read with
+	rpmdb "github.com/knqyf263/go-rpmdb/pkg"
+)
+
+// osPackage is a package recorded in the package database of an operating
+// system.
+type osPackage struct {
+	// Type is the purl type of the package manager: deb, apk or rpm.
+	Type    string
+	Name    string
+	Version string
+	Arch    string
+	License string
+	// Source is the source package the package was built from.
+	Source string
+	// Epoch is the rpm epoch, when set.
+	Epoch string
+	// Layer is the index of the image layer the package was installed in.
+	Layer int
+}
+
+func (p osPackage) key() string {
+	return p.Type + "/" + p.Name + "@" + p.Version + "?" + p.Arch
+}
+
+// osRelease holds the fields of /etc/os-release that identify a distribution.
+type osRelease struct {
+	ID         string
+	VersionID  string
+	PrettyName string
+}
+
+// osPackageReaders maps the paths of package databases inside an image to
+// their parser.
+var osPackageReaders = map[string]func(io.Reader) ([]osPackage, error){
+	"var/lib/dpkg/status":               readDpkgStatus,
+	"lib/apk/db/installed":              readApkInstalled,
+	"var/lib/rpm/Packages":              readRpmDatabase,
+	"var/lib/rpm/Packages.db":           readRpmDatabase,
+	"var/lib/rpm/rpmdb.sqlite":          readRpmDatabase,
+	"usr/lib/sysimage/rpm/Packages":     readRpmDatabase,
+	"usr/lib/sysimage/rpm/Packages.db":  readRpmDatabase,
+	"usr/lib/sysimage/rpm/rpmdb.sqlite": readRpmDatabase,
+}
+
+// osPackageReader returns the parser of the package database at a path
+// inside an image. Distroless images keep one dpkg status file per package
+// in var/lib/dpkg/status.d.
+func osPackageReader(name string) (func(io.Reader) ([]osPackage, error), bool) {
+	if read, ok := osPackageReaders[name]; ok {
+		return read, true
+	}
+	if path.Dir(name) == "var/lib/dpkg/status.d" && !strings.HasSuffix(name, ".md5sums") {
+		return readDpkgStatus, true
+	}
+	return nil, false
+}
+
+// readControlStanzas splits a Debian control file, as used by the dpkg
+// status and the apk database, into stanzas of fields. Continuation lines are
+// joined to their field.
+func readControlStanzas(r io.Reader) ([]map[string]string, error) {
+	var stanzas []map[string]string
+	stanza := map[string]string{}
+	last := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			if len(stanza) > 0 {
+				stanzas = append(stanzas, stanza)
+				stanza = map[string]string{}
+			}
+			last = ""
+		case (line[0] == ' ' || line[0] == '\t') && last != "":
+			stanza[last] += "\n" + strings.TrimSpace(line)
+		default:
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			last = key
+			stanza[key] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stanza) > 0 {
+		stanzas = append(stanzas, stanza)
+	}
+	return stanzas, nil
+}
+
+// readDpkgStatus lists the installed packages of a dpkg status file.
+func readDpkgStatus(r io.Reader) ([]osPackage, error) {
+	stanzas, err := readControlStanzas(r)
+	if err != nil {
+		return nil, err
+	}
+	var packages []osPackage
+	for _, stanza := range stanzas {
+		// Removed packages stay in the status file until they are purged
+		if status, ok := stanza["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+		pkg := osPackage{
+			Type:    "deb",
+			Name:    stanza["Package"],
+			Version: stanza["Version"],
+			Arch:    stanza["Architecture"],
+		}
+		if source := stanza["Source"]; source != "" {
+			// The source may carry its own version: "name (version)"
+			pkg.Source = strings.Fields(source)[0]
+		}
+		if pkg.Name != "" {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// readApkInstalled lists the packages of an Alpine apk database.
+func readApkInstalled(r io.Reader) ([]osPackage, error) {
+	stanzas, err := readControlStanzas(r)
+	if err != nil {
+		return nil, err
+	}
+	var packages []osPackage
+	for _, stanza := range stanzas {
+		pkg := osPackage{
+			Type:    "apk",
+			Name:    stanza["P"],
+			Version: stanza["V"],
+			Arch:    stanza["A"],
+			License: stanza["L"],
+			Source:  stanza["o"],
+		}
+		if pkg.Name != "" {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// readRpmDatabase lists the packages of an rpm database in any of its
+// formats: Berkeley DB, NDB or SQLite. The database library reads from a
+// file, so the content is copied to a temporary one first.
+func readRpmDatabase(r io.Reader) ([]osPackage, error) {
+	tmp, err := os.CreateTemp("", "tracesync-rpmdb-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy rpm database: %w", err)
+	}
+
+	db, err := rpmdb.Open(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rpm database: %w", err)
+	}
+	defer db.Close()
+	infos, err := db.ListPackages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rpm database: %w", err)
+	}
+
+	var packages []osPackage
+	for _, info := range infos {
+		// The public key pseudo-packages are not software
+		if info.Name == "gpg-pubkey" {
+			continue
+		}
+		pkg := osPackage{
+			Type:    "rpm",
+			Name:    info.Name,
+			Version: info.Version + "-" + info.Release,
+			Arch:    info.Arch,
+			License: info.License,
+			Source:  info.SourceRpm,
+		}
+		if info.Epoch != nil && *info.Epoch != 0 {
+			pkg.Epoch = strconv.Itoa(*info.Epoch)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// readOSRelease parses an os-release file.
+func readOSRelease(r io.Reader) (*osRelease, error) {
+	release := &osRelease{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'`)
+		}
+		switch key {
+		case "ID":
+			release.ID = value
+		case "VERSION_ID":
+			release.VersionID = value
+		case "PRETTY_NAME":
+			release.PrettyName = value
+		}
+	}
+	return release, scanner.Err()
+}
+
+// osPackagePURL returns the purl of an OS package. The distribution from
+// os-release names the namespace and the distro qualifier.
+func osPackagePURL(pkg osPackage, release *osRelease) string {
+	namespace, distro := "", ""
+	if release != nil && release.ID != "" {
+		namespace = release.ID
+		distro = release.ID
+		if release.VersionID != "" {
+			distro += "-" + release.VersionID
+		}
+	} else if pkg.Type == "deb" {
+		namespace = "debian"
+	} else if pkg.Type == "apk" {
+		namespace = "alpine"
+	}
+	return packageURL(pkg.Type, namespace, pkg.Name, pkg.Version, map[string]string{
+		"arch":   pkg.Arch,
+		"epoch":  pkg.Epoch,
+		"distro": distro,
+	})
+}
@@ -0,0 +1,120 @@
+package compliance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/policy"
+)
+
+var (
+	policiesMu        sync.RWMutex
+	policies          *policy.Set
+	policyEnvironment string
+)
+
+// SetPolicies installs the rules PerformComplianceCheck evaluates, in the
+// given environment. A nil set restores the built-in rules, which are the
+// default.
+func SetPolicies(set *policy.Set, environment string) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies = set
+	policyEnvironment = environment
+}
+
+func currentPolicies() (*policy.Set, string) {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	if policies == nil {
+		return policy.Builtin(), policyEnvironment
+	}
+	return policies, policyEnvironment
+}
+
+// policyInput returns the variables rules are evaluated with: the artifact
+// descriptor, its SBOM, its provenance statement, empty if there is none,
+// and the environment name.
+func policyInput(artifactPath string, metadata artifactmanager.ArtifactMetadata, sbomPath string, sbom *SBOM, environment string) (map[string]any, error) {
+	descriptor, err := toPolicyValue(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]any, 0, len(sbom.Components))
+	for _, component := range sbom.Components {
+		components = append(components, componentValue(component))
+	}
+	dependencies := make(map[string]any, len(sbom.Dependencies))
+	for _, dependency := range sbom.Dependencies {
+		dependencies[dependency.Ref] = dependency.DependsOn
+	}
+	sbomValue := map[string]any{
+		"path":         sbomPath,
+		"name":         sbom.Name,
+		"version":      sbom.Version,
+		"subject":      componentValue(sbom.Subject),
+		"components":   components,
+		"dependencies": dependencies,
+	}
+
+	provenance := map[string]any{}
+	data, err := os.ReadFile(ProvenancePath(artifactPath, metadata.Name))
+	if err == nil {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&provenance); err != nil {
+			return nil, fmt.Errorf("failed to parse provenance: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read provenance: %w", err)
+	}
+
+	return map[string]any{
+		"descriptor":  descriptor,
+		"sbom":        sbomValue,
+		"provenance":  provenance,
+		"environment": environment,
+	}, nil
+}
+
+func componentValue(component Component) map[string]any {
+	hashes := make(map[string]any, len(component.Hashes))
+	for algorithm, value := range component.Hashes {
+		hashes[algorithm] = value
+	}
+	properties := make(map[string]any, len(component.Properties))
+	for name, value := range component.Properties {
+		properties[name] = value
+	}
+	return map[string]any{
+		"bomRef":     component.BOMRef,
+		"type":       component.Type,
+		"name":       component.Name,
+		"version":    component.Version,
+		"license":    component.License,
+		"purl":       component.PURL,
+		"hashes":     hashes,
+		"properties": properties,
+	}
+}
+
+// toPolicyValue converts a value to maps and lists through its JSON form,
+// so that rules see the field names of the descriptor file.
+func toPolicyValue(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var m map[string]any
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
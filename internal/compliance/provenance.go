@@ -0,0 +1,261 @@
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+)
+
+// Identifiers of the provenance TraceSync produces.
+const (
+	// InTotoStatementType is the type of in-toto v1 statements.
+	InTotoStatementType = "https://in-toto.io/Statement/v1"
+	// SLSAProvenancePredicateType is the predicate type of SLSA Provenance v1.
+	SLSAProvenancePredicateType = "https://slsa.dev/provenance/v1"
+	// DefaultBuilderID identifies TraceSync as the builder unless the
+	// provenance options name another one.
+	DefaultBuilderID = "https://github.com/MChorfa/TraceSync"
+	// UploadBuildType is the build type of artifacts published with
+	// tracesync upload.
+	UploadBuildType = "https://github.com/MChorfa/TraceSync/buildtypes/upload/v1"
+)
+
+// ProvenanceOptions describe the run that produced an artifact.
+type ProvenanceOptions struct {
+	// BuilderID is the URI of the trusted builder, DefaultBuilderID if empty.
+	BuilderID string
+	BuildType string
+	// ExternalParameters are the inputs the user controlled, such as the
+	// command and its flags.
+	ExternalParameters map[string]any
+	// InternalParameters are inputs set by the builder, such as the
+	// environment.
+	InternalParameters map[string]any
+	// InvocationID identifies the run, such as the URL of a CI job. It is
+	// detected from the CI environment if empty.
+	InvocationID string
+	StartedOn    time.Time
+	FinishedOn   time.Time
+	// SBOMPath is the SBOM recorded as a byproduct.
+	SBOMPath string
+	// SBOM is the SBOM written to SBOMPath. It is loaded from SBOMPath if
+	// nil; with neither, the artifact's SBOM is looked up as by
+	// CheckCompliance.
+	SBOM *SBOM
+}
+
+// inTotoStatement is an in-toto v1 statement carrying a SLSA Provenance v1
+// predicate; see https://slsa.dev/spec/v1.0/provenance.
+type inTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []resourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     slsaProvenance       `json:"predicate"`
+}
+
+type resourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition slsaBuildDefinition `json:"buildDefinition"`
+	RunDetails      slsaRunDetails      `json:"runDetails"`
+}
+
+type slsaBuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	InternalParameters   map[string]any       `json:"internalParameters,omitempty"`
+	ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type slsaRunDetails struct {
+	Builder    slsaBuilder          `json:"builder"`
+	Metadata   *slsaBuildMetadata   `json:"metadata,omitempty"`
+	Byproducts []resourceDescriptor `json:"byproducts,omitempty"`
+}
+
+type slsaBuilder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type slsaBuildMetadata struct {
+	InvocationID string `json:"invocationId,omitempty"`
+	StartedOn    string `json:"startedOn,omitempty"`
+	FinishedOn   string `json:"finishedOn,omitempty"`
+}
+
+// ProvenancePath returns where the provenance of an artifact is written:
+// <name>-provenance.intoto.json next to the artifact and its SBOM.
+func ProvenancePath(artifactPath, name string) string {
+	return filepath.Join(filepath.Dir(artifactPath), name+artifactmanager.ProvenanceSuffix)
+}
+
+// GenerateProvenance writes the SLSA provenance of an artifact and returns
+// its path. The subject is the artifact digest, plus the file digests of a
+// bundle; the resolved dependencies are the components of its SBOM, and the
+// SBOM file is recorded as a byproduct when there is one.
+func GenerateProvenance(artifactPath string, options ProvenanceOptions) (string, error) {
+	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+	sbom := options.SBOM
+	if sbom == nil && options.SBOMPath != "" {
+		if sbom, err = LoadSBOM(options.SBOMPath); err != nil {
+			return "", err
+		}
+	} else if sbom == nil {
+		if sbom, options.SBOMPath, err = artifactSBOMFile(artifactPath, metadata); err != nil {
+			return "", err
+		}
+	}
+
+	statement, err := buildProvenance(artifactPath, metadata, sbom, options)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(statement, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal provenance: %w", err)
+	}
+
+	provenancePath := ProvenancePath(artifactPath, metadata.Name)
+	if err := os.WriteFile(provenancePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write provenance file: %w", err)
+	}
+	return provenancePath, nil
+}
+
+func buildProvenance(artifactPath string, metadata artifactmanager.ArtifactMetadata, sbom *SBOM, options ProvenanceOptions) (*inTotoStatement, error) {
+	if len(metadata.Digests) == 0 {
+		return nil, fmt.Errorf("artifact %s has no recorded digest", metadata.Name)
+	}
+
+	subjects := []resourceDescriptor{{Name: metadata.Name, Digest: digestSet(metadata.Digests)}}
+	for _, entry := range metadata.Manifest {
+		subjects = append(subjects, resourceDescriptor{
+			Name:   metadata.Name + "/" + entry.Path,
+			Digest: digestSet(entry.Digests),
+		})
+	}
+
+	// Applications, such as the main module of a go.mod, are the artifact's
+	// own code rather than dependencies
+	var dependencies []resourceDescriptor
+	for _, component := range sbom.Components {
+		if component.Type == ComponentApplication {
+			continue
+		}
+		dependency := resourceDescriptor{URI: component.PURL, Digest: digestSet(component.Hashes)}
+		if component.PURL == "" {
+			dependency.Name = component.Name
+			if component.Version != "" {
+				dependency.Name += "@" + component.Version
+			}
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	var byproducts []resourceDescriptor
+	if options.SBOMPath != "" {
+		_, digests, err := artifactmanager.ComputeDigests(options.SBOMPath, artifactmanager.DigestSHA256)
+		if err != nil {
+			return nil, err
+		}
+		byproducts = append(byproducts, resourceDescriptor{Name: filepath.Base(options.SBOMPath), Digest: digests})
+	}
+
+	builderID := options.BuilderID
+	if builderID == "" {
+		builderID = DefaultBuilderID
+	}
+	buildType := options.BuildType
+	if buildType == "" {
+		buildType = UploadBuildType
+	}
+	externalParameters := options.ExternalParameters
+	if externalParameters == nil {
+		externalParameters = map[string]any{}
+	}
+	invocationID := options.InvocationID
+	if invocationID == "" {
+		invocationID = ciInvocationID()
+	}
+	var runMetadata *slsaBuildMetadata
+	if invocationID != "" || !options.StartedOn.IsZero() || !options.FinishedOn.IsZero() {
+		runMetadata = &slsaBuildMetadata{
+			InvocationID: invocationID,
+			StartedOn:    formatTimestamp(options.StartedOn),
+			FinishedOn:   formatTimestamp(options.FinishedOn),
+		}
+	}
+
+	return &inTotoStatement{
+		Type:          InTotoStatementType,
+		Subject:       subjects,
+		PredicateType: SLSAProvenancePredicateType,
+		Predicate: slsaProvenance{
+			BuildDefinition: slsaBuildDefinition{
+				BuildType:            buildType,
+				ExternalParameters:   externalParameters,
+				InternalParameters:   options.InternalParameters,
+				ResolvedDependencies: dependencies,
+			},
+			RunDetails: slsaRunDetails{
+				Builder:    slsaBuilder{ID: builderID, Version: map[string]string{ToolName: toolVersion()}},
+				Metadata:   runMetadata,
+				Byproducts: byproducts,
+			},
+		},
+	}, nil
+}
+
+// digestSet keeps the hashes in-toto digest sets can carry, under their
+// lowercase algorithm names; others, such as Go's h1, are dropped.
+func digestSet(hashes map[string]string) map[string]string {
+	var set map[string]string
+	for algorithm, value := range hashes {
+		algorithm = strings.ToLower(algorithm)
+		if _, ok := hashAlgorithms[algorithm]; !ok || value == "" {
+			continue
+		}
+		if set == nil {
+			set = make(map[string]string)
+		}
+		set[algorithm] = value
+	}
+	return set
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ciInvocationID identifies the CI job running TraceSync, for GitHub
+// Actions and GitLab CI, or returns "" elsewhere.
+func ciInvocationID() string {
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" && os.Getenv("GITHUB_REPOSITORY") != "" {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		id := fmt.Sprintf("%s/%s/actions/runs/%s", server, os.Getenv("GITHUB_REPOSITORY"), runID)
+		if attempt := os.Getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+			id += "/attempts/" + attempt
+		}
+		return id
+	}
+	return os.Getenv("CI_JOB_URL")
+}
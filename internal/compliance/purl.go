@@ -0,0 +1,117 @@
+package compliance
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// packageURL builds a package URL (https://github.com/package-url/purl-spec)
+// from its parts. The namespace may contain several slash-separated segments.
+func packageURL(purlType, namespace, name, version string, qualifiers map[string]string) string {
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(purlType)
+	b.WriteString("/")
+	if namespace != "" {
+		for _, segment := range strings.Split(namespace, "/") {
+			b.WriteString(escapePURL(segment))
+			b.WriteString("/")
+		}
+	}
+	b.WriteString(escapePURL(name))
+	if version != "" {
+		b.WriteString("@")
+		b.WriteString(escapePURL(version))
+	}
+
+	keys := make([]string, 0, len(qualifiers))
+	for key, value := range qualifiers {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if i == 0 {
+			b.WriteString("?")
+		} else {
+			b.WriteString("&")
+		}
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(escapePURL(qualifiers[key]))
+	}
+	return b.String()
+}
+
+// escapePURL percent-encodes everything except unreserved characters.
+func escapePURL(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%")
+		b.WriteString(strings.ToUpper(string("0123456789abcdef"[c>>4]) + string("0123456789abcdef"[c&15])))
+	}
+	return b.String()
+}
+
+// purlParts are the decoded parts of a package URL.
+type purlParts struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+}
+
+// parsePackageURL splits a package URL into its decoded parts. Subpaths are
+// dropped.
+func parsePackageURL(purl string) (purlParts, error) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return purlParts{}, fmt.Errorf("invalid package URL %q: missing pkg: scheme", purl)
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+
+	parts := purlParts{Qualifiers: make(map[string]string)}
+	rest, qualifiers, _ := strings.Cut(rest, "?")
+	for _, qualifier := range strings.Split(qualifiers, "&") {
+		if key, value, ok := strings.Cut(qualifier, "="); ok {
+			decoded, err := url.PathUnescape(value)
+			if err != nil {
+				return purlParts{}, fmt.Errorf("invalid package URL %q: %w", purl, err)
+			}
+			parts.Qualifiers[strings.ToLower(key)] = decoded
+		}
+	}
+	if i := strings.LastIndexByte(rest, '@'); i >= 0 && strings.IndexByte(rest[i:], '/') < 0 {
+		rest, parts.Version = rest[:i], rest[i+1:]
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 {
+		return purlParts{}, fmt.Errorf("invalid package URL %q: missing name", purl)
+	}
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return purlParts{}, fmt.Errorf("invalid package URL %q: %w", purl, err)
+		}
+		segments[i] = decoded
+	}
+	version, err := url.PathUnescape(parts.Version)
+	if err != nil {
+		return purlParts{}, fmt.Errorf("invalid package URL %q: %w", purl, err)
+	}
+	parts.Type = strings.ToLower(segments[0])
+	parts.Namespace = strings.Join(segments[1:len(segments)-1], "/")
+	parts.Name = segments[len(segments)-1]
+	parts.Version = version
+	return parts, nil
+}
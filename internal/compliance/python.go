@@ -0,0 +1,463 @@
+package compliance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// propertyUnpinned marks components whose version is not fixed. Its value is
+// the version specifier that was found, or "*" when there was none.
+const propertyUnpinned = "tracesync:unpinned"
+
+// pythonNameSeparators matches the runs PEP 503 folds into a single dash.
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName returns the PEP 503 normalized form of a project name.
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// pythonComponent returns the component of a pinned Python package.
+func pythonComponent(name, version, file string) Component {
+	component := Component{
+		Type:       ComponentLibrary,
+		Name:       normalizePythonName(name),
+		Version:    version,
+		Properties: map[string]string{propertyLocation: file},
+	}
+	component.PURL = packageURL("pypi", "", component.Name, version, nil)
+	component.BOMRef = component.PURL
+	return component
+}
+
+// parsePythonHash splits an "algorithm:hex" hash as used by pip and lock
+// files.
+func parsePythonHash(hash string) (string, string, bool) {
+	algorithm, value, ok := strings.Cut(hash, ":")
+	if !ok || value == "" {
+		return "", "", false
+	}
+	return strings.ToLower(algorithm), value, true
+}
+
+// requirementPattern matches a PEP 508 requirement: name, extras, then a
+// version specifier or a direct URL reference.
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*((?:[<>=!~(@]).*)?$`)
+
+// discoverRequirements lists the packages of a pip requirements file,
+// following -r includes. Includes that are missing or that point outside the
+// artifact, through an absolute path or "..", are skipped with a warning.
+// Only "==" and "===" pins are treated as pinned versions; URL and path
+// requirements are unpinned, and lines that cannot be parsed are skipped with
+// a warning.
+func discoverRequirements(root, file string) (*discovery, error) {
+	found := &discovery{}
+	return found, readRequirements(root, file, found, map[string]bool{})
+}
+
+func readRequirements(root, file string, found *discovery, visited map[string]bool) error {
+	if visited[file] {
+		return nil
+	}
+	visited[file] = true
+
+	data, err := readDiscovered(root, file)
+	if err != nil {
+		return err
+	}
+
+	// Join continuation lines before parsing
+	content := strings.ReplaceAll(string(data), "\\\r\n", " ")
+	content = strings.ReplaceAll(content, "\\\n", " ")
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Options: includes are followed, everything else is skipped
+		if strings.HasPrefix(line, "-") {
+			fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+			if len(fields) == 2 && (fields[0] == "-r" || fields[0] == "--requirement") {
+				target := filepath.ToSlash(fields[1])
+				include := path.Join(path.Dir(file), target)
+				if path.IsAbs(target) || filepath.IsAbs(fields[1]) || !filepath.IsLocal(filepath.FromSlash(include)) {
+					found.warnings = append(found.warnings, fmt.Sprintf("%s: skipped include %s outside the artifact", file, fields[1]))
+					continue
+				}
+				err := readRequirements(root, include, found, visited)
+				if errors.Is(err, fs.ErrNotExist) {
+					found.warnings = append(found.warnings, fmt.Sprintf("%s: skipped missing include %s", file, fields[1]))
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// Per-requirement hash options follow the requirement
+		requirement := line
+		hashes := make(map[string]string)
+		if i := strings.Index(line, " --"); i >= 0 {
+			requirement = strings.TrimSpace(line[:i])
+			for _, option := range strings.Fields(line[i:]) {
+				if hash, ok := strings.CutPrefix(option, "--hash="); ok {
+					if algorithm, value, ok := parsePythonHash(hash); ok {
+						if _, exists := hashes[algorithm]; !exists {
+							hashes[algorithm] = value
+						}
+					}
+				}
+			}
+		}
+		if i := strings.Index(requirement, ";"); i >= 0 {
+			requirement = strings.TrimSpace(requirement[:i])
+		}
+
+		// URL and path requirements name an archive, directory or repository
+		if isPythonLocation(requirement) {
+			name := pythonLocationName(requirement)
+			if name == "" {
+				found.warnings = append(found.warnings, fmt.Sprintf("%s: skipped requirement %q without a project name", file, line))
+				continue
+			}
+			component := pythonComponent(name, "", file)
+			component.Properties["tracesync:python:url"] = requirement
+			component.Properties[propertyUnpinned] = requirement
+			if len(hashes) > 0 {
+				component.Hashes = hashes
+			}
+			found.components = append(found.components, component)
+			continue
+		}
+
+		match := requirementPattern.FindStringSubmatch(requirement)
+		if match == nil {
+			found.warnings = append(found.warnings, fmt.Sprintf("%s: skipped invalid requirement %q", file, line))
+			continue
+		}
+		name, specifier := match[1], strings.TrimSpace(match[3])
+
+		component := pythonComponent(name, pinnedPythonVersion(specifier), file)
+		switch {
+		case strings.HasPrefix(specifier, "@"):
+			// Direct references name a file or repository instead of a version
+			component.Properties["tracesync:python:url"] = strings.TrimSpace(specifier[1:])
+			component.Properties[propertyUnpinned] = specifier
+		case component.Version == "":
+			component.Properties[propertyUnpinned] = specifier
+			if specifier == "" {
+				component.Properties[propertyUnpinned] = "*"
+			}
+		}
+		if len(hashes) > 0 {
+			component.Hashes = hashes
+		}
+		found.components = append(found.components, component)
+	}
+	return nil
+}
+
+// pythonURLPattern matches the scheme of a URL requirement, such as https://
+// or git+https://.
+var pythonURLPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+
+// isPythonLocation reports whether a requirement is a URL or a local path
+// rather than a project name.
+func isPythonLocation(requirement string) bool {
+	return pythonURLPattern.MatchString(requirement) ||
+		strings.HasPrefix(requirement, "file:") ||
+		strings.HasPrefix(requirement, ".") ||
+		strings.HasPrefix(requirement, "/") ||
+		strings.HasPrefix(requirement, "~")
+}
+
+// pythonLocationName returns the project name of a URL or path requirement:
+// the #egg= fragment, the name in a wheel or source archive file name, or
+// else the last path segment, without a VCS revision or .git suffix.
+func pythonLocationName(location string) string {
+	location, fragment, _ := strings.Cut(location, "#")
+	for _, parameter := range strings.Split(fragment, "&") {
+		if egg, ok := strings.CutPrefix(parameter, "egg="); ok && egg != "" {
+			return egg
+		}
+	}
+	location, _, _ = strings.Cut(location, "?")
+
+	segment := path.Base(strings.TrimRight(filepath.ToSlash(location), "/"))
+	if i := strings.Index(segment, "@"); i >= 0 {
+		segment = segment[:i]
+	}
+	if wheel, ok := strings.CutSuffix(segment, ".whl"); ok {
+		name, _, _ := strings.Cut(wheel, "-")
+		return name
+	}
+	for _, extension := range []string{".tar.gz", ".tar.bz2", ".tgz", ".zip"} {
+		if archive, ok := strings.CutSuffix(segment, extension); ok {
+			if i := strings.LastIndex(archive, "-"); i > 0 {
+				archive = archive[:i]
+			}
+			return archive
+		}
+	}
+	segment = strings.TrimSuffix(segment, ".git")
+	if segment == "." || segment == ".." || segment == "/" || strings.HasSuffix(segment, ":") {
+		return ""
+	}
+	return segment
+}
+
+// pinnedPythonVersion returns the version of an exact "==" or "===" pin, or
+// "" when the specifier allows several versions.
+func pinnedPythonVersion(specifier string) string {
+	if strings.Contains(specifier, ",") || strings.Contains(specifier, "*") {
+		return ""
+	}
+	for _, operator := range []string{"===", "=="} {
+		if version, ok := strings.CutPrefix(specifier, operator); ok {
+			return strings.TrimSpace(version)
+		}
+	}
+	return ""
+}
+
+// poetryLock is the part of poetry.lock that describes packages.
+type poetryLock struct {
+	Packages []struct {
+		Name         string                 `toml:"name"`
+		Version      string                 `toml:"version"`
+		Description  string                 `toml:"description"`
+		Category     string                 `toml:"category"`
+		Dependencies map[string]interface{} `toml:"dependencies"`
+		Files        []pythonFile           `toml:"files"`
+		Source       struct {
+			Type string `toml:"type"`
+			URL  string `toml:"url"`
+		} `toml:"source"`
+	} `toml:"package"`
+	Metadata struct {
+		// Files are listed here by lock files older than Poetry 1.5
+		Files map[string][]pythonFile `toml:"files"`
+	} `toml:"metadata"`
+}
+
+type pythonFile struct {
+	File string `toml:"file"`
+	Hash string `toml:"hash"`
+}
+
+// discoverPoetryLock lists the locked packages of a Poetry project and the
+// dependencies between them.
+func discoverPoetryLock(root, file string) (*discovery, error) {
+	data, err := readDiscovered(root, file)
+	if err != nil {
+		return nil, err
+	}
+	var lock poetryLock
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	found := &discovery{}
+	refs := make(map[string]string)
+	for _, pkg := range lock.Packages {
+		component := pythonComponent(pkg.Name, pkg.Version, file)
+		component.Description = pkg.Description
+		if pkg.Category != "" {
+			component.Properties["tracesync:python:group"] = pkg.Category
+		}
+		if pkg.Source.Type != "" {
+			component.Properties["tracesync:python:source"] = pkg.Source.Type + "+" + pkg.Source.URL
+		}
+		files := pkg.Files
+		if len(files) == 0 {
+			files = lock.Metadata.Files[pkg.Name]
+		}
+		component.Hashes = distributionHash(files)
+		refs[component.Name] = component.BOMRef
+		found.components = append(found.components, component)
+	}
+
+	for _, pkg := range lock.Packages {
+		dependency := Dependency{Ref: refs[normalizePythonName(pkg.Name)]}
+		for name := range pkg.Dependencies {
+			if ref, ok := refs[normalizePythonName(name)]; ok {
+				dependency.DependsOn = append(dependency.DependsOn, ref)
+			}
+		}
+		if len(dependency.DependsOn) > 0 {
+			sort.Strings(dependency.DependsOn)
+			found.dependencies = append(found.dependencies, dependency)
+		}
+	}
+	return found, nil
+}
+
+// distributionHash picks the hash of the source distribution, or of the
+// first file when there is none.
+func distributionHash(files []pythonFile) map[string]string {
+	if len(files) == 0 {
+		return nil
+	}
+	chosen := files[0]
+	for _, file := range files {
+		if strings.HasSuffix(file.File, ".tar.gz") || strings.HasSuffix(file.File, ".zip") {
+			chosen = file
+			break
+		}
+	}
+	algorithm, value, ok := parsePythonHash(chosen.Hash)
+	if !ok {
+		return nil
+	}
+	return map[string]string{algorithm: value}
+}
+
+// pipfileLock is the structure of Pipfile.lock.
+type pipfileLock struct {
+	Default map[string]pipfilePackage `json:"default"`
+	Develop map[string]pipfilePackage `json:"develop"`
+}
+
+type pipfilePackage struct {
+	Version string   `json:"version"`
+	Hashes  []string `json:"hashes"`
+	Git     string   `json:"git"`
+	Ref     string   `json:"ref"`
+	Path    string   `json:"path"`
+	File    string   `json:"file"`
+}
+
+// discoverPipfileLock lists the default and development packages of a
+// Pipenv lock file.
+func discoverPipfileLock(root, file string) (*discovery, error) {
+	data, err := readDiscovered(root, file)
+	if err != nil {
+		return nil, err
+	}
+	var lock pipfileLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	found := &discovery{}
+	for _, group := range []struct {
+		name     string
+		packages map[string]pipfilePackage
+	}{{"default", lock.Default}, {"develop", lock.Develop}} {
+		names := make([]string, 0, len(group.packages))
+		for name := range group.packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			pkg := group.packages[name]
+			component := pythonComponent(name, pinnedPythonVersion(pkg.Version), file)
+			component.Properties["tracesync:python:group"] = group.name
+			switch {
+			case pkg.Git != "":
+				component.Properties["tracesync:python:source"] = "git+" + pkg.Git + "@" + pkg.Ref
+			case pkg.Path != "" || pkg.File != "":
+				component.Properties["tracesync:python:source"] = pkg.Path + pkg.File
+			case component.Version == "":
+				component.Properties[propertyUnpinned] = pkg.Version
+			}
+			for _, hash := range pkg.Hashes {
+				if algorithm, value, ok := parsePythonHash(hash); ok {
+					component.Hashes = map[string]string{algorithm: value}
+					break
+				}
+			}
+			found.components = append(found.components, component)
+		}
+	}
+	return found, nil
+}
+
+// uvLock is the part of uv.lock that describes packages.
+type uvLock struct {
+	Packages []struct {
+		Name         string                 `toml:"name"`
+		Version      string                 `toml:"version"`
+		Source       map[string]interface{} `toml:"source"`
+		Dependencies []struct {
+			Name string `toml:"name"`
+		} `toml:"dependencies"`
+		Sdist *struct {
+			Hash string `toml:"hash"`
+		} `toml:"sdist"`
+		Wheels []struct {
+			Hash string `toml:"hash"`
+		} `toml:"wheels"`
+	} `toml:"package"`
+}
+
+// discoverUVLock lists the packages of a uv lock file. The project itself
+// (an editable or virtual source) becomes an application component.
+func discoverUVLock(root, file string) (*discovery, error) {
+	data, err := readDiscovered(root, file)
+	if err != nil {
+		return nil, err
+	}
+	var lock uvLock
+	if err := toml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	found := &discovery{}
+	refs := make(map[string]string)
+	for _, pkg := range lock.Packages {
+		component := pythonComponent(pkg.Name, pkg.Version, file)
+		if _, ok := pkg.Source["editable"]; ok {
+			component.Type = ComponentApplication
+		}
+		if _, ok := pkg.Source["virtual"]; ok {
+			component.Type = ComponentApplication
+		}
+		for _, kind := range []string{"git", "url", "path", "directory"} {
+			if source, ok := pkg.Source[kind].(string); ok {
+				component.Properties["tracesync:python:source"] = kind + "+" + source
+			}
+		}
+
+		hash := ""
+		if pkg.Sdist != nil {
+			hash = pkg.Sdist.Hash
+		} else if len(pkg.Wheels) > 0 {
+			hash = pkg.Wheels[0].Hash
+		}
+		if algorithm, value, ok := parsePythonHash(hash); ok {
+			component.Hashes = map[string]string{algorithm: value}
+		}
+		refs[component.Name] = component.BOMRef
+		found.components = append(found.components, component)
+	}
+
+	for _, pkg := range lock.Packages {
+		dependency := Dependency{Ref: refs[normalizePythonName(pkg.Name)]}
+		for _, dep := range pkg.Dependencies {
+			if ref, ok := refs[normalizePythonName(dep.Name)]; ok {
+				dependency.DependsOn = append(dependency.DependsOn, ref)
+			}
+		}
+		if len(dependency.DependsOn) > 0 {
+			found.dependencies = append(found.dependencies, dependency)
+		}
+	}
+	return found, nil
+}
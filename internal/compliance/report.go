@@ -0,0 +1,329 @@
+package compliance
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IDs of the checks PerformComplianceCheck runs besides the policy rules.
+const (
+	RulePinnedDependencies = "pinned-dependencies"
+	RuleLicensePolicy      = "license-policy"
+	RuleVulnerabilities    = "vulnerabilities"
+)
+
+// ReportFormat is the rendering of a compliance report.
+type ReportFormat string
+
+const (
+	// ReportText lists warnings and issues for people, the default.
+	ReportText ReportFormat = "text"
+	// ReportJSON is the Report as JSON.
+	ReportJSON ReportFormat = "json"
+	// ReportJUnit is JUnit XML with a test case per rule, so CI systems
+	// show failed rules as failed tests.
+	ReportJUnit ReportFormat = "junit"
+	// ReportSARIF is SARIF 2.1.0, for code scanning dashboards.
+	ReportSARIF ReportFormat = "sarif"
+)
+
+// ReportFormats lists the supported report formats.
+var ReportFormats = []ReportFormat{ReportText, ReportJSON, ReportJUnit, ReportSARIF}
+
+// ParseReportFormat validates a report format name. An empty name selects
+// the text format.
+func ParseReportFormat(name string) (ReportFormat, error) {
+	if name == "" {
+		return ReportText, nil
+	}
+	for _, format := range ReportFormats {
+		if ReportFormat(name) == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported report format %q (supported: %v)", name, ReportFormats)
+}
+
+// Report is the result of a compliance check.
+type Report struct {
+	// Artifact is the path of the checked artifact.
+	Artifact    string    `json:"artifact"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Environment string    `json:"environment,omitempty"`
+	CheckedAt   time.Time `json:"checkedAt"`
+	// Rules are the rules that were checked, whether or not they passed.
+	Rules  []ReportRule `json:"rules"`
+	Issues []Issue      `json:"issues"`
+}
+
+// ReportRule describes a checked rule.
+type ReportRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+}
+
+// Issue is a finding of a compliance check. Issues of severity error fail
+// the check; warnings are reported only.
+type Issue struct {
+	RuleID   string `json:"ruleId"`
+	Severity string `json:"severity"`
+	// Component is the name and version of the SBOM component the issue
+	// is about, if any.
+	Component string `json:"component,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s [%s]", i.Message, i.RuleID)
+}
+
+// ComplianceError is returned when a compliance check finds issues.
+type ComplianceError struct {
+	Report *Report
+}
+
+func (e *ComplianceError) Error() string {
+	return fmt.Sprintf("compliance check failed: %d issue(s) found", len(e.Report.Errors()))
+}
+
+// Passed reports whether no issue of severity error was found.
+func (r *Report) Passed() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors returns the issues that fail the check.
+func (r *Report) Errors() []Issue {
+	return r.withSeverity(SeverityError)
+}
+
+// Warnings returns the issues that are reported only.
+func (r *Report) Warnings() []Issue {
+	return r.withSeverity(SeverityWarning)
+}
+
+func (r *Report) withSeverity(severity string) []Issue {
+	var issues []Issue
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func (r *Report) addRule(id, description string) {
+	r.Rules = append(r.Rules, ReportRule{ID: id, Description: description})
+}
+
+func (r *Report) add(ruleID, severity, component, message string) {
+	r.Issues = append(r.Issues, Issue{RuleID: ruleID, Severity: severity, Component: component, Message: message})
+}
+
+// Write renders the report in a format.
+func (r *Report) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportText, "":
+		return r.writeText(w)
+	case ReportJSON:
+		report := *r
+		if report.Issues == nil {
+			report.Issues = []Issue{}
+		}
+		return writeJSON(w, report)
+	case ReportJUnit:
+		return r.writeJUnit(w)
+	case ReportSARIF:
+		return writeJSON(w, r.sarif())
+	}
+	return fmt.Errorf("unsupported report format %q (supported: %v)", format, ReportFormats)
+}
+
+func writeJSON(w io.Writer, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	if warnings := r.Warnings(); len(warnings) > 0 {
+		fmt.Fprintln(w, "Compliance warnings:")
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "- %s\n", warning)
+		}
+	}
+	if errors := r.Errors(); len(errors) > 0 {
+		fmt.Fprintln(w, "Compliance check failed. Issues found:")
+		for _, issue := range errors {
+			fmt.Fprintf(w, "- %s\n", issue)
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(w, "Compliance check passed successfully.")
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Name    string           `xml:"name,attr"`
+	Tests   int              `xml:"tests,attr"`
+	Fails   int              `xml:"failures,attr"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Fails     int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit renders a test case per rule. Rules with errors fail, with
+// one line per issue; warnings are attached as output of the test case.
+func (r *Report) writeJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      fmt.Sprintf("compliance: %s", r.Name),
+		Timestamp: r.CheckedAt.Format(time.RFC3339),
+	}
+	for _, rule := range r.Rules {
+		testCase := junitTestCase{Name: rule.ID, Classname: "tracesync.compliance." + r.Name}
+		var errors, warnings []string
+		for _, issue := range r.Issues {
+			if issue.RuleID != rule.ID {
+				continue
+			}
+			if issue.Severity == SeverityError {
+				errors = append(errors, issue.Message)
+			} else {
+				warnings = append(warnings, "warning: "+issue.Message)
+			}
+		}
+		if len(errors) > 0 {
+			testCase.Failure = &junitFailure{Message: errors[0], Type: SeverityError, Text: strings.Join(errors, "\n")}
+			suite.Fails++
+		}
+		testCase.SystemOut = strings.Join(warnings, "\n")
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+	}
+
+	suites := junitTestSuites{Name: ToolName, Tests: suite.Tests, Fails: suite.Fails, Suites: []junitTestSuite{suite}}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+// sarifLog is a SARIF 2.1.0 log; see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func (r *Report) sarif() sarifLog {
+	driver := sarifDriver{Name: ToolName, Version: toolVersion(), InformationURI: DefaultBuilderID, Rules: []sarifRule{}}
+	ruleIndex := make(map[string]int)
+	for _, rule := range r.Rules {
+		ruleIndex[rule.ID] = len(driver.Rules)
+		sarifRule := sarifRule{ID: rule.ID}
+		if rule.Description != "" {
+			sarifRule.ShortDescription = &sarifMessage{Text: rule.Description}
+		}
+		driver.Rules = append(driver.Rules, sarifRule)
+	}
+
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(r.Artifact)},
+	}}
+	results := []sarifResult{}
+	for _, issue := range r.Issues {
+		level := "warning"
+		if issue.Severity == SeverityError {
+			level = "error"
+		}
+		results = append(results, sarifResult{
+			RuleID:    issue.RuleID,
+			RuleIndex: ruleIndex[issue.RuleID],
+			Level:     level,
+			Message:   sarifMessage{Text: issue.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
@@ -1,105 +1,476 @@
 package compliance
 
 import (
-	"encoding/json"
+	"crypto/rand"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/vulndb"
 )
 
+// Format is an SBOM file format.
+type Format string
+
+const (
+	// FormatCycloneDX is CycloneDX 1.5 JSON, the default format.
+	FormatCycloneDX Format = "cyclonedx"
+	// FormatSPDXJSON is SPDX 2.3 JSON.
+	FormatSPDXJSON Format = "spdx-json"
+	// FormatSPDXTagValue is SPDX 2.3 tag-value.
+	FormatSPDXTagValue Format = "spdx-tag-value"
+	// FormatLegacy is the original TraceSync JSON format.
+	FormatLegacy Format = "legacy"
+)
+
+// Formats lists the supported SBOM formats.
+var Formats = []Format{FormatCycloneDX, FormatSPDXJSON, FormatSPDXTagValue, FormatLegacy}
+
+// ParseFormat validates an SBOM format name. An empty name selects the
+// default format.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatCycloneDX, nil
+	}
+	for _, format := range Formats {
+		if Format(name) == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported SBOM format %q (supported: %v)", name, Formats)
+}
+
+// CycloneDX component types used by TraceSync.
+const (
+	ComponentApplication     = "application"
+	ComponentLibrary         = "library"
+	ComponentFile            = "file"
+	ComponentContainer       = "container"
+	ComponentOperatingSystem = "operating-system"
+	// ML-BOM types for models and datasets
+	ComponentMachineLearningModel = "machine-learning-model"
+	ComponentData                 = "data"
+)
+
+// ToolName identifies TraceSync as the SBOM author.
+const ToolName = "tracesync"
+
+// SBOM is TraceSync's format-neutral software bill of materials. It is
+// written out in one of the supported formats.
 type SBOM struct {
-	Name        string      `json:"name"`
-	Version     string      `json:"version"`
-	Description string      `json:"description"`
-	Components  []Component `json:"components"`
-	CreatedAt   time.Time   `json:"created_at"`
+	SerialNumber string
+	Name         string
+	Version      string
+	Description  string
+	CreatedAt    time.Time
+	// Subject is the artifact the SBOM describes.
+	Subject      Component
+	Components   []Component
+	Dependencies []Dependency
+	// Warnings describe what was left out while building the SBOM, such as
+	// a requirements include outside the artifact. They are not encoded.
+	Warnings []string
 }
 
+// Component is a piece of software or data that an artifact contains or
+// depends on.
 type Component struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	Type    string `json:"type"`
-	License string `json:"license"`
+	BOMRef  string
+	Type    string
+	Name    string
+	Version string
+	// License is an SPDX license expression or, failing that, a license name.
+	License     string
+	PURL        string
+	Description string
+	// Hashes are keyed by digest algorithm, such as sha256.
+	Hashes     map[string]string
+	Properties map[string]string
+	// ModelCard describes a machine-learning-model component.
+	ModelCard *ModelCard
+	// Data describes a data component.
+	Data *Data
+	// Components are nested parts, such as the files of a bundle.
+	Components []Component
+}
+
+// Dependency records that the component Ref depends on the components
+// listed in DependsOn, all identified by bom-ref.
+type Dependency struct {
+	Ref       string
+	DependsOn []string
 }
 
+// GenerateSBOM writes an SBOM for the artifact in the default format.
 func GenerateSBOM(artifactPath string) error {
+	_, err := GenerateSBOMAs(artifactPath, FormatCycloneDX)
+	return err
+}
+
+// GenerateSBOMAs writes an SBOM for the artifact in the given format next to
+// the artifact and returns its path. An imported SBOM is written in place of
+// one built from the artifact's content.
+func GenerateSBOMAs(artifactPath string, format Format) (string, error) {
 	// Read artifact metadata
 	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
 	if err != nil {
-		return fmt.Errorf("failed to read artifact metadata: %w", err)
+		return "", fmt.Errorf("failed to read artifact metadata: %w", err)
 	}
 
-	// Create SBOM structure
-	sbom := SBOM{
-		Name:        metadata.Name,
-		Version:     metadata.Version,
-		Description: fmt.Sprintf("SBOM for %s", metadata.Name),
-		Components:  []Component{}, // You would populate this based on your artifact's dependencies
-		CreatedAt:   time.Now(),
+	sbom, err := ArtifactSBOM(artifactPath, metadata)
+	if err != nil {
+		return "", err
+	}
+	return WriteSBOM(artifactPath, sbom, format)
+}
+
+// WriteSBOM writes an SBOM of the artifact in the given format next to the
+// artifact and returns its path.
+func WriteSBOM(artifactPath string, sbom *SBOM, format Format) (string, error) {
+	data, err := EncodeSBOM(sbom, format)
+	if err != nil {
+		return "", err
+	}
+
+	sbomPath := SBOMPath(artifactPath, sbom.Name, format)
+	if err := os.WriteFile(sbomPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write SBOM file: %w", err)
+	}
+	return sbomPath, nil
+}
+
+// EncodeSBOM renders an SBOM in the given format.
+func EncodeSBOM(sbom *SBOM, format Format) ([]byte, error) {
+	switch format {
+	case FormatCycloneDX:
+		return encodeCycloneDX(sbom)
+	case FormatSPDXJSON:
+		return encodeSPDXJSON(sbom)
+	case FormatSPDXTagValue:
+		return encodeSPDXTagValue(sbom)
+	case FormatLegacy:
+		return encodeLegacy(sbom)
 	}
+	return nil, fmt.Errorf("unsupported SBOM format %q (supported: %v)", format, Formats)
+}
 
-	// For demonstration, we'll add a dummy component
-	sbom.Components = append(sbom.Components, Component{
-		Name:    "example-dependency",
-		Version: "1.0.0",
-		Type:    "library",
-		License: "MIT",
-	})
+// SBOMPath returns where the SBOM of an artifact is written in a format:
+// <name>-sbom.json for CycloneDX and legacy JSON, <name>-sbom.spdx.json for
+// SPDX JSON and <name>-sbom.spdx for SPDX tag-value.
+func SBOMPath(artifactPath, name string, format Format) string {
+	suffix := artifactmanager.SBOMSuffix
+	switch format {
+	case FormatSPDXJSON:
+		suffix = artifactmanager.SPDXJSONSBOMSuffix
+	case FormatSPDXTagValue:
+		suffix = artifactmanager.SPDXTagValueSBOMSuffix
+	}
+	return filepath.Join(filepath.Dir(artifactPath), name+suffix)
+}
+
+// FindSBOM returns the path of an existing SBOM for the artifact.
+func FindSBOM(artifactPath, name string) (string, bool) {
+	for _, format := range Formats {
+		path := SBOMPath(artifactPath, name, format)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
 
-	// Convert SBOM to JSON
-	sbomJSON, err := json.MarshalIndent(sbom, "", "  ")
+// BuildSBOM assembles the SBOM of an artifact from its metadata.
+func BuildSBOM(artifactPath string, metadata artifactmanager.ArtifactMetadata) (*SBOM, error) {
+	serial, err := newSerialNumber()
 	if err != nil {
-		return fmt.Errorf("failed to marshal SBOM to JSON: %w", err)
+		return nil, err
 	}
 
-	// Write SBOM to file
-	sbomPath := filepath.Join(filepath.Dir(artifactPath), fmt.Sprintf("%s-sbom.json", metadata.Name))
-	if err := os.WriteFile(sbomPath, sbomJSON, 0644); err != nil {
-		return fmt.Errorf("failed to write SBOM file: %w", err)
+	sbom := &SBOM{
+		SerialNumber: serial,
+		Name:         metadata.Name,
+		Version:      metadata.Version,
+		Description:  fmt.Sprintf("SBOM for %s", metadata.Name),
+		CreatedAt:    time.Now().UTC(),
+		Subject: Component{
+			BOMRef:     fmt.Sprintf("%s@%s", metadata.Name, metadata.Version),
+			Type:       ComponentFile,
+			Name:       metadata.Name,
+			Version:    metadata.Version,
+			License:    metadata.Tags["license"],
+			Hashes:     metadata.Digests,
+			Properties: artifactProperties(metadata),
+		},
 	}
 
-	fmt.Printf("SBOM generated and saved to: %s\n", sbomPath)
-	return nil
+	// Models get a model card and their training datasets as components
+	switch {
+	case isModel(metadata):
+		datasets, err := trainingDatasets(artifactPath, metadata)
+		if err != nil {
+			return nil, err
+		}
+		sbom.Subject.Type = ComponentMachineLearningModel
+		sbom.Subject.ModelCard = modelCard(metadata, datasets)
+		sbom.Components = append(sbom.Components, datasets...)
+	case isDataset(metadata):
+		sbom.Subject.Type = ComponentData
+		sbom.Subject.Data = datasetData(metadata)
+	}
+
+	// The files of a bundle are nested under the artifact
+	for _, entry := range metadata.Manifest {
+		sbom.Subject.Components = append(sbom.Subject.Components, Component{
+			BOMRef: fmt.Sprintf("%s@%s:%s", metadata.Name, metadata.Version, entry.Path),
+			Type:   ComponentFile,
+			Name:   entry.Path,
+			Hashes: entry.Digests,
+		})
+	}
+
+	// Components come from the dependency manifests found in the artifact
+	found, err := discoverComponents(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+	sbom.Components = append(sbom.Components, found.components...)
+	sbom.Dependencies = found.dependencies
+	sbom.Warnings = found.warnings
+
+	assignBOMRefs(sbom)
+	sbom.Dependencies = append([]Dependency{{
+		Ref:       sbom.Subject.BOMRef,
+		DependsOn: rootComponents(sbom.Components, sbom.Dependencies),
+	}}, sbom.Dependencies...)
+	return sbom, nil
+}
+
+// artifactProperties exposes the artifact type and tags as SBOM properties.
+func artifactProperties(metadata artifactmanager.ArtifactMetadata) map[string]string {
+	properties := map[string]string{
+		"tracesync:artifact:type": artifactmanager.ArtifactType(metadata),
+	}
+	for key, value := range metadata.Tags {
+		properties["tracesync:tag:"+key] = value
+	}
+	return properties
+}
+
+// assignBOMRefs gives every top-level component a unique bom-ref, preferring
+// its purl.
+func assignBOMRefs(sbom *SBOM) {
+	used := map[string]bool{sbom.Subject.BOMRef: true}
+	for i := range sbom.Components {
+		component := &sbom.Components[i]
+		ref := component.BOMRef
+		if ref == "" {
+			ref = component.PURL
+		}
+		if ref == "" {
+			ref = fmt.Sprintf("%s@%s", component.Name, component.Version)
+		}
+		unique := ref
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s#%d", ref, n)
+		}
+		used[unique] = true
+		component.BOMRef = unique
+	}
 }
 
+// newSerialNumber returns a random version 4 UUID URN.
+func newSerialNumber() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// toolVersion reports the version TraceSync was built as.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// sortedProperties returns property names in lexical order.
+func sortedProperties(properties map[string]string) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PerformComplianceCheck runs the compliance check on an artifact, prints
+// its findings and returns a *ComplianceError carrying the report if an
+// issue fails the check.
 func PerformComplianceCheck(artifactPath string) error {
+	report, err := CheckCompliance(artifactPath)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(os.Stdout, ReportText); err != nil {
+		return err
+	}
+	if !report.Passed() {
+		return &ComplianceError{Report: report}
+	}
+	return nil
+}
+
+// CheckCompliance evaluates the policy rules, the pinning of dependencies,
+// the license policy and the vulnerability database against the SBOM of an
+// artifact, and returns the findings without printing them. The SBOM is the
+// artifact's imported SBOM if it has one, otherwise the one last written next
+// to the artifact; without either, it is built from the artifact's content.
+func CheckCompliance(artifactPath string) (*Report, error) {
 	// Read artifact metadata
 	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
 	if err != nil {
-		return fmt.Errorf("failed to read artifact metadata: %w", err)
+		return nil, fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+
+	sbom, sbomPath, err := artifactSBOMFile(artifactPath, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return checkCompliance(artifactPath, metadata, sbom, sbomPath)
+}
+
+// CheckSBOMCompliance runs the checks of CheckCompliance against sbom, which
+// was written to sbomPath.
+func CheckSBOMCompliance(artifactPath string, sbom *SBOM, sbomPath string) (*Report, error) {
+	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact metadata: %w", err)
+	}
+	return checkCompliance(artifactPath, metadata, sbom, sbomPath)
+}
+
+// artifactSBOMFile returns the SBOM of an artifact and the file it was read
+// from: the imported SBOM, or else the SBOM last written next to the
+// artifact. Without either, the SBOM is built and the path is empty.
+func artifactSBOMFile(artifactPath string, metadata artifactmanager.ArtifactMetadata) (*SBOM, string, error) {
+	if metadata.SBOM != nil {
+		sbom, err := ArtifactSBOM(artifactPath, metadata)
+		if err != nil {
+			return nil, "", err
+		}
+		return sbom, filepath.Join(filepath.Dir(artifactPath), metadata.SBOM.File), nil
+	}
+	if sbomPath, found := FindSBOM(artifactPath, metadata.Name); found {
+		sbom, err := LoadSBOM(sbomPath)
+		if err != nil {
+			return nil, "", err
+		}
+		return sbom, sbomPath, nil
+	}
+	sbom, err := BuildSBOM(artifactPath, metadata)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build SBOM: %w", err)
 	}
+	return sbom, "", nil
+}
 
-	// Perform compliance checks
-	issues := []string{}
+func checkCompliance(artifactPath string, metadata artifactmanager.ArtifactMetadata, sbom *SBOM, sbomPath string) (*Report, error) {
+	rules, environment := currentPolicies()
+	report := &Report{
+		Artifact:    artifactPath,
+		Name:        metadata.Name,
+		Version:     metadata.Version,
+		Environment: environment,
+		CheckedAt:   time.Now().UTC(),
+	}
 
-	// Check for required metadata fields
-	if metadata.Name == "" {
-		issues = append(issues, "Artifact name is missing")
+	// Evaluate the policy rules, such as the required metadata fields
+	input, err := policyInput(artifactPath, metadata, sbomPath, sbom, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare policy input: %w", err)
 	}
-	if metadata.Version == "" {
-		issues = append(issues, "Artifact version is missing")
+	for _, rule := range rules.Rules() {
+		if rule.Enabled == nil || *rule.Enabled {
+			report.addRule(rule.ID, rule.Description)
+		}
+	}
+	for _, violation := range rules.Evaluate(input) {
+		report.add(violation.RuleID, violation.Severity, "", violation.Message)
 	}
 
-	// Check for SBOM existence
-	sbomPath := filepath.Join(filepath.Dir(artifactPath), fmt.Sprintf("%s-sbom.json", metadata.Name))
-	if _, err := os.Stat(sbomPath); os.IsNotExist(err) {
-		issues = append(issues, "SBOM file is missing")
+	// Check that dependencies are pinned to exact versions
+	report.addRule(RulePinnedDependencies, "Dependencies must be pinned to exact versions")
+	for _, component := range sbom.Components {
+		if spec, ok := component.Properties[propertyUnpinned]; ok {
+			report.add(RulePinnedDependencies, SeverityError, component.Name,
+				fmt.Sprintf("Dependency %s is not pinned to an exact version (%s in %s)",
+					component.Name, spec, component.Properties[propertyLocation]))
+		}
 	}
 
-	// Add more compliance checks as needed
+	// Check the licenses of the artifact and its components
+	if policy, environment := currentLicensePolicy(); policy != nil {
+		report.addRule(RuleLicensePolicy, "Licenses must be allowed by the license policy")
+		components := append([]Component{sbom.Subject}, sbom.Components...)
+		for _, finding := range policy.Check(components, environment) {
+			report.add(RuleLicensePolicy, finding.Severity, finding.Component, fmt.Sprintf("License of %s", finding))
+		}
+	}
 
-	// Report compliance status
-	if len(issues) > 0 {
-		fmt.Println("Compliance check failed. Issues found:")
-		for _, issue := range issues {
-			fmt.Printf("- %s\n", issue)
+	// Check the components against the vulnerability database
+	if db, threshold := currentVulnerabilityDatabase(); db != nil {
+		if !db.Exists() {
+			return nil, fmt.Errorf("vulnerability database %s has no data; import OSV data with 'tracesync vulndb import'", db.Path())
+		}
+		report.addRule(RuleVulnerabilities, fmt.Sprintf("Components must have no known vulnerabilities rated %s or higher", threshold))
+		findings, err := CheckVulnerabilities(db, sbom.Components)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match vulnerabilities: %w", err)
+		}
+		for _, finding := range findings {
+			severity := SeverityWarning
+			if finding.Severity != vulndb.SeverityUnknown && finding.Severity >= threshold {
+				severity = SeverityError
+			}
+			report.add(RuleVulnerabilities, severity, finding.Component, fmt.Sprintf("Vulnerability %s", finding))
 		}
-		return fmt.Errorf("compliance check failed")
 	}
 
-	fmt.Println("Compliance check passed successfully.")
-	return nil
+	// Add more compliance checks as needed
+
+	return report, nil
+}
+
+// hashAlgorithms maps TraceSync digest algorithm names to the names used in
+// SBOM formats.
+var hashAlgorithms = map[string]string{
+	"sha1":   "SHA-1",
+	"sha256": "SHA-256",
+	"sha384": "SHA-384",
+	"sha512": "SHA-512",
+	"md5":    "MD5",
+}
+
+// sortedHashes returns the hash algorithms the SBOM formats can express and,
+// separately, the other ones (such as Go's h1), both in lexical order.
+func sortedHashes(hashes map[string]string) (standard, other []string) {
+	for algorithm := range hashes {
+		if _, ok := hashAlgorithms[strings.ToLower(algorithm)]; ok {
+			standard = append(standard, algorithm)
+		} else {
+			other = append(other, algorithm)
+		}
+	}
+	sort.Strings(standard)
+	sort.Strings(other)
+	return standard, other
 }
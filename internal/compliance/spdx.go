@@ -0,0 +1,356 @@
+package compliance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// noAssertion marks SPDX fields TraceSync has no information about.
+const noAssertion = "NOASSERTION"
+
+// SPDX 2.3 document structure, shared by the JSON and tag-value encoders.
+// See https://spdx.github.io/spdx-spec/v2.3/.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+	// DocumentDescribes is the SPDX 2.2 way of naming the described
+	// packages; TraceSync writes DESCRIBES relationships instead.
+	DocumentDescribes []string               `json:"documentDescribes,omitempty"`
+	ExtractedLicenses []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	CopyrightText         string            `json:"copyrightText"`
+	Description           string            `json:"description,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name,omitempty"`
+}
+
+// spdxPurposes maps component types to SPDX primary package purposes.
+var spdxPurposes = map[string]string{
+	ComponentApplication:     "APPLICATION",
+	ComponentLibrary:         "LIBRARY",
+	ComponentFile:            "FILE",
+	ComponentContainer:       "CONTAINER",
+	ComponentOperatingSystem: "OPERATING-SYSTEM",
+	"framework":              "FRAMEWORK",
+	"device":                 "DEVICE",
+	"firmware":               "FIRMWARE",
+}
+
+// spdxIDChars matches characters that may not appear in an SPDX identifier.
+var spdxIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// spdxBuilder converts an SBOM into an SPDX document, assigning unique
+// identifiers to packages and custom licenses.
+type spdxBuilder struct {
+	doc      spdxDocument
+	ids      map[string]string
+	used     map[string]bool
+	licenses map[string]string
+}
+
+func buildSPDX(sbom *SBOM) *spdxDocument {
+	name := sbom.Name
+	if sbom.Version != "" {
+		name = fmt.Sprintf("%s-%s", sbom.Name, sbom.Version)
+	}
+	b := &spdxBuilder{
+		doc: spdxDocument{
+			SPDXVersion:       "SPDX-2.3",
+			DataLicense:       "CC0-1.0",
+			SPDXID:            "SPDXRef-DOCUMENT",
+			Name:              name,
+			DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", spdxIDChars.ReplaceAllString(name, "-"), strings.TrimPrefix(sbom.SerialNumber, "urn:uuid:")),
+			CreationInfo: spdxCreationInfo{
+				Created:  sbom.CreatedAt.UTC().Format(time.RFC3339),
+				Creators: []string{fmt.Sprintf("Tool: %s-%s", ToolName, toolVersion())},
+			},
+			Packages:      []spdxPackage{},
+			Relationships: []spdxRelationship{},
+		},
+		ids:      make(map[string]string),
+		used:     make(map[string]bool),
+		licenses: make(map[string]string),
+	}
+
+	subject := b.addPackage(sbom.Subject)
+	b.relate(b.doc.SPDXID, "DESCRIBES", subject)
+	b.addContained(subject, sbom.Subject.Components)
+	for _, component := range sbom.Components {
+		b.addContained(b.addPackage(component), component.Components)
+	}
+	for _, dependency := range sbom.Dependencies {
+		from, ok := b.ids[dependency.Ref]
+		if !ok {
+			continue
+		}
+		for _, ref := range dependency.DependsOn {
+			if to, ok := b.ids[ref]; ok {
+				b.relate(from, "DEPENDS_ON", to)
+			}
+		}
+	}
+	return &b.doc
+}
+
+// addContained adds nested components as packages contained in parent.
+func (b *spdxBuilder) addContained(parent string, components []Component) {
+	for _, component := range components {
+		child := b.addPackage(component)
+		b.relate(parent, "CONTAINS", child)
+		b.addContained(child, component.Components)
+	}
+}
+
+func (b *spdxBuilder) addPackage(component Component) string {
+	id := b.packageID(component)
+	pkg := spdxPackage{
+		SPDXID:                id,
+		Name:                  component.Name,
+		VersionInfo:           component.Version,
+		DownloadLocation:      noAssertion,
+		LicenseConcluded:      noAssertion,
+		LicenseDeclared:       b.license(component.License),
+		CopyrightText:         noAssertion,
+		Description:           component.Description,
+		PrimaryPackagePurpose: spdxPurposes[component.Type],
+	}
+	if pkg.PrimaryPackagePurpose == "" && component.Type != "" {
+		pkg.PrimaryPackagePurpose = "OTHER"
+	}
+	standard, other := sortedHashes(component.Hashes)
+	for _, algorithm := range standard {
+		pkg.Checksums = append(pkg.Checksums, spdxChecksum{
+			Algorithm:     strings.ReplaceAll(hashAlgorithms[strings.ToLower(algorithm)], "-", ""),
+			ChecksumValue: component.Hashes[algorithm],
+		})
+	}
+	// SPDX has no checksum algorithm for hashes such as Go's h1
+	var comments []string
+	for _, algorithm := range other {
+		comments = append(comments, fmt.Sprintf("%s: %s", algorithm, component.Hashes[algorithm]))
+	}
+	// SPDX 2.3 has no fields for model cards and datasets either
+	if card := component.ModelCard; card != nil {
+		comments = append(comments, modelCardComments(card)...)
+	}
+	if data := component.Data; data != nil && data.Classification != "" {
+		comments = append(comments, fmt.Sprintf("Data classification: %s", data.Classification))
+	}
+	pkg.Comment = strings.Join(comments, "\n")
+	if component.PURL != "" {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  component.PURL,
+		})
+	}
+	b.doc.Packages = append(b.doc.Packages, pkg)
+	return id
+}
+
+// modelCardComments renders a model card as package comment lines.
+func modelCardComments(card *ModelCard) []string {
+	var comments []string
+	line := func(label, value string) {
+		if value != "" {
+			comments = append(comments, fmt.Sprintf("%s: %s", label, value))
+		}
+	}
+	line("Task", card.Task)
+	line("Approach", card.Approach)
+	line("Architecture", card.Architecture)
+	line("Architecture family", card.ArchitectureFamily)
+	for _, name := range sortedProperties(card.Hyperparameters) {
+		line("Hyperparameter "+name, card.Hyperparameters[name])
+	}
+	for _, name := range sortedProperties(card.Metrics) {
+		line("Metric "+name, card.Metrics[name])
+	}
+	for _, ref := range card.Datasets {
+		line("Trained on", ref)
+	}
+	return comments
+}
+
+// packageID derives a unique SPDX identifier from the component's bom-ref.
+func (b *spdxBuilder) packageID(component Component) string {
+	ref := component.BOMRef
+	if ref == "" {
+		ref = fmt.Sprintf("%s@%s", component.Name, component.Version)
+	}
+	base := "SPDXRef-Package-" + strings.Trim(spdxIDChars.ReplaceAllString(ref, "-"), "-")
+	id := base
+	for n := 2; b.used[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	b.used[id] = true
+	if component.BOMRef != "" {
+		b.ids[component.BOMRef] = id
+	}
+	return id
+}
+
+// license returns the SPDX license expression for a component license.
+// Licenses that are not SPDX expressions become LicenseRef- references with
+// their text recorded as extracted licensing info.
+func (b *spdxBuilder) license(license string) string {
+	if license == "" {
+		return noAssertion
+	}
+	if expression, ok := spdxExpression(license); ok {
+		return expression
+	}
+	if id, ok := b.licenses[license]; ok {
+		return id
+	}
+	id := "LicenseRef-" + strings.Trim(spdxIDChars.ReplaceAllString(license, "-"), "-")
+	for n := 2; b.usedLicenseID(id); n++ {
+		id = fmt.Sprintf("LicenseRef-%s-%d", strings.Trim(spdxIDChars.ReplaceAllString(license, "-"), "-"), n)
+	}
+	b.licenses[license] = id
+	b.doc.ExtractedLicenses = append(b.doc.ExtractedLicenses, spdxExtractedLicense{
+		LicenseID:     id,
+		ExtractedText: license,
+		Name:          license,
+	})
+	return id
+}
+
+func (b *spdxBuilder) usedLicenseID(id string) bool {
+	for _, existing := range b.licenses {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *spdxBuilder) relate(from, relationship, to string) {
+	b.doc.Relationships = append(b.doc.Relationships, spdxRelationship{
+		SPDXElementID:      from,
+		RelationshipType:   relationship,
+		RelatedSPDXElement: to,
+	})
+}
+
+// encodeSPDXJSON renders an SBOM as an SPDX 2.3 JSON document.
+func encodeSPDXJSON(sbom *SBOM) ([]byte, error) {
+	data, err := json.MarshalIndent(buildSPDX(sbom), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SBOM to JSON: %w", err)
+	}
+	return data, nil
+}
+
+// encodeSPDXTagValue renders an SBOM as an SPDX 2.3 tag-value document.
+func encodeSPDXTagValue(sbom *SBOM) ([]byte, error) {
+	doc := buildSPDX(sbom)
+	var buf bytes.Buffer
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\n", name, value)
+		}
+	}
+
+	tag("SPDXVersion", doc.SPDXVersion)
+	tag("DataLicense", doc.DataLicense)
+	tag("SPDXID", doc.SPDXID)
+	tag("DocumentName", doc.Name)
+	tag("DocumentNamespace", doc.DocumentNamespace)
+	for _, creator := range doc.CreationInfo.Creators {
+		tag("Creator", creator)
+	}
+	tag("Created", doc.CreationInfo.Created)
+
+	for _, pkg := range doc.Packages {
+		fmt.Fprintf(&buf, "\n##### Package: %s\n\n", pkg.Name)
+		tag("PackageName", pkg.Name)
+		tag("SPDXID", pkg.SPDXID)
+		tag("PackageVersion", pkg.VersionInfo)
+		tag("PackageDownloadLocation", pkg.DownloadLocation)
+		tag("FilesAnalyzed", fmt.Sprint(pkg.FilesAnalyzed))
+		for _, checksum := range pkg.Checksums {
+			tag("PackageChecksum", fmt.Sprintf("%s: %s", checksum.Algorithm, checksum.ChecksumValue))
+		}
+		tag("PackageLicenseConcluded", pkg.LicenseConcluded)
+		tag("PackageLicenseDeclared", pkg.LicenseDeclared)
+		tag("PackageCopyrightText", pkg.CopyrightText)
+		if pkg.Description != "" {
+			tag("PackageDescription", "<text>"+pkg.Description+"</text>")
+		}
+		for _, ref := range pkg.ExternalRefs {
+			tag("ExternalRef", fmt.Sprintf("%s %s %s", ref.ReferenceCategory, ref.ReferenceType, ref.ReferenceLocator))
+		}
+		tag("PrimaryPackagePurpose", pkg.PrimaryPackagePurpose)
+		if pkg.Comment != "" {
+			tag("PackageComment", "<text>"+pkg.Comment+"</text>")
+		}
+	}
+
+	if len(doc.Relationships) > 0 {
+		buf.WriteString("\n##### Relationships\n\n")
+	}
+	for _, relationship := range doc.Relationships {
+		tag("Relationship", fmt.Sprintf("%s %s %s", relationship.SPDXElementID, relationship.RelationshipType, relationship.RelatedSPDXElement))
+	}
+
+	if len(doc.ExtractedLicenses) > 0 {
+		buf.WriteString("\n##### Extracted licenses\n")
+	}
+	for _, license := range doc.ExtractedLicenses {
+		buf.WriteString("\n")
+		tag("LicenseID", license.LicenseID)
+		tag("ExtractedText", "<text>"+license.ExtractedText+"</text>")
+		tag("LicenseName", license.Name)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,176 @@
+package compliance
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/MChorfa/TraceSync/internal/vulndb"
+)
+
+// VulnerabilityFinding reports a vulnerability affecting a component.
+type VulnerabilityFinding struct {
+	// Component is the component name and version.
+	Component string
+	ID        string
+	Aliases   []string
+	Summary   string
+	Severity  vulndb.Severity
+	// Score is the CVSS base score, or 0 when the entry has none.
+	Score float64
+	Fixed []string
+}
+
+func (f VulnerabilityFinding) String() string {
+	rating := f.Severity.String()
+	if f.Score > 0 {
+		rating = fmt.Sprintf("%s, %.1f", rating, f.Score)
+	}
+	s := fmt.Sprintf("%s (%s) in %s", f.ID, rating, f.Component)
+	if f.Summary != "" {
+		s += ": " + f.Summary
+	}
+	if len(f.Fixed) > 0 {
+		s += fmt.Sprintf(" (fixed in %s)", strings.Join(f.Fixed, ", "))
+	}
+	return s
+}
+
+var (
+	vulnerabilityMu        sync.RWMutex
+	vulnerabilityDB        *vulndb.DB
+	vulnerabilityThreshold vulndb.Severity
+)
+
+// SetVulnerabilityDatabase installs the vulnerability database
+// PerformComplianceCheck matches components against. Vulnerabilities rated
+// at or above the threshold fail the check; others, and those without a
+// rating, are warnings. A nil database turns matching off, which is the
+// default; a database that has no data fails the check.
+func SetVulnerabilityDatabase(db *vulndb.DB, threshold vulndb.Severity) {
+	vulnerabilityMu.Lock()
+	defer vulnerabilityMu.Unlock()
+	vulnerabilityDB = db
+	vulnerabilityThreshold = threshold
+}
+
+func currentVulnerabilityDatabase() (*vulndb.DB, vulndb.Severity) {
+	vulnerabilityMu.RLock()
+	defer vulnerabilityMu.RUnlock()
+	return vulnerabilityDB, vulnerabilityThreshold
+}
+
+// CheckVulnerabilities matches components against a vulnerability database
+// by their package URLs and returns the findings, in component order.
+// Components without a package URL of a known ecosystem are skipped.
+func CheckVulnerabilities(db *vulndb.DB, components []Component) ([]VulnerabilityFinding, error) {
+	var findings []VulnerabilityFinding
+	for _, component := range components {
+		pkg, ok := vulnerabilityPackage(component)
+		if !ok {
+			continue
+		}
+		vulns, err := db.Lookup(pkg)
+		if err != nil {
+			return nil, err
+		}
+		name := component.Name
+		if component.Version != "" {
+			name += "@" + component.Version
+		}
+		for _, vuln := range vulns {
+			severity, score := vuln.Rating(pkg)
+			findings = append(findings, VulnerabilityFinding{
+				Component: name,
+				ID:        vuln.ID,
+				Aliases:   vuln.Aliases,
+				Summary:   vuln.Summary,
+				Severity:  severity,
+				Score:     score,
+				Fixed:     vuln.FixedVersions(pkg),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// purlEcosystems maps purl types to OSV ecosystems.
+var purlEcosystems = map[string]string{
+	"pypi":     "PyPI",
+	"golang":   "Go",
+	"npm":      "npm",
+	"maven":    "Maven",
+	"cargo":    "crates.io",
+	"gem":      "RubyGems",
+	"nuget":    "NuGet",
+	"composer": "Packagist",
+	"hex":      "Hex",
+	"pub":      "Pub",
+}
+
+// distroEcosystems maps the purl namespaces of OS packages to OSV
+// ecosystems.
+var distroEcosystems = map[string]string{
+	"debian":    "Debian",
+	"ubuntu":    "Ubuntu",
+	"alpine":    "Alpine",
+	"rhel":      "Red Hat",
+	"redhat":    "Red Hat",
+	"almalinux": "AlmaLinux",
+	"rocky":     "Rocky Linux",
+	"opensuse":  "openSUSE",
+	"sles":      "SUSE",
+	"mageia":    "Mageia",
+	"openeuler": "openEuler",
+}
+
+// vulnerabilityPackage identifies a component in OSV terms from its package
+// URL. OS packages are looked up by their source package, which is what
+// distribution advisories name, within the release of their distro
+// qualifier.
+func vulnerabilityPackage(component Component) (vulndb.Package, bool) {
+	if component.PURL == "" {
+		return vulndb.Package{}, false
+	}
+	purl, err := parsePackageURL(component.PURL)
+	if err != nil || purl.Version == "" {
+		return vulndb.Package{}, false
+	}
+
+	pkg := vulndb.Package{Name: purl.Name, Version: purl.Version}
+	switch purl.Type {
+	case "deb", "apk", "rpm":
+		ecosystem, ok := distroEcosystems[purl.Namespace]
+		if !ok {
+			return vulndb.Package{}, false
+		}
+		pkg.Ecosystem = ecosystem
+		if _, release, ok := strings.Cut(purl.Qualifiers["distro"], "-"); ok {
+			pkg.Ecosystem += ":" + release
+		}
+		if source := component.Properties["tracesync:os:source"]; source != "" && purl.Type != "rpm" {
+			pkg.Name = source
+		}
+		if epoch := purl.Qualifiers["epoch"]; epoch != "" {
+			pkg.Version = epoch + ":" + pkg.Version
+		}
+		return pkg, true
+	}
+
+	ecosystem, ok := purlEcosystems[purl.Type]
+	if !ok {
+		return vulndb.Package{}, false
+	}
+	pkg.Ecosystem = ecosystem
+	switch {
+	case purl.Namespace == "":
+	case purl.Type == "maven":
+		pkg.Name = purl.Namespace + ":" + purl.Name
+	case purl.Type == "golang", purl.Type == "npm", purl.Type == "composer":
+		pkg.Name = purl.Namespace + "/" + purl.Name
+	}
+	if purl.Type == "golang" {
+		pkg.Version = strings.TrimPrefix(pkg.Version, "v")
+	}
+	return pkg, true
+}
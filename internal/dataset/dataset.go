@@ -0,0 +1,268 @@
+package dataset
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+)
+
+// Check statuses reported in a Report.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// ColumnTypes lists the column types a DatasetSpec can declare.
+var ColumnTypes = []string{"string", "integer", "number", "boolean", "timestamp", "date"}
+
+// CheckResult is the outcome of a single dataset check.
+type CheckResult struct {
+	Check   string `json:"check" yaml:"check"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// Report is the structured result of validating a dataset against its spec.
+type Report struct {
+	Artifact string        `json:"artifact" yaml:"artifact"`
+	Format   string        `json:"format" yaml:"format"`
+	Rows     int64         `json:"rows" yaml:"rows"`
+	Checks   []CheckResult `json:"checks" yaml:"checks"`
+}
+
+// Passed reports whether no check failed.
+func (r *Report) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) add(check, status, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, CheckResult{Check: check, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// profile summarizes a dataset as needed by the checks. Fields that a
+// format cannot provide are left unset and the matching checks are skipped.
+type profile struct {
+	rows    int64
+	columns []string
+	// nulls counts null values per column; a column missing from the map
+	// has no null information.
+	nulls map[string]int64
+	// types holds the storage type of each column for formats with a
+	// schema (parquet).
+	types map[string]string
+	// invalid counts values that do not match the declared column type and
+	// keeps the first offending value, for formats without a schema.
+	invalid      map[string]int64
+	firstInvalid map[string]string
+	// duplicateKeys and nullKeys are only set when primary keys were
+	// checked while reading the data.
+	keysChecked   bool
+	duplicateKeys int64
+	firstDupKey   string
+	nullKeys      int64
+	// keyProblem explains why the declared primary key cannot be checked.
+	keyProblem string
+}
+
+// Validate checks the dataset at path against spec. Problems with the data
+// are reported as failed checks; an error is only returned when the dataset
+// cannot be examined at all.
+func Validate(path string, spec artifactmanager.DatasetSpec) (*Report, error) {
+	format, err := detectFormat(path, spec.Format)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Artifact: path, Format: format}
+
+	for _, column := range spec.Columns {
+		if column.Type != "" && !isColumnType(column.Type) {
+			return nil, fmt.Errorf("column %s: unknown type %q (supported: %s)", column.Name, column.Type, strings.Join(ColumnTypes, ", "))
+		}
+	}
+
+	var p *profile
+	switch format {
+	case "csv", "tsv":
+		p, err = profileCSV(path, format == "tsv", spec)
+	case "jsonl":
+		p, err = profileJSONL(path, spec)
+	case "parquet":
+		p, err = profileParquet(path, spec)
+	}
+	if err != nil {
+		report.add("parse", StatusFail, "%v", err)
+		return report, nil
+	}
+	report.Rows = p.rows
+	report.add("parse", StatusPass, "parsed %d rows and %d columns", p.rows, len(p.columns))
+
+	checkColumns(report, p, spec)
+	checkTypes(report, p, spec)
+	checkNullRates(report, p, spec)
+	checkPrimaryKey(report, p, spec)
+	checkRowCount(report, p, spec)
+	return report, nil
+}
+
+// detectFormat returns the declared format or infers it from the extension.
+func detectFormat(path, declared string) (string, error) {
+	format := strings.ToLower(declared)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = "csv"
+		case ".tsv":
+			format = "tsv"
+		case ".jsonl", ".ndjson":
+			format = "jsonl"
+		case ".parquet", ".pq":
+			format = "parquet"
+		default:
+			return "", fmt.Errorf("cannot infer dataset format of %s, set dataset.format in the descriptor", path)
+		}
+	}
+
+	switch format {
+	case "csv", "tsv", "jsonl", "parquet":
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported dataset format %q (supported: csv, tsv, jsonl, parquet)", format)
+}
+
+func isColumnType(columnType string) bool {
+	for _, known := range ColumnTypes {
+		if columnType == known {
+			return true
+		}
+	}
+	return false
+}
+
+func hasColumn(p *profile, name string) bool {
+	for _, column := range p.columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func checkColumns(report *Report, p *profile, spec artifactmanager.DatasetSpec) {
+	if len(spec.Columns) == 0 {
+		report.add("columns", StatusSkip, "no columns declared")
+		return
+	}
+
+	var missing []string
+	for _, column := range spec.Columns {
+		if !hasColumn(p, column.Name) {
+			missing = append(missing, column.Name)
+		}
+	}
+	if len(missing) > 0 {
+		report.add("columns", StatusFail, "missing columns: %s", strings.Join(missing, ", "))
+		return
+	}
+	report.add("columns", StatusPass, "all %d declared columns present", len(spec.Columns))
+}
+
+func checkTypes(report *Report, p *profile, spec artifactmanager.DatasetSpec) {
+	for _, column := range spec.Columns {
+		check := "type:" + column.Name
+		switch {
+		case column.Type == "":
+			continue
+		case !hasColumn(p, column.Name):
+			report.add(check, StatusSkip, "column is missing")
+		case p.types != nil:
+			actual := p.types[column.Name]
+			if typeCompatible(column.Type, actual) {
+				report.add(check, StatusPass, "column is stored as %s", actual)
+			} else {
+				report.add(check, StatusFail, "expected %s, column is stored as %s", column.Type, actual)
+			}
+		case p.invalid[column.Name] > 0:
+			report.add(check, StatusFail, "%d values are not of type %s, first: %q", p.invalid[column.Name], column.Type, p.firstInvalid[column.Name])
+		default:
+			report.add(check, StatusPass, "all values are of type %s", column.Type)
+		}
+	}
+}
+
+// typeCompatible reports whether a column stored as actual satisfies the
+// declared type.
+func typeCompatible(declared, actual string) bool {
+	return declared == actual || (declared == "number" && actual == "integer")
+}
+
+func checkNullRates(report *Report, p *profile, spec artifactmanager.DatasetSpec) {
+	for _, column := range spec.Columns {
+		if column.MaxNullRate == nil {
+			continue
+		}
+		check := "null_rate:" + column.Name
+		nulls, known := p.nulls[column.Name]
+		switch {
+		case !hasColumn(p, column.Name):
+			report.add(check, StatusSkip, "column is missing")
+		case !known:
+			report.add(check, StatusSkip, "null counts are not available for this column")
+		case p.rows == 0:
+			report.add(check, StatusPass, "dataset has no rows")
+		default:
+			rate := float64(nulls) / float64(p.rows)
+			status := StatusPass
+			if rate > *column.MaxNullRate {
+				status = StatusFail
+			}
+			report.add(check, status, "null rate %.4f (%d of %d rows), maximum %.4f", rate, nulls, p.rows, *column.MaxNullRate)
+		}
+	}
+}
+
+func checkPrimaryKey(report *Report, p *profile, spec artifactmanager.DatasetSpec) {
+	if len(spec.PrimaryKey) == 0 {
+		return
+	}
+	for _, column := range spec.PrimaryKey {
+		if !hasColumn(p, column) {
+			report.add("primary_key", StatusFail, "primary key column %s is missing", column)
+			return
+		}
+	}
+
+	switch {
+	case p.keyProblem != "":
+		report.add("primary_key", StatusFail, "%s", p.keyProblem)
+	case !p.keysChecked:
+		report.add("primary_key", StatusFail, "primary keys are not supported for %s datasets", report.Format)
+	case p.duplicateKeys > 0:
+		report.add("primary_key", StatusFail, "%d duplicate keys, first: %s", p.duplicateKeys, p.firstDupKey)
+	case p.nullKeys > 0:
+		report.add("primary_key", StatusFail, "%d rows have a null primary key", p.nullKeys)
+	default:
+		report.add("primary_key", StatusPass, "no duplicate keys")
+	}
+}
+
+func checkRowCount(report *Report, p *profile, spec artifactmanager.DatasetSpec) {
+	if spec.MinRows == nil && spec.MaxRows == nil {
+		return
+	}
+	switch {
+	case spec.MinRows != nil && p.rows < *spec.MinRows:
+		report.add("row_count", StatusFail, "%d rows, minimum %d", p.rows, *spec.MinRows)
+	case spec.MaxRows != nil && p.rows > *spec.MaxRows:
+		report.add("row_count", StatusFail, "%d rows, maximum %d", p.rows, *spec.MaxRows)
+	default:
+		report.add("row_count", StatusPass, "%d rows within bounds", p.rows)
+	}
+}
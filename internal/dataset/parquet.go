@@ -0,0 +1,224 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+)
+
+// profileParquet reads a parquet file. The schema provides the column types
+// and the row count comes from the file metadata. Only the columns the spec
+// needs are decoded: those with a maximum null rate, for their null counts,
+// and the primary key columns, for duplicate detection.
+func profileParquet(path string, spec artifactmanager.DatasetSpec) (*profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat dataset: %w", err)
+	}
+	parquetFile, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	p := &profile{
+		rows:  parquetFile.NumRows(),
+		nulls: make(map[string]int64),
+		types: make(map[string]string),
+	}
+	leaves := make(map[string]*parquet.Column)
+	var walk func(column *parquet.Column)
+	walk = func(column *parquet.Column) {
+		if !column.Leaf() {
+			for _, child := range column.Columns() {
+				walk(child)
+			}
+			return
+		}
+		name := strings.Join(column.Path(), ".")
+		leaves[name] = column
+		p.columns = append(p.columns, name)
+		p.types[name] = parquetColumnType(column.Type())
+	}
+	walk(parquetFile.Root())
+	if len(p.columns) == 0 {
+		return nil, errors.New("parquet file has no columns")
+	}
+
+	for _, column := range spec.Columns {
+		leaf, ok := leaves[column.Name]
+		if column.MaxNullRate == nil || !ok {
+			continue
+		}
+		nulls, err := parquetNulls(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		p.nulls[column.Name] = nulls
+	}
+
+	if len(spec.PrimaryKey) > 0 {
+		if err := checkParquetKeys(p, leaves, spec.PrimaryKey); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// parquetNulls counts the null values of a column across all row groups.
+func parquetNulls(column *parquet.Column) (int64, error) {
+	pages := column.Pages()
+	defer pages.Close()
+
+	var nulls int64
+	for {
+		page, err := pages.ReadPage()
+		if err == io.EOF {
+			return nulls, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		nulls += page.NumNulls()
+		parquet.Release(page)
+	}
+}
+
+// parquetValues decodes every value of a column as text; a nil entry is a
+// null value.
+func parquetValues(column *parquet.Column) ([]*string, error) {
+	pages := column.Pages()
+	defer pages.Close()
+
+	var values []*string
+	buffer := make([]parquet.Value, 1024)
+	for {
+		page, err := pages.ReadPage()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		reader := page.Values()
+		for {
+			n, err := reader.ReadValues(buffer)
+			for _, v := range buffer[:n] {
+				if v.IsNull() {
+					values = append(values, nil)
+					continue
+				}
+				text := v.String()
+				values = append(values, &text)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				parquet.Release(page)
+				return nil, err
+			}
+		}
+		parquet.Release(page)
+	}
+}
+
+// checkParquetKeys decodes the primary key columns and records duplicate and
+// null keys in p. Key columns that are missing are left to the primary key
+// check to report; repeated columns cannot form a key.
+func checkParquetKeys(p *profile, leaves map[string]*parquet.Column, key []string) error {
+	columns := make([][]*string, len(key))
+	for i, name := range key {
+		leaf, ok := leaves[name]
+		if !ok {
+			return nil
+		}
+		if leaf.MaxRepetitionLevel() > 0 {
+			p.keyProblem = fmt.Sprintf("primary key column %s is repeated", name)
+			return nil
+		}
+		values, err := parquetValues(leaf)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", name, err)
+		}
+		if int64(len(values)) != p.rows {
+			return fmt.Errorf("column %s has %d values for %d rows", name, len(values), p.rows)
+		}
+		columns[i] = values
+	}
+
+	p.keysChecked = true
+	seen := make(map[string]struct{})
+	parts := make([]string, len(key))
+	for row := int64(0); row < p.rows; row++ {
+		null := false
+		for i, values := range columns {
+			if values[row] == nil {
+				null = true
+				break
+			}
+			parts[i] = *values[row]
+		}
+		if null {
+			p.nullKeys++
+			continue
+		}
+		p.observeKey(seen, parts)
+	}
+	return nil
+}
+
+// parquetColumnType maps a column type to the column types used in dataset
+// specs, preferring the logical type annotation when present.
+func parquetColumnType(columnType parquet.Type) string {
+	if logical := columnType.LogicalType(); logical != nil {
+		switch {
+		case logical.UTF8 != nil, logical.Enum != nil, logical.Json != nil, logical.UUID != nil:
+			return "string"
+		case logical.Decimal != nil:
+			return "number"
+		case logical.Date != nil:
+			return "date"
+		case logical.Timestamp != nil:
+			return "timestamp"
+		case logical.Integer != nil:
+			return "integer"
+		}
+	}
+	if converted := columnType.ConvertedType(); converted != nil {
+		switch *converted {
+		case deprecated.UTF8, deprecated.Enum, deprecated.Json:
+			return "string"
+		case deprecated.Decimal:
+			return "number"
+		case deprecated.Date:
+			return "date"
+		case deprecated.TimestampMillis, deprecated.TimestampMicros:
+			return "timestamp"
+		}
+	}
+
+	switch columnType.Kind() {
+	case parquet.Boolean:
+		return "boolean"
+	case parquet.Int32, parquet.Int64:
+		return "integer"
+	case parquet.Int96:
+		return "timestamp"
+	case parquet.Float, parquet.Double:
+		return "number"
+	}
+	return "binary"
+}
@@ -0,0 +1,275 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+)
+
+// value is a single cell of a row-oriented dataset.
+type value struct {
+	null bool
+	text string
+	// raw holds the decoded JSON value for JSONL datasets; it is nil for
+	// delimited text where every cell is a string.
+	raw interface{}
+}
+
+// rowProfiler builds a profile by observing rows one at a time.
+type rowProfiler struct {
+	p        *profile
+	declared map[string]string
+	nonNull  map[string]int64
+	key      []string
+	seen     map[string]struct{}
+}
+
+func newRowProfiler(spec artifactmanager.DatasetSpec) *rowProfiler {
+	declared := make(map[string]string)
+	for _, column := range spec.Columns {
+		if column.Type != "" {
+			declared[column.Name] = column.Type
+		}
+	}
+	return &rowProfiler{
+		p: &profile{
+			nulls:        make(map[string]int64),
+			invalid:      make(map[string]int64),
+			firstInvalid: make(map[string]string),
+			keysChecked:  len(spec.PrimaryKey) > 0,
+		},
+		declared: declared,
+		nonNull:  make(map[string]int64),
+		key:      spec.PrimaryKey,
+		seen:     make(map[string]struct{}),
+	}
+}
+
+func (r *rowProfiler) addColumn(name string) {
+	if _, ok := r.nonNull[name]; !ok {
+		r.nonNull[name] = 0
+		r.p.columns = append(r.p.columns, name)
+	}
+}
+
+func (r *rowProfiler) observe(row map[string]value) {
+	r.p.rows++
+
+	for name, cell := range row {
+		r.addColumn(name)
+		if cell.null {
+			continue
+		}
+		r.nonNull[name]++
+		if declared, ok := r.declared[name]; ok && !matchesType(declared, cell) {
+			if r.p.invalid[name] == 0 {
+				r.p.firstInvalid[name] = cell.text
+			}
+			r.p.invalid[name]++
+		}
+	}
+
+	if len(r.key) > 0 {
+		parts := make([]string, len(r.key))
+		for i, column := range r.key {
+			cell, ok := row[column]
+			if !ok || cell.null {
+				r.p.nullKeys++
+				return
+			}
+			parts[i] = cell.text
+		}
+		r.p.observeKey(r.seen, parts)
+	}
+}
+
+// observeKey records the primary key of one row, counting it as a duplicate
+// if it is already in seen.
+func (p *profile) observeKey(seen map[string]struct{}, parts []string) {
+	key := strings.Join(parts, "\x1f")
+	if _, dup := seen[key]; dup {
+		if p.duplicateKeys == 0 {
+			p.firstDupKey = strings.Join(parts, ", ")
+		}
+		p.duplicateKeys++
+		return
+	}
+	seen[key] = struct{}{}
+}
+
+func (r *rowProfiler) finish() *profile {
+	for _, column := range r.p.columns {
+		r.p.nulls[column] = r.p.rows - r.nonNull[column]
+	}
+	return r.p
+}
+
+// profileCSV reads a delimited text file whose first record is the header.
+// Empty cells are treated as nulls.
+func profileCSV(path string, tabs bool, spec artifactmanager.DatasetSpec) (*profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(bufio.NewReader(file))
+	if tabs {
+		reader.Comma = '\t'
+	}
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty, expected a header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := append([]string(nil), header...)
+
+	profiler := newRowProfiler(spec)
+	for _, column := range columns {
+		profiler.addColumn(column)
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]value, len(columns))
+		for i, column := range columns {
+			row[column] = value{null: record[i] == "", text: record[i]}
+		}
+		profiler.observe(row)
+	}
+	return profiler.finish(), nil
+}
+
+// profileJSONL reads a file with one JSON object per line. Missing keys and
+// JSON nulls are treated as nulls.
+func profileJSONL(path string, spec artifactmanager.DatasetSpec) (*profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	profiler := newRowProfiler(spec)
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(trimmed))
+			decoder.UseNumber()
+			var object map[string]interface{}
+			if decodeErr := decoder.Decode(&object); decodeErr != nil || object == nil {
+				if decodeErr == nil {
+					decodeErr = errors.New("expected a JSON object")
+				}
+				return nil, fmt.Errorf("line %d: %w", line, decodeErr)
+			}
+
+			row := make(map[string]value, len(object))
+			for key, raw := range object {
+				row[key] = value{null: raw == nil, text: jsonText(raw), raw: raw}
+			}
+			profiler.observe(row)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return profiler.finish(), nil
+}
+
+// jsonText renders a decoded JSON value for keys and messages.
+func jsonText(raw interface{}) string {
+	switch v := raw.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// timestampLayouts are the accepted spellings of timestamp values.
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// matchesType reports whether a non-null cell is a valid value of the
+// declared column type.
+func matchesType(declared string, cell value) bool {
+	if cell.raw != nil {
+		switch v := cell.raw.(type) {
+		case json.Number:
+			switch declared {
+			case "integer":
+				_, err := v.Int64()
+				return err == nil
+			case "number":
+				return true
+			}
+			return false
+		case bool:
+			return declared == "boolean"
+		case string:
+			if declared == "string" {
+				return true
+			}
+			if declared != "timestamp" && declared != "date" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	switch declared {
+	case "string":
+		return true
+	case "integer":
+		_, err := strconv.ParseInt(cell.text, 10, 64)
+		return err == nil
+	case "number":
+		_, err := strconv.ParseFloat(cell.text, 64)
+		return err == nil
+	case "boolean":
+		_, err := strconv.ParseBool(cell.text)
+		return err == nil
+	case "timestamp":
+		for _, layout := range timestampLayouts {
+			if _, err := time.Parse(layout, cell.text); err == nil {
+				return true
+			}
+		}
+		return false
+	case "date":
+		_, err := time.Parse("2006-01-02", cell.text)
+		return err == nil
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// Expression is a compiled policy expression in the Common Expression
+// Language (https://github.com/google/cel-spec), with the string extension
+// functions such as lowerAscii and split. Expressions read the variables
+// descriptor, sbom, provenance and environment.
+type Expression struct {
+	source  string
+	program cel.Program
+	// returnsBool is false when the expression can only return something
+	// other than a bool.
+	returnsBool bool
+}
+
+// environment declares the variables of the compliance check's policy input.
+var environment = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("descriptor", cel.DynType),
+		cel.Variable("sbom", cel.DynType),
+		cel.Variable("provenance", cel.DynType),
+		cel.Variable("environment", cel.StringType),
+		ext.Strings(),
+	)
+})
+
+// Compile parses and type-checks an expression.
+func Compile(source string) (*Expression, error) {
+	env, err := environment()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(source)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	output := ast.OutputType()
+	return &Expression{
+		source:      source,
+		program:     program,
+		returnsBool: output.IsExactType(cel.BoolType) || output.IsExactType(cel.DynType),
+	}, nil
+}
+
+// String returns the expression source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression with the given variables and returns the
+// result as a Go value. JSON numbers in the variables are bound as integers
+// when they have no fraction, as doubles otherwise.
+func (e *Expression) Eval(vars map[string]any) (any, error) {
+	bound := make(map[string]any, len(vars))
+	for name, value := range vars {
+		bound[name] = bind(value)
+	}
+	result, _, err := e.program.Eval(bound)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value(), nil
+}
+
+// bind converts the JSON numbers of decoded descriptors and provenance,
+// which CEL does not know, into int64 or float64.
+func bind(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]any:
+		bound := make(map[string]any, len(v))
+		for key, item := range v {
+			bound[key] = bind(item)
+		}
+		return bound
+	case []any:
+		bound := make([]any, len(v))
+		for i, item := range v {
+			bound[i] = bind(item)
+		}
+		return bound
+	}
+	return value
+}
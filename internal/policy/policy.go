@@ -0,0 +1,229 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of rules. Violations of error rules fail the compliance check;
+// violations of warning rules are reported only.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Rule is a compliance rule: Expr must evaluate to true for the artifact to
+// comply, otherwise Message is reported with the rule's severity.
+type Rule struct {
+	ID          string `yaml:"id" json:"id"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Severity    string `yaml:"severity" json:"severity"`
+	Message     string `yaml:"message" json:"message"`
+	Expr        string `yaml:"expr" json:"expr"`
+	// Enabled turns a rule off when false, such as a built-in rule that
+	// does not apply to an organisation.
+	Enabled *bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	expression *Expression
+}
+
+// Violation reports a rule an artifact does not comply with.
+type Violation struct {
+	RuleID   string
+	Severity string
+	Message  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s [%s]", v.Message, v.RuleID)
+}
+
+// policyFile is the layout of the files of a policy directory.
+type policyFile struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Set is an ordered set of rules with unique IDs.
+type Set struct {
+	rules []*Rule
+}
+
+// builtinRules are the checks TraceSync applies unless a policy directory
+// overrides or disables them.
+var builtinRules = []Rule{
+	{
+		ID:          "artifact-name",
+		Description: "Artifacts must be named",
+		Severity:    SeverityError,
+		Message:     "Artifact name is missing",
+		Expr:        `descriptor.name != ""`,
+	},
+	{
+		ID:          "artifact-version",
+		Description: "Artifacts must be versioned",
+		Severity:    SeverityError,
+		Message:     "Artifact version is missing",
+		Expr:        `descriptor.version != ""`,
+	},
+	{
+		ID:          "sbom-present",
+		Description: "Artifacts must have an SBOM",
+		Severity:    SeverityError,
+		Message:     "SBOM file is missing",
+		Expr:        `sbom.path != ""`,
+	},
+}
+
+// Builtin returns the built-in rules.
+func Builtin() *Set {
+	set := &Set{}
+	for _, rule := range builtinRules {
+		rule := rule
+		if err := set.add(&rule, true); err != nil {
+			panic(err)
+		}
+	}
+	return set
+}
+
+// LoadDir returns the built-in rules together with the rules of the YAML
+// and JSON files in dir, read in name order. A rule with the ID of a
+// built-in rule replaces it.
+func LoadDir(dir string) (*Set, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, entry.Name())
+			}
+		}
+	}
+	sort.Strings(files)
+
+	set := Builtin()
+	builtin := make(map[string]bool)
+	for _, rule := range set.rules {
+		builtin[rule.ID] = true
+	}
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy: %w", err)
+		}
+		var file policyFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+		}
+		for i := range file.Rules {
+			rule := file.Rules[i]
+			if err := rule.compile(); err != nil {
+				return nil, fmt.Errorf("policy %s: %w", path, err)
+			}
+			// Built-in rules may be replaced once; later duplicates are errors
+			replace := builtin[rule.ID]
+			delete(builtin, rule.ID)
+			if err := set.add(&rule, replace); err != nil {
+				return nil, fmt.Errorf("policy %s: %w", path, err)
+			}
+		}
+	}
+	return set, nil
+}
+
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule without an id")
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning:
+	default:
+		return fmt.Errorf("rule %s: unknown severity %q (supported: error, warning)", r.ID, r.Severity)
+	}
+	if r.Message == "" {
+		return fmt.Errorf("rule %s: message is missing", r.ID)
+	}
+	if r.Expr == "" {
+		return fmt.Errorf("rule %s: expr is missing", r.ID)
+	}
+	expression, err := Compile(r.Expr)
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+	if !expression.returnsBool {
+		return fmt.Errorf("rule %s: expression does not return a bool", r.ID)
+	}
+	r.expression = expression
+	return nil
+}
+
+// add appends a rule, or replaces the rule with the same ID if replace is
+// set.
+func (s *Set) add(rule *Rule, replace bool) error {
+	if rule.expression == nil {
+		if err := rule.compile(); err != nil {
+			return err
+		}
+	}
+	for i, existing := range s.rules {
+		if existing.ID != rule.ID {
+			continue
+		}
+		if !replace {
+			return fmt.Errorf("duplicate rule id %s", rule.ID)
+		}
+		s.rules[i] = rule
+		return nil
+	}
+	s.rules = append(s.rules, rule)
+	return nil
+}
+
+// Rules returns the rules of the set, including disabled ones.
+func (s *Set) Rules() []Rule {
+	rules := make([]Rule, len(s.rules))
+	for i, rule := range s.rules {
+		rules[i] = *rule
+	}
+	return rules
+}
+
+// Evaluate checks the enabled rules against input, whose entries are the
+// variables of the expressions. A rule whose expression fails or does not
+// return a bool is violated, with the error appended to its message.
+func (s *Set) Evaluate(input map[string]any) []Violation {
+	var violations []Violation
+	for _, rule := range s.rules {
+		if rule.Enabled != nil && !*rule.Enabled {
+			continue
+		}
+		result, err := rule.expression.Eval(input)
+		if err == nil {
+			if passed, ok := result.(bool); ok {
+				if passed {
+					continue
+				}
+			} else {
+				err = fmt.Errorf("expression returned %T, not a bool", result)
+			}
+		}
+		message := rule.Message
+		if err != nil {
+			message = fmt.Sprintf("%s (rule could not be evaluated: %v)", message, err)
+		}
+		violations = append(violations, Violation{RuleID: rule.ID, Severity: rule.Severity, Message: message})
+	}
+	return violations
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// isKeyword reports whether the token is the given boolean keyword. Keywords
+// are case-insensitive.
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// operators is ordered so that two-character operators match first.
+var operators = []string{">=", "<=", "!=", "=", ">", "<", "~"}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '"':
+			value, end, err := readString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: i})
+			i = end
+		default:
+			if op := matchOperator(runes, i); op != "" {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(runes) && !isDelimiter(runes, i) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// readString reads a double-quoted string starting at start and returns its
+// unescaped content and the index just past the closing quote.
+func readString(runes []rune, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteRune(runes[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func matchOperator(runes []rune, i int) string {
+	for _, op := range operators {
+		if strings.HasPrefix(string(runes[i:min(i+len(op), len(runes))]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDelimiter(runes []rune, i int) bool {
+	r := runes[i]
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' || matchOperator(runes, i) != ""
+}
@@ -0,0 +1,261 @@
+package query
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/semver"
+)
+
+// Query is a parsed search expression such as
+//
+//	owner=vision AND stage!=archived AND created_at>2026-01-01
+//
+// Comparisons are combined with AND, OR, NOT and parentheses. Supported
+// operators are =, !=, >, >=, <, <= and ~ (glob match). Values containing
+// spaces or operator characters can be double-quoted.
+type Query struct {
+	root node
+}
+
+// Parse compiles a search expression.
+func Parse(input string) (*Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("empty query")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &Query{root: root}, nil
+}
+
+// Match evaluates the query against a record's fields. Field names that are
+// not present are looked up as tags under "tags.<name>", so "owner=vision"
+// and "tags.owner=vision" are equivalent. Missing fields compare as the empty
+// string.
+func (q *Query) Match(fields map[string]string) bool {
+	return q.root.eval(fields)
+}
+
+type node interface {
+	eval(fields map[string]string) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(fields map[string]string) bool {
+	return n.left.eval(fields) && n.right.eval(fields)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(fields map[string]string) bool {
+	return n.left.eval(fields) || n.right.eval(fields)
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(fields map[string]string) bool {
+	return !n.operand.eval(fields)
+}
+
+type comparisonNode struct {
+	field string
+	op    string
+	value string
+}
+
+func (n comparisonNode) eval(fields map[string]string) bool {
+	actual, ok := fields[n.field]
+	if !ok {
+		actual = fields["tags."+n.field]
+	}
+
+	switch n.op {
+	case "=":
+		return equal(actual, n.value)
+	case "!=":
+		return !equal(actual, n.value)
+	case "~":
+		matched, err := path.Match(n.value, actual)
+		return err == nil && matched
+	}
+
+	cmp, ok := compare(actual, n.value)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// equal reports whether two values are the same, treating numbers and
+// timestamps that differ only in formatting as equal.
+func equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	cmp, ok := compareTyped(a, b)
+	return ok && cmp == 0
+}
+
+// compare orders two values as timestamps, then semantic versions, then
+// numbers, then strings.
+func compare(a, b string) (int, bool) {
+	if a == "" {
+		return 0, false
+	}
+	if cmp, ok := compareTyped(a, b); ok {
+		return cmp, true
+	}
+	return strings.Compare(a, b), true
+}
+
+func compareTyped(a, b string) (int, bool) {
+	if ta, ok := parseTime(a); ok {
+		if tb, ok := parseTime(b); ok {
+			return ta.Compare(tb), true
+		}
+	}
+	// Versions such as 1.10.0 and 1.9.0 order by precedence, not as text
+	if va, err := semver.Parse(a); err == nil {
+		if vb, err := semver.Parse(b); err == nil {
+			return semver.Compare(va, vb), true
+		}
+	}
+	if fa, err := strconv.ParseFloat(a, 64); err == nil {
+		if fb, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// timeLayouts are the timestamp formats recognized in fields and values.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+	switch {
+	case tok.isKeyword("NOT"):
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	case tok.kind == tokenLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ) at position %d", closing.pos)
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	field := p.next()
+	if field.kind != tokenWord || field.isKeyword("AND") || field.isKeyword("OR") {
+		return nil, fmt.Errorf("expected field name at position %d, got %q", field.pos, field.text)
+	}
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, fmt.Errorf("expected operator after %q at position %d", field.text, op.pos)
+	}
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("expected value after %q at position %d", field.text+op.text, value.pos)
+	}
+	return comparisonNode{field: field.text, op: op.text, value: value.text}, nil
+}
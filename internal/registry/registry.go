@@ -0,0 +1,237 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/utils"
+)
+
+// ErrNotFound is returned when an artifact is not in the registry.
+var ErrNotFound = errors.New("artifact not found in registry")
+
+// maxEvents bounds the event history kept per artifact.
+const maxEvents = 50
+
+// Entry describes one artifact known to TraceSync.
+type Entry struct {
+	Path      string            `json:"path"`
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Digests   map[string]string `json:"digests,omitempty"`
+	Status    string            `json:"status"`
+	Backend   string            `json:"backend,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Events    []Event           `json:"events,omitempty"`
+}
+
+// Event records a single action performed on an artifact.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// Registry is a persistent index of artifacts stored as a JSON file. Writes
+// hold an advisory lock and replace the file atomically, so concurrent CLI
+// invocations never lose updates or observe a partial file.
+type Registry struct {
+	path string
+}
+
+type registryFile struct {
+	Artifacts map[string]Entry `json:"artifacts"`
+}
+
+// DefaultPath returns the registry file used when none is configured:
+// $TRACESYNC_REGISTRY if set, otherwise registry.json in the user's
+// TraceSync config directory.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("TRACESYNC_REGISTRY"); path != "" {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(configDir, "tracesync", "registry.json"), nil
+}
+
+// Open returns the registry stored at path, creating its directory if needed.
+func Open(path string) (*Registry, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	return &Registry{path: path}, nil
+}
+
+// Path returns the registry file location.
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// List returns every registered artifact ordered by path.
+func (r *Registry) List() ([]Entry, error) {
+	data, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(data.Artifacts))
+	for _, entry := range data.Artifacts {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// Get returns the entry for the artifact at artifactPath.
+func (r *Registry) Get(artifactPath string) (Entry, error) {
+	key, err := entryKey(artifactPath)
+	if err != nil {
+		return Entry{}, err
+	}
+	data, err := r.read()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry, ok := data.Artifacts[key]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return entry, nil
+}
+
+// Put stores entry, replacing any existing entry for the same path.
+func (r *Registry) Put(entry Entry) error {
+	return r.Update(entry.Path, func(existing *Entry) error {
+		path, createdAt := existing.Path, existing.CreatedAt
+		*existing = entry
+		existing.Path = path
+		if existing.CreatedAt.IsZero() {
+			existing.CreatedAt = createdAt
+		}
+		return nil
+	})
+}
+
+// Update applies fn to the entry for artifactPath under the registry lock.
+// A new entry is created if the artifact is not registered yet.
+func (r *Registry) Update(artifactPath string, fn func(*Entry) error) error {
+	key, err := entryKey(artifactPath)
+	if err != nil {
+		return err
+	}
+
+	return r.modify(func(data *registryFile) error {
+		now := time.Now()
+		entry, ok := data.Artifacts[key]
+		if !ok {
+			entry = Entry{CreatedAt: now}
+		}
+		entry.Path = key
+		if err := fn(&entry); err != nil {
+			return err
+		}
+		entry.UpdatedAt = now
+		data.Artifacts[key] = entry
+		return nil
+	})
+}
+
+// Record appends an event to the artifact's history and makes its status the
+// artifact's current status.
+func (r *Registry) Record(artifactPath string, event Event, fn func(*Entry)) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	return r.Update(artifactPath, func(entry *Entry) error {
+		if fn != nil {
+			fn(entry)
+		}
+		entry.Status = event.Status
+		entry.Events = append(entry.Events, event)
+		if len(entry.Events) > maxEvents {
+			entry.Events = entry.Events[len(entry.Events)-maxEvents:]
+		}
+		return nil
+	})
+}
+
+// Delete removes the artifact at artifactPath from the registry.
+func (r *Registry) Delete(artifactPath string) error {
+	key, err := entryKey(artifactPath)
+	if err != nil {
+		return err
+	}
+
+	return r.modify(func(data *registryFile) error {
+		if _, ok := data.Artifacts[key]; !ok {
+			return fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		delete(data.Artifacts, key)
+		return nil
+	})
+}
+
+// modify runs fn against the current registry content while holding the
+// registry lock and atomically writes the result back.
+func (r *Registry) modify(fn func(*registryFile) error) error {
+	lock, err := utils.LockFile(r.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := r.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(data); err != nil {
+		return err
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry: %w", err)
+	}
+	return utils.WriteFileAtomic(r.path, encoded, 0644)
+}
+
+// read loads the registry file. A missing file is an empty registry.
+func (r *Registry) read() (*registryFile, error) {
+	data := &registryFile{Artifacts: make(map[string]Entry)}
+
+	content, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry: %w", err)
+	}
+	if err := json.Unmarshal(content, data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal registry: %w", err)
+	}
+	if data.Artifacts == nil {
+		data.Artifacts = make(map[string]Entry)
+	}
+	return data, nil
+}
+
+// entryKey normalizes an artifact path into the key used by the registry.
+func entryKey(artifactPath string) (string, error) {
+	abs, err := filepath.Abs(artifactPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve artifact path: %w", err)
+	}
+	return abs, nil
+}
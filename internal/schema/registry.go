@@ -0,0 +1,68 @@
+package schema
+
+import "fmt"
+
+// DefaultType is the artifact type whose schema applies to every artifact.
+const DefaultType = "default"
+
+// Builtin returns the schema used when no default schema is configured. It
+// requires the fields every descriptor entry must have.
+func Builtin() *Schema {
+	minLength := 1
+	return &Schema{
+		Type:     "object",
+		Required: []string{"name", "version", "created_at"},
+		Properties: map[string]*Schema{
+			"name":       {Type: "string", MinLength: &minLength},
+			"version":    {Type: "string", MinLength: &minLength},
+			"created_at": {Type: "string", Format: "date-time"},
+			"tags":       {Type: "object"},
+		},
+	}
+}
+
+// Registry selects descriptor schemas by artifact type. Every artifact is
+// checked against the default schema and, when one is registered for its
+// type, against the type's schema as well.
+type Registry struct {
+	schemas map[string]*Schema
+}
+
+// NewRegistry returns a registry holding only the built-in default schema.
+func NewRegistry() *Registry {
+	return &Registry{schemas: map[string]*Schema{DefaultType: Builtin()}}
+}
+
+// LoadRegistry builds a registry from schema files keyed by artifact type.
+// A "default" entry replaces the built-in default schema.
+func LoadRegistry(files map[string]string) (*Registry, error) {
+	registry := NewRegistry()
+	for artifactType, path := range files {
+		s, err := LoadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("schema for %s artifacts: %w", artifactType, err)
+		}
+		registry.Register(artifactType, s)
+	}
+	return registry, nil
+}
+
+// Register sets the schema for an artifact type.
+func (r *Registry) Register(artifactType string, s *Schema) {
+	r.schemas[artifactType] = s
+}
+
+// Validate checks document against the default schema and the schema for
+// artifactType, returning every violation.
+func (r *Registry) Validate(artifactType string, document interface{}) []Violation {
+	var violations []Violation
+	if s, ok := r.schemas[DefaultType]; ok {
+		violations = append(violations, s.Validate(document)...)
+	}
+	if artifactType != DefaultType {
+		if s, ok := r.schemas[artifactType]; ok {
+			violations = append(violations, s.Validate(document)...)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,271 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a JSON-Schema-style description of a descriptor entry. The
+// supported keywords are type, required, properties, enum, pattern,
+// minLength, maxLength, minimum, maximum, format, items, minItems and
+// maxItems. Schemas are written in JSON or YAML.
+type Schema struct {
+	Type       string             `yaml:"type,omitempty" json:"type,omitempty"`
+	Required   []string           `yaml:"required,omitempty" json:"required,omitempty"`
+	Properties map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Enum       []interface{}      `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Pattern    string             `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	MinLength  *int               `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength  *int               `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+	Minimum    *float64           `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum    *float64           `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	Format     string             `yaml:"format,omitempty" json:"format,omitempty"`
+	Items      *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+	MinItems   *int               `yaml:"minItems,omitempty" json:"minItems,omitempty"`
+	MaxItems   *int               `yaml:"maxItems,omitempty" json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Violation is a single schema violation. Path is a JSON pointer to the
+// offending value, such as /tags/owner.
+type Violation struct {
+	Path    string `yaml:"path" json:"path"`
+	Message string `yaml:"message" json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// LoadFile reads a schema from a JSON or YAML file.
+func LoadFile(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse reads a schema from JSON or YAML.
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schema: %w", err)
+	}
+	if err := s.compile(""); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// compile checks keyword values and prepares regular expressions.
+func (s *Schema) compile(path string) error {
+	switch s.Type {
+	case "", "object", "array", "string", "integer", "number", "boolean":
+	default:
+		return fmt.Errorf("%s: unsupported type %q", pointer(path), s.Type)
+	}
+	switch s.Format {
+	case "", "date-time", "date", "semver":
+	default:
+		return fmt.Errorf("%s: unsupported format %q", pointer(path), s.Format)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", pointer(path), err)
+		}
+		s.pattern = re
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s: empty property schema", pointer(path+"/"+name))
+		}
+		if err := property.compile(path + "/" + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "/items")
+	}
+	return nil
+}
+
+// Validate checks a document decoded from JSON (maps, slices, strings,
+// float64 numbers, booleans and nil) and returns every violation found,
+// ordered by path.
+func (s *Schema) Validate(document interface{}) []Violation {
+	var violations []Violation
+	s.validate("", document, &violations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations
+}
+
+func (s *Schema) validate(path string, value interface{}, violations *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Path: pointer(path), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		report("expected %s, got %s", s.Type, typeName(value))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, candidate := range s.Enum {
+			if equalValues(candidate, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			report("must be one of %s", formatEnum(s.Enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*violations = append(*violations, Violation{Path: pointer(path + "/" + escape(name)), Message: "is required"})
+			}
+		}
+		for name, property := range s.Properties {
+			if child, ok := v[name]; ok {
+				property.validate(path+"/"+escape(name), child, violations)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(path+"/"+strconv.Itoa(i), item, violations)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			report("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("must match pattern %s", s.Pattern)
+		}
+		if s.Format != "" && !matchesFormat(s.Format, v) {
+			report("must be a valid %s", s.Format)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			report("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+func hasType(value interface{}, expected string) bool {
+	switch expected {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+	return typeName(value) == expected
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// equalValues compares an enum entry decoded from YAML with a document value
+// decoded from JSON.
+func equalValues(candidate, value interface{}) bool {
+	switch c := candidate.(type) {
+	case int:
+		f, ok := value.(float64)
+		return ok && f == float64(c)
+	case float64:
+		f, ok := value.(float64)
+		return ok && f == c
+	}
+	return reflect.DeepEqual(candidate, value)
+}
+
+func formatEnum(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "semver":
+		_, err := semver.Parse(value)
+		return err == nil
+	}
+	return true
+}
+
+// pointer renders an internal path as a JSON pointer; the document root is "/".
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// escape encodes a property name as a JSON pointer reference token.
+func escape(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,184 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parts of a version that can be bumped.
+const (
+	Major = "major"
+	Minor = "minor"
+	Patch = "patch"
+)
+
+// Version is a semantic version as defined by https://semver.org.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses a semantic version such as 1.4.2, 2.0.0-rc.1 or 1.0.0+build.5.
+func Parse(s string) (Version, error) {
+	var v Version
+	rest := s
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		build := rest[i+1:]
+		rest = rest[:i]
+		v.Build = strings.Split(build, ".")
+		for _, identifier := range v.Build {
+			if !validIdentifier(identifier) {
+				return Version{}, fmt.Errorf("invalid semantic version %q: invalid build metadata", s)
+			}
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		prerelease := rest[i+1:]
+		rest = rest[:i]
+		v.Prerelease = strings.Split(prerelease, ".")
+		for _, identifier := range v.Prerelease {
+			if !validIdentifier(identifier) || (isNumeric(identifier) && len(identifier) > 1 && identifier[0] == '0') {
+				return Version{}, fmt.Errorf("invalid semantic version %q: invalid pre-release", s)
+			}
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return Version{}, fmt.Errorf("invalid semantic version %q: %q is not a valid number", s, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Bump returns the next version for the given part. Pre-release and build
+// metadata are dropped; a pre-release is bumped to its own release when it
+// already has the requested shape, so 2.0.0-rc.1 bumped by major is 2.0.0.
+func (v Version) Bump(part string) (Version, error) {
+	release := len(v.Prerelease) == 0
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch part {
+	case Major:
+		if release || v.Minor != 0 || v.Patch != 0 {
+			next = Version{Major: v.Major + 1}
+		}
+	case Minor:
+		if release || v.Patch != 0 {
+			next = Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+	case Patch:
+		if release {
+			next.Patch++
+		}
+	default:
+		return Version{}, fmt.Errorf("unknown version part %q (expected %s, %s or %s)", part, Major, Minor, Patch)
+	}
+	return next, nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether a has lower, equal or
+// higher precedence than b. Build metadata is ignored.
+func Compare(a, b Version) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A release has higher precedence than any of its pre-releases
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifiers(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a.Prerelease) < len(b.Prerelease):
+		return -1
+	case len(a.Prerelease) > len(b.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifiers orders pre-release identifiers: numeric identifiers
+// compare numerically and sort before alphanumeric ones.
+func compareIdentifiers(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func validIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,8 @@
 package storagemanager
 
 import (
+	"archive/tar"
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -9,14 +11,34 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
 )
 
-// EncryptArtifact encrypts the given artifact and returns the path to the encrypted file
+// EncryptArtifact encrypts the given artifact and returns the path to the encrypted file.
+// Directory artifacts (bundles) are packed into a single tar archive first.
 func EncryptArtifact(artifactPath string) (string, error) {
-	// Read the artifact file
-	plaintext, err := os.ReadFile(artifactPath)
+	info, err := os.Stat(artifactPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read artifact file: %w", err)
+		return "", fmt.Errorf("failed to stat artifact: %w", err)
+	}
+
+	// Read the artifact file
+	var plaintext []byte
+	encryptedPath := artifactPath + ".enc"
+	if info.IsDir() {
+		artifactPath = filepath.Clean(artifactPath)
+		plaintext, err = archiveBundle(artifactPath)
+		if err != nil {
+			return "", err
+		}
+		encryptedPath = artifactPath + ".tar.enc"
+	} else {
+		plaintext, err = os.ReadFile(artifactPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read artifact file: %w", err)
+		}
 	}
 
 	// Generate a random 32-byte key for AES-256
@@ -51,7 +73,6 @@ func EncryptArtifact(artifactPath string) (string, error) {
 	encodedData := base64.StdEncoding.EncodeToString(encryptedData)
 
 	// Write the encrypted data to a new file
-	encryptedPath := artifactPath + ".enc"
 	if err := os.WriteFile(encryptedPath, []byte(encodedData), 0644); err != nil {
 		return "", fmt.Errorf("failed to write encrypted file: %w", err)
 	}
@@ -59,6 +80,43 @@ func EncryptArtifact(artifactPath string) (string, error) {
 	return encryptedPath, nil
 }
 
+// archiveBundle packs the files of a bundle into a tar archive with paths
+// relative to the bundle root. Entries are written in manifest order with
+// fixed ownership and timestamps so the same bundle always yields the same
+// archive.
+func archiveBundle(dir string) ([]byte, error) {
+	files, err := artifactmanager.BundleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle file: %w", err)
+		}
+		header := &tar.Header{
+			Name:     file,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", file, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", file, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize bundle archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // UploadArtifact uploads the encrypted artifact to the specified storage backend
 func UploadArtifact(encryptedArtifactPath, backend string) error {
 	switch backend {
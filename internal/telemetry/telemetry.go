@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"github.com/MChorfa/TraceSync/internal/registry"
 )
 
 type QualityMetrics struct {
@@ -41,8 +43,16 @@ Last Updated: %s`,
 		metrics.LastUpdated.Format(time.RFC3339))
 }
 
-func GetAllArtifacts() ([]string, error) {
-	// In a real implementation, this would fetch a list of artifacts from a database or storage system
-	// For now, we'll return a mock list
-	return []string{"artifact1", "artifact2", "artifact3"}, nil
+// GetAllArtifacts returns the paths of every artifact in the local registry.
+func GetAllArtifacts(reg *registry.Registry) ([]string, error) {
+	entries, err := reg.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registered artifacts: %w", err)
+	}
+
+	artifacts := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		artifacts = append(artifacts, entry.Path)
+	}
+	return artifacts, nil
 }
@@ -0,0 +1,64 @@
+//go:build !unix
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// lockRetryInterval is how often a contended lock is retried on
+	// platforms without flock.
+	lockRetryInterval = 50 * time.Millisecond
+	// lockStaleAfter is the age after which a marker is taken to be left
+	// behind by a process that crashed while holding the lock. Locks are only
+	// held for a descriptor or registry update.
+	lockStaleAfter = 30 * time.Second
+	// lockTimeout bounds how long a contended lock is waited for.
+	lockTimeout = 2 * time.Minute
+)
+
+// Without flock, the lock is a marker file created exclusively next to the
+// lock file and removed on unlock. The marker holds the owner's process ID.
+func lock(file *os.File) error {
+	markerPath := file.Name() + ".held"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		marker, err := os.OpenFile(markerPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(marker, "%d\n", os.Getpid())
+			if closeErr := marker.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+
+		info, err := os.Stat(markerPath)
+		switch {
+		case err == nil && time.Since(info.ModTime()) > lockStaleAfter:
+			// Left behind by a crashed holder
+			if err := os.Remove(markerPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove stale lock marker: %w", err)
+			}
+			continue
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+		if time.Now().After(deadline) {
+			owner, _ := os.ReadFile(markerPath)
+			return fmt.Errorf("timed out waiting for the lock held by process %s", strings.TrimSpace(string(owner)))
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func unlock(file *os.File) error {
+	return os.Remove(file.Name() + ".held")
+}
@@ -0,0 +1,241 @@
+package vulndb
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Vulnerability is an entry in the OSV format
+// (https://ossf.github.io/osv-schema/). Fields TraceSync does not use are
+// kept in the stored files but not decoded.
+type Vulnerability struct {
+	ID               string         `json:"id"`
+	Modified         time.Time      `json:"modified"`
+	Published        time.Time      `json:"published,omitempty"`
+	Withdrawn        *time.Time     `json:"withdrawn,omitempty"`
+	Aliases          []string       `json:"aliases,omitempty"`
+	Summary          string         `json:"summary,omitempty"`
+	Details          string         `json:"details,omitempty"`
+	Severity         []SeverityInfo `json:"severity,omitempty"`
+	Affected         []Affected     `json:"affected"`
+	DatabaseSpecific map[string]any `json:"database_specific,omitempty"`
+}
+
+// SeverityInfo is a severity score, such as a CVSS vector.
+type SeverityInfo struct {
+	// Type is CVSS_V2, CVSS_V3, CVSS_V4 or a distribution's own scale, such
+	// as Ubuntu.
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected lists the affected versions of one package.
+type Affected struct {
+	Package          Package        `json:"package"`
+	Severity         []SeverityInfo `json:"severity,omitempty"`
+	Ranges           []Range        `json:"ranges,omitempty"`
+	Versions         []string       `json:"versions,omitempty"`
+	DatabaseSpecific map[string]any `json:"database_specific,omitempty"`
+}
+
+// Package identifies a package in an ecosystem. In queries, Version is the
+// installed version; OSV entries leave it empty.
+type Package struct {
+	// Ecosystem is the OSV ecosystem, such as PyPI, Go or Debian:12. A
+	// release after the colon restricts distribution ecosystems.
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	PURL      string `json:"purl,omitempty"`
+	Version   string `json:"-"`
+}
+
+// Range is a range of affected versions described by events.
+type Range struct {
+	// Type is SEMVER, ECOSYSTEM or GIT. GIT ranges name commits and are not
+	// matched.
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// Event starts or ends an affected range. Exactly one field is set;
+// introduced "0" stands for the first version.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+func (e Event) version() string {
+	switch {
+	case e.Introduced != "":
+		return e.Introduced
+	case e.Fixed != "":
+		return e.Fixed
+	case e.LastAffected != "":
+		return e.LastAffected
+	}
+	return e.Limit
+}
+
+// Affects reports whether the vulnerability affects a package version.
+func (v *Vulnerability) Affects(pkg Package) bool {
+	if v.Withdrawn != nil {
+		return false
+	}
+	for _, affected := range v.affecting(pkg) {
+		if affected.affects(pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// FixedVersions returns the versions that fix the vulnerability in the
+// ranges affecting a package, in range order.
+func (v *Vulnerability) FixedVersions(pkg Package) []string {
+	var fixed []string
+	for _, affected := range v.affecting(pkg) {
+		for _, r := range affected.Ranges {
+			if r.Type == "GIT" {
+				continue
+			}
+			for _, event := range r.Events {
+				if event.Fixed != "" && !contains(fixed, event.Fixed) {
+					fixed = append(fixed, event.Fixed)
+				}
+			}
+		}
+	}
+	return fixed
+}
+
+// affecting returns the affected entries for the package's ecosystem and
+// name.
+func (v *Vulnerability) affecting(pkg Package) []Affected {
+	var entries []Affected
+	for _, affected := range v.Affected {
+		if ecosystemMatches(affected.Package.Ecosystem, pkg.Ecosystem) &&
+			normalizeName(ecosystemBase(pkg.Ecosystem), affected.Package.Name) == normalizeName(ecosystemBase(pkg.Ecosystem), pkg.Name) {
+			entries = append(entries, affected)
+		}
+	}
+	return entries
+}
+
+func (a Affected) affects(pkg Package) bool {
+	compare := versionComparator(ecosystemBase(pkg.Ecosystem))
+	for _, version := range a.Versions {
+		if compare(version, pkg.Version) == 0 {
+			return true
+		}
+	}
+	for _, r := range a.Ranges {
+		switch r.Type {
+		case "SEMVER":
+			if r.affects(pkg.Version, compareSemver) {
+				return true
+			}
+		case "ECOSYSTEM":
+			if r.affects(pkg.Version, compare) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// affects evaluates the events of a range in version order, as described by
+// the OSV schema: a version is affected after an introduced event until a
+// fixed event at or below it, or a last_affected event below it.
+func (r Range) affects(version string, compare func(a, b string) int) bool {
+	events := make([]Event, 0, len(r.Events))
+	for _, event := range r.Events {
+		if event.Limit == "" {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].version(), events[j].version()
+		if a == "0" || b == "0" {
+			return a == "0" && b != "0"
+		}
+		return compare(a, b) < 0
+	})
+
+	affected := false
+	for _, event := range events {
+		switch {
+		case event.Introduced != "":
+			if event.Introduced == "0" || compare(version, event.Introduced) >= 0 {
+				affected = true
+			}
+		case event.Fixed != "":
+			if compare(version, event.Fixed) >= 0 {
+				affected = false
+			}
+		case event.LastAffected != "":
+			if compare(version, event.LastAffected) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+// ecosystemBase returns an ecosystem without its release: Debian for
+// Debian:12.
+func ecosystemBase(ecosystem string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return base
+}
+
+// ecosystemRelease returns the distribution release of an ecosystem, the
+// first suffix segment that starts with a digit, without a leading "v":
+// 3.20 for Alpine:v3.20 and 22.04 for Ubuntu:Pro:22.04:LTS.
+func ecosystemRelease(ecosystem string) string {
+	segments := strings.Split(ecosystem, ":")
+	for _, segment := range segments[1:] {
+		segment = strings.TrimPrefix(segment, "v")
+		if segment != "" && segment[0] >= '0' && segment[0] <= '9' {
+			return segment
+		}
+	}
+	return ""
+}
+
+// ecosystemMatches reports whether an entry's ecosystem covers a package's.
+// Releases only restrict the match when both sides name one; the entry's
+// release may be less precise, so Alpine:v3.20 covers Alpine:3.20.3.
+func ecosystemMatches(entry, pkg string) bool {
+	if !strings.EqualFold(ecosystemBase(entry), ecosystemBase(pkg)) {
+		return false
+	}
+	entryRelease, pkgRelease := ecosystemRelease(entry), ecosystemRelease(pkg)
+	if entryRelease == "" || pkgRelease == "" {
+		return true
+	}
+	return pkgRelease == entryRelease || strings.HasPrefix(pkgRelease, entryRelease+".")
+}
+
+// normalizeName returns the form package names are compared in. Python
+// names follow PEP 503; other ecosystems compare names as they are.
+func normalizeName(ecosystem, name string) string {
+	if !strings.EqualFold(ecosystem, "PyPI") {
+		return name
+	}
+	name = strings.ToLower(name)
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	}), "-")
+}
+
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package vulndb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Severity is a qualitative severity rating, ordered from unknown to
+// critical.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityNone
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = []string{"unknown", "none", "low", "medium", "high", "critical"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return severityNames[0]
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity parses a severity rating. The ratings of GitHub advisories
+// ("moderate"), Red Hat ("important") and Ubuntu ("negligible") are
+// accepted too.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "moderate":
+		return SeverityMedium, nil
+	case "important":
+		return SeverityHigh, nil
+	case "negligible":
+		return SeverityLow, nil
+	}
+	for i, name := range severityNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return Severity(i), nil
+		}
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity %q (supported: %s)", s, strings.Join(severityNames[1:], ", "))
+}
+
+// scoreSeverity rates a CVSS base score.
+func scoreSeverity(score float64) Severity {
+	switch {
+	case score == 0:
+		return SeverityNone
+	case score < 4:
+		return SeverityLow
+	case score < 7:
+		return SeverityMedium
+	case score < 9:
+		return SeverityHigh
+	}
+	return SeverityCritical
+}
+
+// Rating returns the severity of the vulnerability for a package and its
+// CVSS base score, or 0 when there is none. CVSS v3 and v2 vectors are
+// scored, preferring scores specific to the package; CVSS v4 vectors are
+// not, so entries with only those fall back to the rating of the database
+// they come from.
+func (v *Vulnerability) Rating(pkg Package) (Severity, float64) {
+	var infos []SeverityInfo
+	for _, affected := range v.affecting(pkg) {
+		infos = append(infos, affected.Severity...)
+	}
+	infos = append(infos, v.Severity...)
+
+	for _, scoreType := range []string{"CVSS_V3", "CVSS_V2"} {
+		for _, info := range infos {
+			if info.Type != scoreType {
+				continue
+			}
+			if score, err := cvssScore(info.Score); err == nil {
+				return scoreSeverity(score), score
+			}
+		}
+	}
+	for _, info := range infos {
+		if !strings.HasPrefix(info.Type, "CVSS_") {
+			if severity, err := ParseSeverity(info.Score); err == nil {
+				return severity, 0
+			}
+		}
+	}
+	for _, affected := range v.affecting(pkg) {
+		if severity, ok := affected.DatabaseSpecific["severity"].(string); ok {
+			if rating, err := ParseSeverity(severity); err == nil {
+				return rating, 0
+			}
+		}
+	}
+	if severity, ok := v.DatabaseSpecific["severity"].(string); ok {
+		if rating, err := ParseSeverity(severity); err == nil {
+			return rating, 0
+		}
+	}
+	return SeverityUnknown, 0
+}
+
+// cvssScore computes the base score of a CVSS v3.x or v2 vector.
+func cvssScore(vector string) (float64, error) {
+	metrics := make(map[string]string)
+	parts := strings.Split(vector, "/")
+	version := "2.0"
+	if strings.HasPrefix(parts[0], "CVSS:") {
+		version = strings.TrimPrefix(parts[0], "CVSS:")
+		parts = parts[1:]
+	}
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS vector %q", vector)
+		}
+		metrics[key] = value
+	}
+	switch version {
+	case "3.0", "3.1":
+		return cvss3Score(metrics, vector)
+	case "2.0":
+		return cvss2Score(metrics, vector)
+	}
+	return 0, fmt.Errorf("unsupported CVSS version %s", version)
+}
+
+// cvssWeight looks up the weight of a metric value.
+func cvssWeight(metrics map[string]string, metric string, weights map[string]float64, vector string) (float64, error) {
+	weight, ok := weights[metrics[metric]]
+	if !ok {
+		return 0, fmt.Errorf("invalid CVSS vector %q: bad %s", vector, metric)
+	}
+	return weight, nil
+}
+
+func cvss3Score(metrics map[string]string, vector string) (float64, error) {
+	changed := metrics["S"] == "C"
+	if metrics["S"] != "C" && metrics["S"] != "U" {
+		return 0, fmt.Errorf("invalid CVSS vector %q: bad S", vector)
+	}
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	impactWeights := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	var w [7]float64
+	for i, metric := range []struct {
+		name    string
+		weights map[string]float64
+	}{
+		{"AV", map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}},
+		{"AC", map[string]float64{"L": 0.77, "H": 0.44}},
+		{"PR", privileges},
+		{"UI", map[string]float64{"N": 0.85, "R": 0.62}},
+		{"C", impactWeights},
+		{"I", impactWeights},
+		{"A", impactWeights},
+	} {
+		weight, err := cvssWeight(metrics, metric.name, metric.weights, vector)
+		if err != nil {
+			return 0, err
+		}
+		w[i] = weight
+	}
+
+	iss := 1 - (1-w[4])*(1-w[5])*(1-w[6])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	exploitability := 8.22 * w[0] * w[1] * w[2] * w[3]
+	if impact <= 0 {
+		return 0, nil
+	}
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp rounds up to one decimal as specified by CVSS v3.1, avoiding
+// floating point artifacts.
+func roundUp(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+func cvss2Score(metrics map[string]string, vector string) (float64, error) {
+	impactWeights := map[string]float64{"N": 0, "P": 0.275, "C": 0.660}
+	var w [6]float64
+	for i, metric := range []struct {
+		name    string
+		weights map[string]float64
+	}{
+		{"AV", map[string]float64{"L": 0.395, "A": 0.646, "N": 1}},
+		{"AC", map[string]float64{"H": 0.35, "M": 0.61, "L": 0.71}},
+		{"Au", map[string]float64{"M": 0.45, "S": 0.56, "N": 0.704}},
+		{"C", impactWeights},
+		{"I", impactWeights},
+		{"A", impactWeights},
+	} {
+		weight, err := cvssWeight(metrics, metric.name, metric.weights, vector)
+		if err != nil {
+			return 0, err
+		}
+		w[i] = weight
+	}
+
+	impact := 10.41 * (1 - (1-w[3])*(1-w[4])*(1-w[5]))
+	exploitability := 20 * w[0] * w[1] * w[2]
+	f := 1.176
+	if impact == 0 {
+		f = 0
+	}
+	score := (0.6*impact + 0.4*exploitability - 1.5) * f
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(score, 'f', 1, 64), 64)
+	return rounded, nil
+}
@@ -0,0 +1,502 @@
+package vulndb
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/MChorfa/TraceSync/internal/semver"
+)
+
+// CompareVersions orders two versions of a package of an ecosystem, such
+// as PyPI or Debian, returning -1, 0 or 1.
+func CompareVersions(ecosystem, a, b string) int {
+	return versionComparator(ecosystem)(a, b)
+}
+
+// versionComparator returns the version ordering of an ecosystem. Unknown
+// ecosystems use a generic ordering of numeric and alphabetic segments.
+func versionComparator(ecosystem string) func(a, b string) int {
+	switch ecosystem {
+	case "Go", "npm", "crates.io", "Hex", "Pub", "NuGet", "SwiftURL":
+		return compareSemver
+	case "PyPI":
+		return comparePEP440
+	case "Debian", "Ubuntu":
+		return compareDpkg
+	case "Alpine":
+		return compareApk
+	case "Red Hat", "AlmaLinux", "Rocky Linux", "openSUSE", "SUSE", "Mageia", "openEuler":
+		return compareRpm
+	}
+	return compareGeneric
+}
+
+// compareSemver orders semantic versions, with or without a leading "v".
+// Versions with fewer than three numbers are padded with zeros; anything
+// else falls back to the generic ordering.
+func compareSemver(a, b string) int {
+	va, errA := parseLooseSemver(a)
+	vb, errB := parseLooseSemver(b)
+	if errA != nil || errB != nil {
+		return compareGeneric(a, b)
+	}
+	return semver.Compare(va, vb)
+}
+
+func parseLooseSemver(s string) (semver.Version, error) {
+	s = strings.TrimPrefix(s, "v")
+	if v, err := semver.Parse(s); err == nil {
+		return v, nil
+	}
+	core, suffix := s, ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, suffix = s[:i], s[i:]
+	}
+	for strings.Count(core, ".") < 2 {
+		core += ".0"
+	}
+	return semver.Parse(core + suffix)
+}
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?` +
+	`(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?$`)
+
+// pep440Version is the sort key of a Python version.
+type pep440Version struct {
+	epoch   *big.Int
+	release []*big.Int
+	// pre ranks the pre-release: -1 for a development release without one,
+	// 0 to 2 for a, b and rc, and 3 for none
+	pre       int
+	preNumber *big.Int
+	// post and dev are nil when absent
+	post, dev *big.Int
+}
+
+func parsePEP440(s string) (pep440Version, bool) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return pep440Version{}, false
+	}
+	v := pep440Version{epoch: number(m[1]), pre: 3, preNumber: number("")}
+	for _, part := range strings.Split(m[2], ".") {
+		v.release = append(v.release, number(part))
+	}
+	// Trailing zeros do not count: 1.0 == 1.0.0
+	for len(v.release) > 1 && v.release[len(v.release)-1].Sign() == 0 {
+		v.release = v.release[:len(v.release)-1]
+	}
+	switch m[3] {
+	case "a", "alpha":
+		v.pre = 0
+	case "b", "beta":
+		v.pre = 1
+	case "c", "rc", "pre", "preview":
+		v.pre = 2
+	}
+	if m[3] != "" {
+		v.preNumber = number(m[4])
+	}
+	switch {
+	case m[5] != "":
+		v.post = number(m[5])
+	case m[6] != "":
+		v.post = number(m[7])
+	}
+	if m[8] != "" {
+		v.dev = number(m[9])
+		if m[3] == "" && v.post == nil {
+			v.pre = -1
+		}
+	}
+	return v, true
+}
+
+// comparePEP440 orders Python versions as described in PEP 440. Local
+// version labels are ignored.
+func comparePEP440(a, b string) int {
+	va, okA := parsePEP440(a)
+	vb, okB := parsePEP440(b)
+	if !okA || !okB {
+		return compareGeneric(a, b)
+	}
+	if c := va.epoch.Cmp(vb.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(va.release) || i < len(vb.release); i++ {
+		x, y := big.NewInt(0), big.NewInt(0)
+		if i < len(va.release) {
+			x = va.release[i]
+		}
+		if i < len(vb.release) {
+			y = vb.release[i]
+		}
+		if c := x.Cmp(y); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(va.pre, vb.pre); c != 0 {
+		return c
+	}
+	if c := va.preNumber.Cmp(vb.preNumber); c != 0 {
+		return c
+	}
+	// No post-release sorts first, no development release last
+	if c := compareOptional(va.post, vb.post, -1); c != 0 {
+		return c
+	}
+	return compareOptional(va.dev, vb.dev, 1)
+}
+
+// compareOptional compares optional numbers; absent sorts below present
+// ones when absent is -1 and above them when it is 1.
+func compareOptional(a, b *big.Int, absent int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return absent
+	case b == nil:
+		return -absent
+	}
+	return a.Cmp(b)
+}
+
+// compareDpkg orders Debian versions, [epoch:]upstream[-revision], as dpkg
+// does.
+func compareDpkg(a, b string) int {
+	epochA, upstreamA, revisionA := splitDpkgVersion(a)
+	epochB, upstreamB, revisionB := splitDpkgVersion(b)
+	if c := number(epochA).Cmp(number(epochB)); c != 0 {
+		return c
+	}
+	if c := compareDpkgPart(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareDpkgPart(revisionA, revisionB)
+}
+
+func splitDpkgVersion(v string) (epoch, upstream, revision string) {
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		epoch, v = v[:i], v[i+1:]
+	}
+	if i := strings.LastIndexByte(v, '-'); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// compareDpkgPart is dpkg's verrevcmp: non-digit runs compare character by
+// character with letters before other characters and "~" before anything,
+// even the end; digit runs compare numerically.
+func compareDpkgPart(a, b string) int {
+	order := func(s string, i int) int {
+		if i >= len(s) {
+			return 0
+		}
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			return 0
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			return int(c)
+		case c == '~':
+			return -1
+		}
+		return int(c) + 256
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isDigit(a[i]) || j < len(b) && !isDigit(b[j]) {
+			if c := compareInts(order(a, i), order(b, j)); c != 0 {
+				return c
+			}
+			i++
+			j++
+		}
+		startA, startB := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		if c := number(a[startA:i]).Cmp(number(b[startB:j])); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareRpm orders rpm versions, [epoch:]version[-release], as rpm does.
+func compareRpm(a, b string) int {
+	epochA, versionA, releaseA := splitRpmVersion(a)
+	epochB, versionB, releaseB := splitRpmVersion(b)
+	if c := number(epochA).Cmp(number(epochB)); c != 0 {
+		return c
+	}
+	if c := rpmvercmp(versionA, versionB); c != 0 {
+		return c
+	}
+	if releaseA == "" || releaseB == "" {
+		return 0
+	}
+	return rpmvercmp(releaseA, releaseB)
+}
+
+func splitRpmVersion(v string) (epoch, version, release string) {
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		epoch, v = v[:i], v[i+1:]
+	}
+	if i := strings.LastIndexByte(v, '-'); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// rpmvercmp compares alternating alphabetic and numeric segments; numeric
+// segments are newer than alphabetic ones, "~" sorts before anything and
+// "^" after the end but before any other segment.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	isAlnum := func(c byte) bool {
+		return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	for len(a) > 0 || len(b) > 0 {
+		for len(a) > 0 && !isAlnum(a[0]) && a[0] != '~' && a[0] != '^' {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isAlnum(b[0]) && b[0] != '~' && b[0] != '^' {
+			b = b[1:]
+		}
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case !strings.HasPrefix(a, "^"):
+				return 1
+			case !strings.HasPrefix(b, "^"):
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+
+		numeric := isDigit(a[0])
+		segment := func(s string) (string, string) {
+			i := 0
+			for i < len(s) && (numeric && isDigit(s[i]) || !numeric && isAlnum(s[i]) && !isDigit(s[i])) {
+				i++
+			}
+			return s[:i], s[i:]
+		}
+		var segA, segB string
+		segA, a = segment(a)
+		segB, b = segment(b)
+		if segB == "" {
+			// Numeric segments are newer than alphabetic ones
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+		var c int
+		if numeric {
+			c = number(segA).Cmp(number(segB))
+		} else {
+			c = strings.Compare(segA, segB)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// apkSuffixes ranks the suffixes of Alpine versions: pre-release suffixes
+// sort before the release, the others after it.
+var apkSuffixes = map[string]int{"alpha": -4, "beta": -3, "pre": -2, "rc": -1, "cvs": 1, "svn": 2, "git": 3, "hg": 4, "p": 5}
+
+// apkVersion is the sort key of an Alpine version such as 1.2.3a_rc1-r2.
+type apkVersion struct {
+	numbers  []*big.Int
+	letter   string
+	suffixes [][2]*big.Int
+	revision *big.Int
+}
+
+var apkPattern = regexp.MustCompile(`^(\d+(?:\.\d+)*)([a-z]?)((?:_[a-z]+\d*)*)(?:-r(\d+))?$`)
+
+func parseApk(s string) (apkVersion, bool) {
+	m := apkPattern.FindStringSubmatch(s)
+	if m == nil {
+		return apkVersion{}, false
+	}
+	v := apkVersion{letter: m[2], revision: number(m[4])}
+	for _, part := range strings.Split(m[1], ".") {
+		v.numbers = append(v.numbers, number(part))
+	}
+	for _, suffix := range strings.Split(m[3], "_")[1:] {
+		name := strings.TrimRightFunc(suffix, func(r rune) bool { return r >= '0' && r <= '9' })
+		rank, ok := apkSuffixes[name]
+		if !ok {
+			return apkVersion{}, false
+		}
+		v.suffixes = append(v.suffixes, [2]*big.Int{big.NewInt(int64(rank)), number(suffix[len(name):])})
+	}
+	return v, true
+}
+
+// compareApk orders Alpine package versions as apk does.
+func compareApk(a, b string) int {
+	va, okA := parseApk(a)
+	vb, okB := parseApk(b)
+	if !okA || !okB {
+		return compareGeneric(a, b)
+	}
+	for i := 0; i < len(va.numbers) || i < len(vb.numbers); i++ {
+		// A version with more numbers is newer: 1.2.1 > 1.2
+		switch {
+		case i >= len(va.numbers):
+			return -1
+		case i >= len(vb.numbers):
+			return 1
+		}
+		if c := va.numbers[i].Cmp(vb.numbers[i]); c != 0 {
+			return c
+		}
+	}
+	if c := strings.Compare(va.letter, vb.letter); c != 0 {
+		return c
+	}
+	for i := 0; i < len(va.suffixes) || i < len(vb.suffixes); i++ {
+		x, y := [2]*big.Int{big.NewInt(0), big.NewInt(0)}, [2]*big.Int{big.NewInt(0), big.NewInt(0)}
+		if i < len(va.suffixes) {
+			x = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			y = vb.suffixes[i]
+		}
+		if c := x[0].Cmp(y[0]); c != 0 {
+			return c
+		}
+		if c := x[1].Cmp(y[1]); c != 0 {
+			return c
+		}
+	}
+	return va.revision.Cmp(vb.revision)
+}
+
+// compareGeneric splits versions into runs of digits and of letters,
+// ignoring separators. Numbers compare numerically and sort after words;
+// when one version runs out, a remaining word makes it a pre-release
+// (1.0-beta < 1.0) and a remaining number a later release (1.0 < 1.0.1).
+func compareGeneric(a, b string) int {
+	ta, tb := genericTokens(a), genericTokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			if isDigit(tb[i][0]) {
+				return -1
+			}
+			return 1
+		case i >= len(tb):
+			if isDigit(ta[i][0]) {
+				return 1
+			}
+			return -1
+		}
+		x, y := ta[i], tb[i]
+		switch {
+		case isDigit(x[0]) && isDigit(y[0]):
+			if c := number(x).Cmp(number(y)); c != 0 {
+				return c
+			}
+		case isDigit(x[0]):
+			return 1
+		case isDigit(y[0]):
+			return -1
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func genericTokens(v string) []string {
+	var tokens []string
+	v = strings.ToLower(v)
+	for i := 0; i < len(v); {
+		j := i
+		switch {
+		case isDigit(v[i]):
+			for j < len(v) && isDigit(v[j]) {
+				j++
+			}
+		case v[i] >= 'a' && v[i] <= 'z':
+			for j < len(v) && v[j] >= 'a' && v[j] <= 'z' {
+				j++
+			}
+		default:
+			i++
+			continue
+		}
+		tokens = append(tokens, v[i:j])
+		i = j
+	}
+	return tokens
+}
+
+// number parses a run of digits, which may exceed 64 bits in dates and
+// snapshots. An empty run is zero.
+func number(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return big.NewInt(0)
+	}
+	return n
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
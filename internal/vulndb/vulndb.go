@@ -0,0 +1,277 @@
+package vulndb
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/utils"
+)
+
+// indexFile and entriesDir are the index and the directory of entries
+// inside a database directory.
+const (
+	indexFile  = "index.json"
+	entriesDir = "osv"
+)
+
+// DB is a local mirror of OSV entries. Each entry is stored as its own
+// file; an index maps packages to the entries that mention them, so lookups
+// never read the whole database. It works fully offline: data only arrives
+// through Import.
+type DB struct {
+	dir string
+
+	mu    sync.Mutex
+	index *index
+}
+
+type index struct {
+	// Packages maps "<ecosystem>/<name>" to entry ids. Entries that no
+	// longer mention a package are filtered out when they are read.
+	Packages map[string][]string `json:"packages"`
+	// Modified records when each stored entry was last modified.
+	Modified map[string]time.Time `json:"modified"`
+}
+
+// ImportResult counts the entries an import added, replaced with a newer
+// modification and skipped as already present.
+type ImportResult struct {
+	Added     int
+	Updated   int
+	Unchanged int
+}
+
+// DefaultPath returns the database directory used when none is configured:
+// $TRACESYNC_VULNDB if set, otherwise vulndb in the user's TraceSync config
+// directory.
+func DefaultPath() (string, error) {
+	if path := os.Getenv("TRACESYNC_VULNDB"); path != "" {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(configDir, "tracesync", "vulndb"), nil
+}
+
+// Open returns the database stored in dir. The directory is created by the
+// first import.
+func Open(dir string) *DB {
+	return &DB{dir: dir}
+}
+
+// Path returns the database directory.
+func (db *DB) Path() string {
+	return db.dir
+}
+
+// Exists reports whether data has been imported into the database.
+func (db *DB) Exists() bool {
+	_, err := os.Stat(filepath.Join(db.dir, indexFile))
+	return err == nil
+}
+
+// Import loads OSV entries from a directory of JSON files, searched
+// recursively, or from a zip archive such as the per-ecosystem all.zip
+// dumps of osv.dev. Entries replace stored ones with the same id unless
+// those are at least as recent.
+func (db *DB) Import(source string) (ImportResult, error) {
+	var result ImportResult
+	if err := os.MkdirAll(filepath.Join(db.dir, entriesDir), 0755); err != nil {
+		return result, fmt.Errorf("failed to create vulnerability database: %w", err)
+	}
+	lock, err := utils.LockFile(filepath.Join(db.dir, ".lock"))
+	if err != nil {
+		return result, err
+	}
+	defer lock.Unlock()
+
+	idx, err := db.readIndex()
+	if err != nil {
+		return result, err
+	}
+	add := func(name string, data []byte) error {
+		var vuln Vulnerability
+		if err := json.Unmarshal(data, &vuln); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if vuln.ID == "" {
+			return fmt.Errorf("%s is not an OSV entry: it has no id", name)
+		}
+
+		modified, known := idx.Modified[vuln.ID]
+		switch {
+		case known && !vuln.Modified.After(modified):
+			result.Unchanged++
+			return nil
+		case known:
+			result.Updated++
+		default:
+			result.Added++
+		}
+		if err := utils.WriteFileAtomic(db.entryPath(vuln.ID), data, 0644); err != nil {
+			return err
+		}
+		idx.Modified[vuln.ID] = vuln.Modified
+		for _, affected := range vuln.Affected {
+			key := packageKey(affected.Package.Ecosystem, affected.Package.Name)
+			if !contains(idx.Packages[key], vuln.ID) {
+				idx.Packages[key] = append(idx.Packages[key], vuln.ID)
+			}
+		}
+		return nil
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if info.IsDir() {
+		err = importDir(source, add)
+	} else {
+		err = importZip(source, add)
+	}
+	// Entries written before a failure are kept, and indexed
+	if writeErr := db.writeIndex(idx); err == nil {
+		err = writeErr
+	}
+	return result, err
+}
+
+func importDir(dir string, add func(name string, data []byte) error) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return add(path, data)
+	})
+}
+
+func importZip(path string, add func(name string, data []byte) error) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(file.Name), ".json") {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s in %s: %w", file.Name, path, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s in %s: %w", file.Name, path, err)
+		}
+		if err := add(file.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup returns the vulnerabilities affecting a package version. Withdrawn
+// entries are skipped.
+func (db *DB) Lookup(pkg Package) ([]*Vulnerability, error) {
+	db.mu.Lock()
+	if db.index == nil {
+		idx, err := db.readIndex()
+		if err != nil {
+			db.mu.Unlock()
+			return nil, err
+		}
+		db.index = idx
+	}
+	ids := db.index.Packages[packageKey(pkg.Ecosystem, pkg.Name)]
+	db.mu.Unlock()
+
+	var vulns []*Vulnerability
+	for _, id := range ids {
+		data, err := os.ReadFile(db.entryPath(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vulnerability %s: %w", id, err)
+		}
+		var vuln Vulnerability
+		if err := json.Unmarshal(data, &vuln); err != nil {
+			return nil, fmt.Errorf("failed to parse vulnerability %s: %w", id, err)
+		}
+		if vuln.Affects(pkg) {
+			vulns = append(vulns, &vuln)
+		}
+	}
+	return vulns, nil
+}
+
+// readIndex loads the index. A missing index is an empty database.
+func (db *DB) readIndex() (*index, error) {
+	idx := &index{Packages: make(map[string][]string), Modified: make(map[string]time.Time)}
+	content, err := os.ReadFile(filepath.Join(db.dir, indexFile))
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vulnerability database index: %w", err)
+	}
+	if err := json.Unmarshal(content, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse vulnerability database index: %w", err)
+	}
+	if idx.Packages == nil {
+		idx.Packages = make(map[string][]string)
+	}
+	if idx.Modified == nil {
+		idx.Modified = make(map[string]time.Time)
+	}
+	return idx, nil
+}
+
+func (db *DB) writeIndex(idx *index) error {
+	encoded, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vulnerability database index: %w", err)
+	}
+	if err := utils.WriteFileAtomic(filepath.Join(db.dir, indexFile), encoded, 0644); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	db.index = nil
+	db.mu.Unlock()
+	return nil
+}
+
+// entryPath returns the file an entry is stored in. Ids such as
+// ALSA-2024:1234 contain characters that are not portable in file names.
+func (db *DB) entryPath(id string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id)
+	return filepath.Join(db.dir, entriesDir, strings.TrimLeft(name, ".")+".json")
+}
+
+// packageKey is the index key of a package: its ecosystem without release,
+// so Debian:11 and Debian:12 entries share a key, and its normalized name.
+func packageKey(ecosystem, name string) string {
+	base := ecosystemBase(ecosystem)
+	return strings.ToLower(base) + "/" + normalizeName(base, name)
+}
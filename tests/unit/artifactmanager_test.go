@@ -1,8 +1,12 @@
 package unit
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/MChorfa/TraceSync/internal/artifactmanager"
@@ -97,3 +101,522 @@ func TestValidateArtifact(t *testing.T) {
 		t.Errorf("Expected no error for valid artifact, but got: %v", err)
 	}
 }
+
+func TestTagArtifactKeepsPerArtifactMetadata(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create two artifacts that share a directory
+	weightsPath := filepath.Join(tempDir, "weights.bin")
+	tokenizerPath := filepath.Join(tempDir, "tokenizer.json")
+	for _, path := range []string{weightsPath, tokenizerPath} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test artifact: %v", err)
+		}
+	}
+
+	if err := artifactmanager.TagArtifact(weightsPath, map[string]string{"stage": "train"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(tokenizerPath, map[string]string{"stage": "release"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+
+	weights, err := artifactmanager.GetArtifactMetadata(weightsPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	tokenizer, err := artifactmanager.GetArtifactMetadata(tokenizerPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+
+	if weights.Name != "weights.bin" || weights.Tags["stage"] != "train" {
+		t.Errorf("Unexpected weights metadata: %+v", weights)
+	}
+	if tokenizer.Name != "tokenizer.json" || tokenizer.Tags["stage"] != "release" {
+		t.Errorf("Unexpected tokenizer metadata: %+v", tokenizer)
+	}
+}
+
+func TestMigrateDescriptor(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, name := range []string{"weights.bin", "eval.csv", "requirements.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test artifact: %v", err)
+		}
+	}
+
+	// Write a descriptor in the legacy shared layout
+	legacy := `name: weights.bin
+version: "2.0"
+created_at: 2024-01-01T00:00:00Z
+updated_at: 2024-01-01T00:00:00Z
+tags:
+  owner: vision
+`
+	metadataPath := filepath.Join(tempDir, artifactmanager.DescriptorFileName)
+	if err := os.WriteFile(metadataPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy descriptor: %v", err)
+	}
+
+	if err := artifactmanager.MigrateDescriptor(tempDir); err != nil {
+		t.Fatalf("MigrateDescriptor failed: %v", err)
+	}
+
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		t.Fatalf("Failed to read migrated descriptor: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "artifacts:") {
+		t.Errorf("Expected migrated descriptor to use the per-artifact layout, got:\n%s", data)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(filepath.Join(tempDir, "weights.bin"))
+	if err != nil {
+		t.Fatalf("Failed to read migrated metadata: %v", err)
+	}
+	if metadata.Name != "weights.bin" || metadata.Version != "2.0" || metadata.Tags["owner"] != "vision" {
+		t.Errorf("Unexpected migrated metadata: %+v", metadata)
+	}
+
+	// The legacy entry has no digests, which validation reports
+	err = artifactmanager.ValidateArtifact(filepath.Join(tempDir, "weights.bin"))
+	if !errors.Is(err, artifactmanager.ErrDigestMissing) {
+		t.Errorf("Expected ErrDigestMissing, got: %v", err)
+	}
+
+	// Files that were never tagged do not inherit the entry
+	for _, name := range []string{"eval.csv", "requirements.txt"} {
+		if _, err := artifactmanager.GetArtifactMetadata(filepath.Join(tempDir, name)); err == nil {
+			t.Errorf("Expected %s to stay untagged", name)
+		}
+	}
+}
+
+func TestValidateArtifactDetectsDrift(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("original weights"), 0644); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"owner": "vision"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if metadata.Size != int64(len("original weights")) {
+		t.Errorf("Expected size %d, got %d", len("original weights"), metadata.Size)
+	}
+	if len(metadata.Digests[artifactmanager.DigestSHA256]) != 64 {
+		t.Errorf("Expected a sha256 digest, got %q", metadata.Digests[artifactmanager.DigestSHA256])
+	}
+
+	// Swap the artifact under the existing descriptor
+	if err := os.WriteFile(artifactPath, []byte("swapped weights!"), 0644); err != nil {
+		t.Fatalf("Failed to modify test artifact: %v", err)
+	}
+	err = artifactmanager.ValidateArtifact(artifactPath)
+	if !errors.Is(err, artifactmanager.ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got: %v", err)
+	}
+
+	// Editing tags must not record the swapped content under the same version
+	err = artifactmanager.TagArtifact(artifactPath, map[string]string{"owner": "other"})
+	if !errors.Is(err, artifactmanager.ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch when tagging, got: %v", err)
+	}
+	if err := artifactmanager.ValidateArtifact(artifactPath); !errors.Is(err, artifactmanager.ErrDigestMismatch) {
+		t.Errorf("Expected validation to keep failing, got: %v", err)
+	}
+
+	// A new version records the new content and archives the old digests
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.1.0"}); err != nil {
+		t.Fatalf("Tagging a new version failed: %v", err)
+	}
+	if err := artifactmanager.ValidateArtifact(artifactPath); err != nil {
+		t.Errorf("Expected the new version to validate, got: %v", err)
+	}
+	updated, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if len(updated.History) != 1 || updated.History[0].Digests[artifactmanager.DigestSHA256] != metadata.Digests[artifactmanager.DigestSHA256] {
+		t.Errorf("Expected the original digest in the history, got: %+v", updated.History)
+	}
+	if updated.Tags["owner"] != "vision" {
+		t.Errorf("Expected the failed edit to change nothing, got owner %q", updated.Tags["owner"])
+	}
+
+	// Retagging records the current content under the same version
+	if err := os.WriteFile(artifactPath, []byte("retrained weights"), 0644); err != nil {
+		t.Fatalf("Failed to modify test artifact: %v", err)
+	}
+	if err := artifactmanager.EditArtifact(artifactPath, artifactmanager.MetadataEdit{Retag: true}); err != nil {
+		t.Fatalf("Retag failed: %v", err)
+	}
+	if err := artifactmanager.ValidateArtifact(artifactPath); err != nil {
+		t.Errorf("Expected the retagged artifact to validate, got: %v", err)
+	}
+}
+
+func TestManageArtifactMetadata(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+
+	// Load tags from a file
+	tagsPath := filepath.Join(tempDir, "tags.yaml")
+	if err := os.WriteFile(tagsPath, []byte("owner: vision\nstage: train\nrelease: 1.10\n"), 0644); err != nil {
+		t.Fatalf("Failed to write tags file: %v", err)
+	}
+	tags, err := artifactmanager.ReadTagsFile(tagsPath)
+	if err != nil {
+		t.Fatalf("ReadTagsFile failed: %v", err)
+	}
+	if tags["release"] != "1.10" {
+		t.Errorf("Expected release '1.10', got '%s'", tags["release"])
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, tags); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+
+	// Remove a tag and set core fields
+	if err := artifactmanager.UntagArtifact(artifactPath, []string{"stage"}); err != nil {
+		t.Fatalf("UntagArtifact failed: %v", err)
+	}
+	if err := artifactmanager.UntagArtifact(artifactPath, []string{"missing"}); err == nil {
+		t.Errorf("Expected error when removing a missing tag, but got nil")
+	}
+	if err := artifactmanager.SetArtifactFields(artifactPath, map[string]string{"name": "Vision Model"}); err != nil {
+		t.Fatalf("SetArtifactFields failed: %v", err)
+	}
+	if err := artifactmanager.SetArtifactFields(artifactPath, map[string]string{"created_at": "now"}); err == nil {
+		t.Errorf("Expected error when setting an unsupported field, but got nil")
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if metadata.Name != "Vision Model" {
+		t.Errorf("Expected name 'Vision Model', got '%s'", metadata.Name)
+	}
+	if _, ok := metadata.Tags["stage"]; ok {
+		t.Errorf("Expected tag 'stage' to be removed")
+	}
+	if metadata.Tags["owner"] != "vision" {
+		t.Errorf("Expected owner 'vision', got '%s'", metadata.Tags["owner"])
+	}
+
+	// A combined edit is stored completely or not at all
+	err = artifactmanager.EditArtifact(artifactPath, artifactmanager.MetadataEdit{
+		Tags:   map[string]string{"reviewed": "yes"},
+		Remove: []string{"missing"},
+	})
+	if err == nil {
+		t.Errorf("Expected error when removing a missing tag, but got nil")
+	}
+	unchanged, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if _, ok := unchanged.Tags["reviewed"]; ok || unchanged.Revision != metadata.Revision {
+		t.Errorf("Expected a failed edit to change nothing, got revision %d and tags %v", unchanged.Revision, unchanged.Tags)
+	}
+	err = artifactmanager.EditArtifact(artifactPath, artifactmanager.MetadataEdit{
+		Tags:   map[string]string{"reviewed": "yes"},
+		Remove: []string{"owner"},
+		Fields: map[string]string{"type": "model"},
+	})
+	if err != nil {
+		t.Fatalf("EditArtifact failed: %v", err)
+	}
+	edited, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if edited.Tags["reviewed"] != "yes" || edited.Tags["owner"] != "" || edited.Type != "model" || edited.Revision != metadata.Revision+1 {
+		t.Errorf("Expected the edit applied in one revision, got %+v", edited)
+	}
+}
+
+func TestConcurrentTagArtifact(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		artifactPath := filepath.Join(tempDir, fmt.Sprintf("shard-%d.bin", i))
+		if err := os.WriteFile(artifactPath, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test artifact: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"owner": "vision"}); err != nil {
+				t.Errorf("TagArtifact failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	artifacts, err := artifactmanager.ListArtifacts(tempDir)
+	if err != nil {
+		t.Fatalf("ListArtifacts failed: %v", err)
+	}
+	if len(artifacts) != writers {
+		t.Errorf("Expected %d descriptor entries, got %d", writers, len(artifacts))
+	}
+}
+
+func TestSaveArtifactMetadataRevisionConflict(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"owner": "vision"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+
+	// Two writers read the same revision
+	first, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	second := first
+
+	first.Tags["stage"] = "train"
+	if err := artifactmanager.SaveArtifactMetadata(artifactPath, first); err != nil {
+		t.Fatalf("SaveArtifactMetadata failed: %v", err)
+	}
+
+	second.Name = "renamed"
+	err = artifactmanager.SaveArtifactMetadata(artifactPath, second)
+	if !errors.Is(err, artifactmanager.ErrRevisionConflict) {
+		t.Errorf("Expected ErrRevisionConflict, got: %v", err)
+	}
+
+	stored, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if stored.Revision != first.Revision+1 || stored.Name != "model.bin" {
+		t.Errorf("Unexpected stored metadata after conflict: %+v", stored)
+	}
+}
+
+func TestBundleArtifact(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a model bundle with shards, config and tokenizer
+	bundlePath := filepath.Join(tempDir, "model")
+	files := map[string]string{
+		"config.json":              `{"layers": 12}`,
+		"shards/model-00001.bin":   "shard one",
+		"shards/model-00002.bin":   "shard two",
+		"tokenizer/tokenizer.json": `{"vocab": []}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(bundlePath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create bundle directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create bundle file: %v", err)
+		}
+	}
+
+	if err := artifactmanager.TagArtifact(bundlePath, map[string]string{"owner": "vision"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+	metadata, err := artifactmanager.GetArtifactMetadata(bundlePath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if len(metadata.Manifest) != len(files) {
+		t.Errorf("Expected %d manifest entries, got %d", len(files), len(metadata.Manifest))
+	}
+	if metadata.Manifest[0].Path != "config.json" || metadata.Manifest[0].Digests[artifactmanager.DigestSHA256] == "" {
+		t.Errorf("Unexpected first manifest entry: %+v", metadata.Manifest[0])
+	}
+	if err := artifactmanager.ValidateArtifact(bundlePath); err != nil {
+		t.Fatalf("ValidateArtifact failed: %v", err)
+	}
+
+	// Replace a shard and add an unexpected file
+	if err := os.WriteFile(filepath.Join(bundlePath, "shards", "model-00002.bin"), []byte("shard 2!!"), 0644); err != nil {
+		t.Fatalf("Failed to modify bundle file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundlePath, "extra.bin"), []byte("extra"), 0644); err != nil {
+		t.Fatalf("Failed to add bundle file: %v", err)
+	}
+	err = artifactmanager.ValidateArtifact(bundlePath)
+	if !errors.Is(err, artifactmanager.ErrDigestMismatch) {
+		t.Fatalf("Expected ErrDigestMismatch, got: %v", err)
+	}
+	for _, expected := range []string{"shards/model-00002.bin", "unexpected file extra.bin"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected drift error to mention %q, got: %v", expected, err)
+		}
+	}
+}
+
+func TestVersionBumpHistory(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights v1"), 0644); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"stage": "dev"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+	first, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if first.Version != artifactmanager.DefaultVersion {
+		t.Errorf("Expected new artifacts to start at %s, got %s", artifactmanager.DefaultVersion, first.Version)
+	}
+
+	// The SBOM of the first version is regenerated after the bump
+	sbomPath := filepath.Join(tempDir, "model.bin-sbom.json")
+	if err := os.WriteFile(sbomPath, []byte(`{"bomFormat": "CycloneDX", "version": "1.0.0"}`), 0644); err != nil {
+		t.Fatalf("Failed to create test SBOM: %v", err)
+	}
+
+	// Retrain and bump; the previous digest stays retrievable
+	if err := os.WriteFile(artifactPath, []byte("weights v2"), 0644); err != nil {
+		t.Fatalf("Failed to update test artifact: %v", err)
+	}
+	version, err := artifactmanager.BumpVersion(artifactPath, "minor")
+	if err != nil {
+		t.Fatalf("BumpVersion failed: %v", err)
+	}
+	if version != "1.1.0" {
+		t.Errorf("Expected version 1.1.0, got %s", version)
+	}
+	if err := artifactmanager.ValidateArtifact(artifactPath); err != nil {
+		t.Errorf("Expected bumped artifact to validate, got %v", err)
+	}
+
+	previous, err := artifactmanager.GetArtifactVersion(artifactPath, "1.0.0")
+	if err != nil {
+		t.Fatalf("GetArtifactVersion failed: %v", err)
+	}
+	if previous.Digests["sha256"] != first.Digests["sha256"] || previous.Tags["stage"] != "dev" {
+		t.Errorf("Expected archived version to keep its digest and tags, got %+v", previous)
+	}
+	if _, err := artifactmanager.GetArtifactVersion(artifactPath, "3.0.0"); !errors.Is(err, artifactmanager.ErrVersionNotFound) {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+
+	// The archived version keeps a copy of the SBOM it had
+	if err := os.WriteFile(sbomPath, []byte(`{"bomFormat": "CycloneDX", "version": "1.1.0"}`), 0644); err != nil {
+		t.Fatalf("Failed to regenerate test SBOM: %v", err)
+	}
+	snapshotPath, err := artifactmanager.GetVersionSBOM(artifactPath, "1.0.0")
+	if err != nil {
+		t.Fatalf("GetVersionSBOM failed: %v", err)
+	}
+	if snapshot, err := os.ReadFile(snapshotPath); err != nil || !strings.Contains(string(snapshot), `"1.0.0"`) {
+		t.Errorf("Expected the SBOM of version 1.0.0 at %s, got %s (%v)", snapshotPath, snapshot, err)
+	}
+	if filepath.Base(snapshotPath) != "model.bin@1.0.0-sbom.json" {
+		t.Errorf("Unexpected snapshot path %s", snapshotPath)
+	}
+	if _, err := artifactmanager.GetVersionSBOM(artifactPath, "3.0.0"); !errors.Is(err, artifactmanager.ErrVersionNotFound) {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+	if err := os.WriteFile(snapshotPath, []byte(`{"bomFormat": "CycloneDX", "version": "9.9.9"}`), 0644); err != nil {
+		t.Fatalf("Failed to modify snapshot: %v", err)
+	}
+	if _, err := artifactmanager.GetVersionSBOM(artifactPath, "1.0.0"); !errors.Is(err, artifactmanager.ErrDigestMismatch) {
+		t.Errorf("Expected a modified snapshot to be refused, got %v", err)
+	}
+
+	// The version tag sets the version and must be a newer semantic version
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.1"}); err == nil {
+		t.Errorf("Expected an invalid semantic version to be rejected")
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.5"}); err == nil {
+		t.Errorf("Expected an older version to be rejected")
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "2.0.0"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+	current, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if _, ok := current.Tags["version"]; ok {
+		t.Errorf("Expected version not to be stored as a tag")
+	}
+	if len(current.History) != 2 || current.History[1].Version != "1.1.0" {
+		t.Errorf("Expected versions 1.0.0 and 1.1.0 in history, got %+v", current.History)
+	}
+
+	// History cannot be rewritten through SaveArtifactMetadata
+	current.History = nil
+	if err := artifactmanager.SaveArtifactMetadata(artifactPath, current); err != nil {
+		t.Fatalf("SaveArtifactMetadata failed: %v", err)
+	}
+	saved, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if len(saved.History) != 2 {
+		t.Errorf("Expected history to be preserved, got %+v", saved.History)
+	}
+}
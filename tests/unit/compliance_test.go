@@ -1,12 +1,24 @@
 package unit
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/MChorfa/TraceSync/internal/artifactmanager"
 	"github.com/MChorfa/TraceSync/internal/compliance"
+	"github.com/MChorfa/TraceSync/internal/policy"
+	"github.com/MChorfa/TraceSync/internal/vulndb"
 )
 
 func TestGenerateSBOM(t *testing.T) {
@@ -24,10 +36,6 @@ func TestGenerateSBOM(t *testing.T) {
 	}
 
 	// Create mock metadata
-	metadata := artifactmanager.ArtifactMetadata{
-		Name:    "test-artifact",
-		Version: "1.0.0",
-	}
 	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.0"}); err != nil {
 		t.Fatalf("Failed to create test metadata: %v", err)
 	}
@@ -43,7 +51,87 @@ func TestGenerateSBOM(t *testing.T) {
 		t.Errorf("SBOM file was not created")
 	}
 
-	// TODO: Add more detailed checks on the content of the SBOM file
+	// Check the SBOM is a CycloneDX 1.5 document describing the artifact
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+	var bom struct {
+		BOMFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Metadata     struct {
+			Component struct {
+				BOMRef  string `json:"bom-ref"`
+				Name    string `json:"name"`
+				Version string `json:"version"`
+				Hashes  []struct {
+					Algorithm string `json:"alg"`
+					Content   string `json:"content"`
+				} `json:"hashes"`
+			} `json:"component"`
+		} `json:"metadata"`
+		Dependencies []struct {
+			Ref string `json:"ref"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("Failed to parse SBOM: %v", err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" {
+		t.Errorf("Expected a CycloneDX 1.5 document, got %s %s", bom.BOMFormat, bom.SpecVersion)
+	}
+	if !strings.HasPrefix(bom.SerialNumber, "urn:uuid:") {
+		t.Errorf("Expected a UUID serial number, got %q", bom.SerialNumber)
+	}
+	component := bom.Metadata.Component
+	if component.Name != "test-artifact" || component.Version != "1.0.0" {
+		t.Errorf("Expected the artifact as metadata component, got %s@%s", component.Name, component.Version)
+	}
+	if len(component.Hashes) != 1 || component.Hashes[0].Algorithm != "SHA-256" {
+		t.Errorf("Expected the artifact's SHA-256 hash, got %+v", component.Hashes)
+	}
+	if len(bom.Dependencies) == 0 || bom.Dependencies[0].Ref != component.BOMRef {
+		t.Errorf("Expected the artifact's dependencies to reference its bom-ref, got %+v", bom.Dependencies)
+	}
+}
+
+func TestGenerateLegacySBOM(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "test-artifact")
+	if err := os.WriteFile(artifactPath, []byte("test artifact content"), 0644); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+
+	sbomPath, err := compliance.GenerateSBOMAs(artifactPath, compliance.FormatLegacy)
+	if err != nil {
+		t.Fatalf("GenerateSBOMAs failed: %v", err)
+	}
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+	var legacy map[string]interface{}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		t.Fatalf("Failed to parse SBOM: %v", err)
+	}
+	for _, key := range []string{"name", "version", "description", "components", "created_at"} {
+		if _, ok := legacy[key]; !ok {
+			t.Errorf("Expected legacy SBOM to have %q", key)
+		}
+	}
+	if _, ok := legacy["bomFormat"]; ok {
+		t.Errorf("Expected legacy SBOM not to be CycloneDX")
+	}
 }
 
 func TestPerformComplianceCheck(t *testing.T) {
@@ -82,3 +170,1762 @@ func TestPerformComplianceCheck(t *testing.T) {
 		t.Errorf("Expected compliance check to pass, but it failed: %v", err)
 	}
 }
+
+func TestGenerateSPDXSBOM(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A bundle whose go.mod gives the artifact a dependency
+	artifactPath := filepath.Join(tempDir, "test-artifact")
+	if err := os.MkdirAll(artifactPath, 0755); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+	goMod := "module example.com/server\n\nrequire github.com/spf13/cobra v1.8.1\n"
+	if err := os.WriteFile(filepath.Join(artifactPath, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatalf("Failed to create go.mod: %v", err)
+	}
+	tags := map[string]string{"version": "1.0.0", "license": "Apache-2.0 OR MIT"}
+	if err := artifactmanager.TagArtifact(artifactPath, tags); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+
+	sbomPath, err := compliance.GenerateSBOMAs(artifactPath, compliance.FormatSPDXJSON)
+	if err != nil {
+		t.Fatalf("GenerateSBOMAs failed: %v", err)
+	}
+	if filepath.Base(sbomPath) != "test-artifact-sbom.spdx.json" {
+		t.Errorf("Unexpected SPDX path %s", sbomPath)
+	}
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+	var doc struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DocumentNamespace string `json:"documentNamespace"`
+		Packages          []struct {
+			SPDXID          string `json:"SPDXID"`
+			Name            string `json:"name"`
+			LicenseDeclared string `json:"licenseDeclared"`
+		} `json:"packages"`
+		Relationships []struct {
+			From string `json:"spdxElementId"`
+			Type string `json:"relationshipType"`
+			To   string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to parse SBOM: %v", err)
+	}
+	if doc.SPDXVersion != "SPDX-2.3" || doc.DocumentNamespace == "" {
+		t.Errorf("Expected an SPDX 2.3 document with a namespace, got %q %q", doc.SPDXVersion, doc.DocumentNamespace)
+	}
+	if len(doc.Packages) == 0 || doc.Packages[0].Name != "test-artifact" || doc.Packages[0].LicenseDeclared != "Apache-2.0 OR MIT" {
+		t.Fatalf("Expected the artifact package with its license expression, got %+v", doc.Packages)
+	}
+	relationships := make(map[string]bool)
+	for _, relationship := range doc.Relationships {
+		relationships[relationship.From+" "+relationship.Type] = true
+	}
+	if !relationships["SPDXRef-DOCUMENT DESCRIBES"] || !relationships[doc.Packages[0].SPDXID+" DEPENDS_ON"] || !relationships[doc.Packages[0].SPDXID+" CONTAINS"] {
+		t.Errorf("Expected DESCRIBES, DEPENDS_ON and CONTAINS relationships, got %+v", doc.Relationships)
+	}
+
+	// The tag-value form is written alongside and satisfies the compliance check
+	sbomPath, err = compliance.GenerateSBOMAs(artifactPath, compliance.FormatSPDXTagValue)
+	if err != nil {
+		t.Fatalf("GenerateSBOMAs failed: %v", err)
+	}
+	data, err = os.ReadFile(sbomPath)
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+	for _, line := range []string{"SPDXVersion: SPDX-2.3", "PackageName: test-artifact", "PackageLicenseDeclared: Apache-2.0 OR MIT"} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("Expected tag-value SBOM to contain %q", line)
+		}
+	}
+	if err := compliance.PerformComplianceCheck(artifactPath); err != nil {
+		t.Errorf("Expected compliance check to pass with an SPDX SBOM, got %v", err)
+	}
+
+	// Malformed expressions are not written as SPDX expressions
+	for _, license := range []string{"MIT AND", "AND OR", "( MIT"} {
+		if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"license": license}); err != nil {
+			t.Fatalf("Failed to update test metadata: %v", err)
+		}
+		sbomPath, err := compliance.GenerateSBOMAs(artifactPath, compliance.FormatSPDXJSON)
+		if err != nil {
+			t.Fatalf("GenerateSBOMAs failed: %v", err)
+		}
+		data, err := os.ReadFile(sbomPath)
+		if err != nil {
+			t.Fatalf("Failed to read SBOM: %v", err)
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("Failed to parse SBOM: %v", err)
+		}
+		if declared := doc.Packages[0].LicenseDeclared; !strings.HasPrefix(declared, "LicenseRef-") {
+			t.Errorf("Expected %q to become a LicenseRef, got %q", license, declared)
+		}
+
+		sbomPath, err = compliance.GenerateSBOMAs(artifactPath, compliance.FormatCycloneDX)
+		if err != nil {
+			t.Fatalf("GenerateSBOMAs failed: %v", err)
+		}
+		data, err = os.ReadFile(sbomPath)
+		if err != nil {
+			t.Fatalf("Failed to read SBOM: %v", err)
+		}
+		var bom struct {
+			Metadata struct {
+				Component struct {
+					Licenses []struct {
+						License *struct {
+							Name string `json:"name"`
+						} `json:"license"`
+						Expression string `json:"expression"`
+					} `json:"licenses"`
+				} `json:"component"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &bom); err != nil {
+			t.Fatalf("Failed to parse SBOM: %v", err)
+		}
+		licenses := bom.Metadata.Component.Licenses
+		if len(licenses) != 1 || licenses[0].Expression != "" || licenses[0].License == nil || licenses[0].License.Name != license {
+			t.Errorf("Expected %q as a license name, got %+v", license, licenses)
+		}
+	}
+}
+
+func TestGenerateSBOMFromGoModules(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A bundle holding the source of a Go inference server
+	bundlePath := filepath.Join(tempDir, "server")
+	if err := os.MkdirAll(bundlePath, 0755); err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	goMod := `module example.com/server
+
+go 1.22
+
+require (
+	github.com/spf13/cobra v1.8.1
+	golang.org/x/sys v0.26.0 // indirect
+	example.com/internal/lib v0.1.0
+)
+
+replace github.com/spf13/cobra v1.8.1 => github.com/fork/cobra v1.8.2
+replace example.com/internal/lib => ./lib
+`
+	goSum := `github.com/fork/cobra v1.8.2 h1:forkhash=
+github.com/fork/cobra v1.8.2/go.mod h1:modhash=
+golang.org/x/sys v0.26.0 h1:syshash=
+`
+	files := map[string]string{"go.mod": goMod, "go.sum": goSum, "main.go": "package main\n"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(bundlePath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	// Includes must stay inside the artifact
+	if err := os.WriteFile(filepath.Join(tempDir, "outside.txt"), []byte("leaked==1.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to create outside.txt: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(bundlePath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(bundlePath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err := compliance.BuildSBOM(bundlePath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+
+	components := make(map[string]compliance.Component)
+	for _, component := range sbom.Components {
+		components[component.Name] = component
+	}
+	if len(components) != 4 {
+		t.Errorf("Expected the main module and 3 requirements, got %+v", sbom.Components)
+	}
+	if main := components["example.com/server"]; main.Type != compliance.ComponentApplication {
+		t.Errorf("Expected the main module as application, got %+v", main)
+	}
+	fork := components["github.com/fork/cobra"]
+	if fork.Version != "v1.8.2" || fork.PURL != "pkg:golang/github.com/fork/cobra@v1.8.2" || fork.Hashes["h1"] != "h1:forkhash=" {
+		t.Errorf("Expected the replacement module with its h1 hash, got %+v", fork)
+	}
+	if fork.Type != compliance.ComponentLibrary {
+		t.Errorf("Expected modules to be libraries, got %s", fork.Type)
+	}
+	if sys := components["golang.org/x/sys"]; sys.Properties["tracesync:go:indirect"] != "true" || sys.Hashes["h1"] != "h1:syshash=" {
+		t.Errorf("Expected an indirect module with its h1 hash, got %+v", sys)
+	}
+	if local := components["example.com/internal/lib"]; local.Version != "" || local.Properties["tracesync:go:replace-dir"] != "./lib" {
+		t.Errorf("Expected a local replacement without version, got %+v", local)
+	}
+	if _, ok := components["example-dependency"]; ok {
+		t.Errorf("Expected no placeholder components")
+	}
+
+	// The artifact depends on the main module, which depends on its requirements
+	if len(sbom.Dependencies) != 2 || sbom.Dependencies[0].DependsOn[0] != "pkg:golang/example.com/server" || len(sbom.Dependencies[1].DependsOn) != 3 {
+		t.Errorf("Unexpected dependency graph %+v", sbom.Dependencies)
+	}
+}
+
+func TestGenerateSBOMFromPythonLockFiles(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A bundle holding the lock files of a Python training project
+	bundlePath := filepath.Join(tempDir, "trainer")
+	if err := os.MkdirAll(bundlePath, 0755); err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	requirements := `# Runtime dependencies
+-r requirements-base.txt
+-r ../outside.txt
+--requirement=/etc/outside.txt
+-r missing.txt
+NumPy==1.26.4 \
+    --hash=sha256:numpyhash
+scikit_learn>=1.4 ; python_version >= "3.10"
+./local/featurizer
+git+https://github.com/example/utils.git@v1.2#egg=trainer_utils
+https://files.example/wheels/tokenizers-0.19.1-cp311-none-any.whl --hash=sha256:tokenizershash
+not a requirement!
+`
+	poetryLock := `[[package]]
+name = "requests"
+version = "2.32.3"
+description = "Python HTTP for Humans."
+files = [
+    {file = "requests-2.32.3-py3-none-any.whl", hash = "sha256:wheelhash"},
+    {file = "requests-2.32.3.tar.gz", hash = "sha256:requestshash"},
+]
+
+[package.dependencies]
+urllib3 = ">=1.21.1,<3"
+
+[[package]]
+name = "urllib3"
+version = "2.2.2"
+description = "HTTP library"
+files = []
+`
+	pipfileLock := `{
+  "default": {"torch": {"version": "==2.3.1", "hashes": ["sha256:torchhash"]}},
+  "develop": {"pytest": {"version": "==8.2.2", "hashes": []}}
+}`
+	uvLock := `version = 1
+
+[[package]]
+name = "trainer"
+version = "0.1.0"
+source = { editable = "." }
+dependencies = [{ name = "pandas" }]
+
+[[package]]
+name = "pandas"
+version = "2.2.2"
+source = { registry = "https://pypi.org/simple" }
+sdist = { url = "https://files.example/pandas-2.2.2.tar.gz", hash = "sha256:pandashash" }
+`
+	files := map[string]string{
+		"requirements.txt":      requirements,
+		"requirements-base.txt": "pyyaml==6.0.1\n",
+		"poetry.lock":           poetryLock,
+		"Pipfile.lock":          pipfileLock,
+		"uv.lock":               uvLock,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(bundlePath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	if err := artifactmanager.TagArtifact(bundlePath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(bundlePath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err := compliance.BuildSBOM(bundlePath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+
+	components := make(map[string]compliance.Component)
+	for _, component := range sbom.Components {
+		components[component.Name] = component
+	}
+	if numpy := components["numpy"]; numpy.Version != "1.26.4" || numpy.PURL != "pkg:pypi/numpy@1.26.4" || numpy.Hashes["sha256"] != "numpyhash" {
+		t.Errorf("Expected a pinned requirement with its hash, got %+v", numpy)
+	}
+	if pyyaml := components["pyyaml"]; pyyaml.Version != "6.0.1" {
+		t.Errorf("Expected included requirements, got %+v", pyyaml)
+	}
+	if _, ok := components["leaked"]; ok || len(sbom.Warnings) != 4 {
+		t.Errorf("Expected escaping and missing includes and invalid lines to be skipped with warnings, got %v", sbom.Warnings)
+	}
+	for name, url := range map[string]string{
+		"featurizer":    "./local/featurizer",
+		"trainer-utils": "git+https://github.com/example/utils.git@v1.2#egg=trainer_utils",
+		"tokenizers":    "https://files.example/wheels/tokenizers-0.19.1-cp311-none-any.whl",
+	} {
+		component, ok := components[name]
+		if !ok || component.Properties["tracesync:python:url"] != url || component.Properties["tracesync:unpinned"] == "" {
+			t.Errorf("Expected an unpinned URL requirement %s for %s, got %+v", name, url, component)
+		}
+	}
+	if tokenizers := components["tokenizers"]; tokenizers.Hashes["sha256"] != "tokenizershash" {
+		t.Errorf("Expected the wheel hash, got %+v", tokenizers)
+	}
+	for _, name := range []string{"git", "https", "not"} {
+		if _, ok := components[name]; ok {
+			t.Errorf("Expected no component named %s", name)
+		}
+	}
+	if sklearn := components["scikit-learn"]; sklearn.Version != "" || sklearn.Properties["tracesync:unpinned"] != ">=1.4" {
+		t.Errorf("Expected an unpinned requirement, got %+v", sklearn)
+	}
+	if requests := components["requests"]; requests.Version != "2.32.3" || requests.Hashes["sha256"] != "requestshash" {
+		t.Errorf("Expected the Poetry package with its sdist hash, got %+v", requests)
+	}
+	if torch := components["torch"]; torch.Version != "2.3.1" || torch.Hashes["sha256"] != "torchhash" {
+		t.Errorf("Expected the Pipenv package, got %+v", torch)
+	}
+	if pytest := components["pytest"]; pytest.Properties["tracesync:python:group"] != "develop" {
+		t.Errorf("Expected the Pipenv development package, got %+v", pytest)
+	}
+	if trainer := components["trainer"]; trainer.Type != compliance.ComponentApplication {
+		t.Errorf("Expected the uv project as application, got %+v", trainer)
+	}
+	if pandas := components["pandas"]; pandas.PURL != "pkg:pypi/pandas@2.2.2" || pandas.Hashes["sha256"] != "pandashash" {
+		t.Errorf("Expected the uv package with its hash, got %+v", pandas)
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, dependency := range sbom.Dependencies {
+		dependsOn[dependency.Ref] = dependency.DependsOn
+	}
+	if deps := dependsOn["pkg:pypi/requests@2.32.3"]; len(deps) != 1 || deps[0] != "pkg:pypi/urllib3@2.2.2" {
+		t.Errorf("Expected requests to depend on urllib3, got %v", deps)
+	}
+	if deps := dependsOn["pkg:pypi/trainer@0.1.0"]; len(deps) != 1 || deps[0] != "pkg:pypi/pandas@2.2.2" {
+		t.Errorf("Expected the project to depend on pandas, got %v", deps)
+	}
+
+	// The unpinned requirement fails the compliance check
+	if err := compliance.GenerateSBOM(bundlePath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+	if err := compliance.PerformComplianceCheck(bundlePath); err == nil {
+		t.Errorf("Expected the compliance check to fail on an unpinned requirement")
+	}
+	if err := os.WriteFile(filepath.Join(bundlePath, "requirements.txt"), []byte("numpy==1.26.4\n"), 0644); err != nil {
+		t.Fatalf("Failed to update requirements: %v", err)
+	}
+	// The check reads the written SBOM, so it must be generated again
+	if err := compliance.PerformComplianceCheck(bundlePath); err == nil {
+		t.Errorf("Expected the compliance check to evaluate the SBOM written before the change")
+	}
+	if err := compliance.GenerateSBOM(bundlePath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+	if err := compliance.PerformComplianceCheck(bundlePath); err != nil {
+		t.Errorf("Expected the compliance check to pass with pinned requirements: %v", err)
+	}
+}
+
+func TestGenerateSBOMFromGoBinary(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// The test binary itself is a Go executable with build info
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate test binary: %v", err)
+	}
+	data, err := os.ReadFile(executable)
+	if err != nil {
+		t.Fatalf("Failed to read test binary: %v", err)
+	}
+	binaryPath := filepath.Join(tempDir, "server")
+	if err := os.WriteFile(binaryPath, data, 0755); err != nil {
+		t.Fatalf("Failed to create binary: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(binaryPath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(binaryPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err := compliance.BuildSBOM(binaryPath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+
+	components := make(map[string]compliance.Component)
+	for _, component := range sbom.Components {
+		components[component.Name] = component
+	}
+	main, ok := components["github.com/MChorfa/TraceSync"]
+	if !ok || main.Type != compliance.ComponentApplication {
+		t.Fatalf("Expected the main module as application, got %+v", sbom.Components)
+	}
+	if main.Properties["tracesync:go:version"] != runtime.Version() {
+		t.Errorf("Expected Go version %s, got %+v", runtime.Version(), main.Properties)
+	}
+	if _, ok := main.Properties["tracesync:go:build:CGO_ENABLED"]; !ok {
+		t.Errorf("Expected build settings as properties, got %+v", main.Properties)
+	}
+	toml := components["github.com/pelletier/go-toml/v2"]
+	if toml.PURL != "pkg:golang/github.com/pelletier/go-toml/v2@v2.2.3" || !strings.HasPrefix(toml.Hashes["h1"], "h1:") {
+		t.Errorf("Expected linked modules with their h1 hash, got %+v", toml)
+	}
+	if toml.Properties["tracesync:location"] != "server" {
+		t.Errorf("Expected the binary as location, got %+v", toml.Properties)
+	}
+}
+
+// tarFile is an entry of a tar archive built by buildTar.
+type tarFile struct {
+	name    string
+	content string
+}
+
+func buildTar(t *testing.T, files []tarFile, compress bool) []byte {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(file.content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatalf("Failed to close gzip: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateSBOMFromContainerImage(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A Debian based image saved by docker save: the base layer installs
+	// libc6 and the second layer adds curl and removes a package
+	baseLayer := buildTar(t, []tarFile{
+		{"etc/os-release", "PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nID=debian\nVERSION_ID=\"12\"\n"},
+		{"var/lib/dpkg/status", "Package: libc6\nStatus: install ok installed\nArchitecture: amd64\nSource: glibc\nVersion: 2.36-9\nDescription: GNU C Library\n shared libraries\n\nPackage: wget\nStatus: install ok installed\nArchitecture: amd64\nVersion: 1.21.3-1\n"},
+	}, true)
+	appLayer := buildTar(t, []tarFile{
+		{"var/lib/dpkg/status", "Package: libc6\nStatus: install ok installed\nArchitecture: amd64\nSource: glibc\nVersion: 2.36-9\n\nPackage: wget\nStatus: deinstall ok config-files\nArchitecture: amd64\nVersion: 1.21.3-1\n\nPackage: curl\nStatus: install ok installed\nArchitecture: amd64\nVersion: 7.88.1-10\n"},
+		{"srv/model.bin", "weights"},
+	}, false)
+	config := `{"os":"linux","architecture":"amd64","rootfs":{"type":"layers","diff_ids":["sha256:base","sha256:app"]}}`
+	manifest := `[{"Config":"blobs/sha256/cfg","RepoTags":["registry.example.com/team/model-server:1.2"],"Layers":["blobs/sha256/l1","blobs/sha256/l2"]}]`
+	archive := buildTar(t, []tarFile{
+		{"blobs/sha256/cfg", config},
+		{"blobs/sha256/l1", string(baseLayer)},
+		{"blobs/sha256/l2", string(appLayer)},
+		{"manifest.json", manifest},
+	}, false)
+	imagePath := filepath.Join(tempDir, "model-server.tar")
+	if err := os.WriteFile(imagePath, archive, 0644); err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(imagePath, map[string]string{"version": "1.2.0"}); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(imagePath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err := compliance.BuildSBOM(imagePath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+
+	components := make(map[string]compliance.Component)
+	for _, component := range sbom.Components {
+		components[component.Name] = component
+	}
+	if len(components) != 4 {
+		t.Errorf("Expected the image, its OS and 2 packages, got %+v", sbom.Components)
+	}
+	image := components["registry.example.com/team/model-server"]
+	if image.Type != compliance.ComponentContainer || image.Version != "1.2" || image.Properties["tracesync:image:id"] != "sha256:cfg" {
+		t.Errorf("Expected the image component, got %+v", image)
+	}
+	if system := components["debian"]; system.Type != compliance.ComponentOperatingSystem || system.Version != "12" {
+		t.Errorf("Expected the operating system, got %+v", system)
+	}
+	libc := components["libc6"]
+	if libc.PURL != "pkg:deb/debian/libc6@2.36-9?arch=amd64&distro=debian-12" || libc.Properties["tracesync:os:source"] != "glibc" {
+		t.Errorf("Expected the libc6 package, got %+v", libc)
+	}
+	if libc.Properties["tracesync:image:layer"] != "sha256:base" {
+		t.Errorf("Expected libc6 from the base layer, got %s", libc.Properties["tracesync:image:layer"])
+	}
+	if curl := components["curl"]; curl.Properties["tracesync:image:layer"] != "sha256:app" {
+		t.Errorf("Expected curl from the second layer, got %+v", curl)
+	}
+	if _, ok := components["wget"]; ok {
+		t.Errorf("Expected removed packages to be skipped")
+	}
+
+	// The same kind of image as an OCI layout directory, based on Alpine
+	layoutPath := filepath.Join(tempDir, "alpine-layout")
+	apkLayer := buildTar(t, []tarFile{
+		{"etc/os-release", "ID=alpine\nVERSION_ID=3.20.0\n"},
+		{"lib/apk/db/installed", "C:Q1abc=\nP:musl\nV:1.2.5-r0\nA:x86_64\nL:MIT\no:musl\n\nP:busybox\nV:1.36.1-r29\nA:x86_64\nL:GPL-2.0-only\n"},
+	}, true)
+	layout := map[string]string{
+		"oci-layout":        `{"imageLayoutVersion":"1.0.0"}`,
+		"index.json":        `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:man","annotations":{"org.opencontainers.image.ref.name":"3.20"}}]}`,
+		"blobs/sha256/man":  `{"schemaVersion":2,"config":{"digest":"sha256:conf"},"layers":[{"digest":"sha256:apk"}]}`,
+		"blobs/sha256/conf": `{"os":"linux","architecture":"amd64","rootfs":{"diff_ids":["sha256:apkdiff"]}}`,
+		"blobs/sha256/apk":  string(apkLayer),
+	}
+	for name, content := range layout {
+		target := filepath.Join(layoutPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			t.Fatalf("Failed to create layout: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	if err := artifactmanager.TagArtifact(layoutPath, map[string]string{"version": "3.20.0"}); err != nil {
+		t.Fatalf("Failed to create test metadata: %v", err)
+	}
+	metadata, err = artifactmanager.GetArtifactMetadata(layoutPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err = compliance.BuildSBOM(layoutPath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+	components = make(map[string]compliance.Component)
+	for _, component := range sbom.Components {
+		components[component.Name] = component
+	}
+	musl := components["musl"]
+	if musl.PURL != "pkg:apk/alpine/musl@1.2.5-r0?arch=x86_64&distro=alpine-3.20.0" || musl.License != "MIT" {
+		t.Errorf("Expected the musl package, got %+v", musl)
+	}
+	if musl.Properties["tracesync:image:layer"] != "sha256:apkdiff" {
+		t.Errorf("Expected musl from the apk layer, got %+v", musl.Properties)
+	}
+	if image := components["alpine-layout"]; image.Type != compliance.ComponentContainer || image.Version != "3.20" {
+		t.Errorf("Expected the image named after the layout, got %+v", sbom.Components)
+	}
+	if _, ok := components["busybox"]; !ok {
+		t.Errorf("Expected the busybox package, got %+v", sbom.Components)
+	}
+}
+
+func TestGenerateMLBOM(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// A tagged training set, an untagged evaluation set and the model
+	files := map[string]string{"train.csv": "id,label\n1,a\n", "eval.csv": "id,label\n2,b\n", "model.bin": "weights"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	trainPath := filepath.Join(tempDir, "train.csv")
+	if err := artifactmanager.TagArtifact(trainPath, map[string]string{"version": "2.0.0", "classification": "internal"}); err != nil {
+		t.Fatalf("Failed to tag dataset: %v", err)
+	}
+	if err := artifactmanager.SetArtifactFields(trainPath, map[string]string{"type": "dataset"}); err != nil {
+		t.Fatalf("Failed to set dataset type: %v", err)
+	}
+
+	modelPath := filepath.Join(tempDir, "model.bin")
+	if err := artifactmanager.TagArtifact(modelPath, map[string]string{
+		"version":                      "1.0.0",
+		"task":                         "text-classification",
+		"architecture":                 "bert-base-uncased",
+		"architecture_family":          "transformer",
+		"approach":                     "supervised",
+		"datasets":                     "train.csv, imagenet",
+		"metric.accuracy":              "0.93",
+		"hyperparameter.learning_rate": "0.001",
+	}); err != nil {
+		t.Fatalf("Failed to tag model: %v", err)
+	}
+	if err := artifactmanager.TrackLineage(modelPath, map[string]string{"dataset": "eval.csv"}); err != nil {
+		t.Fatalf("Failed to track lineage: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(modelPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err := compliance.BuildSBOM(modelPath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+
+	if sbom.Subject.Type != compliance.ComponentMachineLearningModel || sbom.Subject.ModelCard == nil {
+		t.Fatalf("Expected the model as machine-learning-model with a model card, got %+v", sbom.Subject)
+	}
+	card := sbom.Subject.ModelCard
+	if card.Task != "text-classification" || card.Architecture != "bert-base-uncased" || card.Metrics["accuracy"] != "0.93" || card.Hyperparameters["learning_rate"] != "0.001" {
+		t.Errorf("Unexpected model card %+v", card)
+	}
+	if len(card.Datasets) != 3 || card.Datasets[0] != "train.csv@2.0.0" {
+		t.Errorf("Expected the tagged, external and lineage datasets, got %v", card.Datasets)
+	}
+
+	datasets := make(map[string]compliance.Component)
+	for _, component := range sbom.Components {
+		if component.Type == compliance.ComponentData {
+			datasets[component.BOMRef] = component
+		}
+	}
+	train := datasets["train.csv@2.0.0"]
+	trainMetadata, _ := artifactmanager.GetArtifactMetadata(trainPath)
+	if train.Hashes["sha256"] != trainMetadata.Digests["sha256"] || train.Data == nil || train.Data.Classification != "internal" {
+		t.Errorf("Expected the tagged dataset with its digest, got %+v", train)
+	}
+	if eval := datasets["eval.csv"]; len(eval.Hashes["sha256"]) != 64 {
+		t.Errorf("Expected the lineage dataset digested, got %+v", eval)
+	}
+	if external := datasets["imagenet"]; external.Name != "imagenet" || external.Hashes != nil {
+		t.Errorf("Expected the external dataset by name, got %+v", external)
+	}
+
+	// The CycloneDX document carries the model card
+	data, err := compliance.EncodeSBOM(sbom, compliance.FormatCycloneDX)
+	if err != nil {
+		t.Fatalf("EncodeSBOM failed: %v", err)
+	}
+	var bom struct {
+		Metadata struct {
+			Component struct {
+				Type      string `json:"type"`
+				ModelCard struct {
+					ModelParameters struct {
+						Task     string `json:"task"`
+						Datasets []struct {
+							Ref string `json:"ref"`
+						} `json:"datasets"`
+					} `json:"modelParameters"`
+					QuantitativeAnalysis struct {
+						PerformanceMetrics []struct {
+							Type  string `json:"type"`
+							Value string `json:"value"`
+						} `json:"performanceMetrics"`
+					} `json:"quantitativeAnalysis"`
+				} `json:"modelCard"`
+			} `json:"component"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("Failed to parse CycloneDX: %v", err)
+	}
+	model := bom.Metadata.Component
+	if model.Type != "machine-learning-model" || model.ModelCard.ModelParameters.Task != "text-classification" || len(model.ModelCard.ModelParameters.Datasets) != 3 {
+		t.Errorf("Unexpected model component %+v", model)
+	}
+	if metrics := model.ModelCard.QuantitativeAnalysis.PerformanceMetrics; len(metrics) != 1 || metrics[0].Type != "accuracy" {
+		t.Errorf("Unexpected performance metrics %+v", metrics)
+	}
+}
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := map[string]string{
+		"MIT":                                       "MIT",
+		"mit or apache-2.0":                         "mit OR apache-2.0",
+		"MIT AND (Apache-2.0 OR BSD-3-Clause)":      "MIT AND (Apache-2.0 OR BSD-3-Clause)",
+		"(MIT AND Zlib) OR GPL-2.0+":                "MIT AND Zlib OR GPL-2.0+",
+		"GPL-2.0-only WITH Classpath-exception-2.0": "GPL-2.0-only WITH Classpath-exception-2.0",
+		"DocumentRef-spdx:LicenseRef-Custom OR MIT": "DocumentRef-spdx:LicenseRef-Custom OR MIT",
+	}
+	for input, expected := range tests {
+		expression, err := compliance.ParseLicenseExpression(input)
+		if err != nil {
+			t.Errorf("ParseLicenseExpression(%q) failed: %v", input, err)
+			continue
+		}
+		if expression.String() != expected {
+			t.Errorf("ParseLicenseExpression(%q) = %q, expected %q", input, expression.String(), expected)
+		}
+	}
+
+	expression, _ := compliance.ParseLicenseExpression("MIT AND (GPL-2.0-only WITH Classpath-exception-2.0 OR Apache-2.0)")
+	if licenses := expression.Licenses(); len(licenses) != 3 || licenses[1] != "GPL-2.0-only WITH Classpath-exception-2.0" {
+		t.Errorf("Unexpected licenses %v", licenses)
+	}
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT AND AND Apache-2.0", "GPL-2.0 WITH", "Apache License 2.0"} {
+		if _, err := compliance.ParseLicenseExpression(invalid); err == nil {
+			t.Errorf("Expected ParseLicenseExpression(%q) to fail", invalid)
+		}
+	}
+}
+
+func TestLicensePolicy(t *testing.T) {
+	policy := &compliance.LicensePolicy{
+		Allowed:    []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Denied:     []string{"AGPL-3.0-only", "GPL-2.0-only"},
+		Review:     []string{"LGPL-2.1-only"},
+		Strictness: map[string]string{"production": compliance.StrictnessStrict, "development": compliance.StrictnessPermissive},
+	}
+	components := []compliance.Component{
+		{Name: "choice", Version: "1.0", License: "MIT OR AGPL-3.0-only"},
+		{Name: "both", Version: "1.0", License: "MIT AND AGPL-3.0-only"},
+		{Name: "exception", Version: "1.0", License: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{Name: "gpl", Version: "1.0", License: "gpl-2.0-only"},
+		{Name: "lgpl", Version: "1.0", License: "LGPL-2.1-only"},
+		{Name: "unlisted", Version: "1.0", License: "BSD-3-Clause"},
+		{Name: "unlicensed", Version: "1.0"},
+	}
+
+	severities := func(environment string) map[string]string {
+		result := make(map[string]string)
+		for _, finding := range policy.Check(components, environment) {
+			result[strings.Split(finding.Component, "@")[0]] = finding.Severity
+		}
+		return result
+	}
+	expected := map[string]map[string]string{
+		"staging":     {"both": "error", "gpl": "error", "lgpl": "warning", "unlisted": "warning", "unlicensed": "warning"},
+		"production":  {"both": "error", "gpl": "error", "lgpl": "error", "unlisted": "error", "unlicensed": "error"},
+		"development": {"both": "error", "gpl": "error", "lgpl": "warning"},
+	}
+	for environment, want := range expected {
+		got := severities(environment)
+		if len(got) != len(want) {
+			t.Errorf("%s: expected findings %v, got %v", environment, want, got)
+			continue
+		}
+		for name, severity := range want {
+			if got[name] != severity {
+				t.Errorf("%s: expected %s for %s, got %q", environment, severity, name, got[name])
+			}
+		}
+	}
+}
+
+func TestComplianceCheckLicensePolicy(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	policyPath := filepath.Join(tempDir, "license-policy.yaml")
+	policyYAML := `denied: [AGPL-3.0-only]
+review: [GPL-3.0-only]
+strictness:
+  production: strict
+`
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	policy, err := compliance.LoadLicensePolicy(policyPath)
+	if err != nil {
+		t.Fatalf("LoadLicensePolicy failed: %v", err)
+	}
+	defer compliance.SetLicensePolicy(nil, "")
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.0", "license": "MIT OR GPL-3.0-only"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+	if err := compliance.GenerateSBOM(artifactPath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+
+	// MIT can be chosen, so the artifact passes everywhere
+	compliance.SetLicensePolicy(policy, "production")
+	if err := compliance.PerformComplianceCheck(artifactPath); err != nil {
+		t.Errorf("Expected the license choice to pass: %v", err)
+	}
+
+	// Without the choice, review-required licenses fail only in production
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"license": "GPL-3.0-only"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+	if err := compliance.GenerateSBOM(artifactPath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+	compliance.SetLicensePolicy(policy, "staging")
+	if err := compliance.PerformComplianceCheck(artifactPath); err != nil {
+		t.Errorf("Expected a warning only in staging: %v", err)
+	}
+	compliance.SetLicensePolicy(policy, "production")
+	if err := compliance.PerformComplianceCheck(artifactPath); err == nil {
+		t.Errorf("Expected the review-required license to fail in production")
+	}
+
+	if err := os.WriteFile(policyPath, []byte("strictness: {production: lenient}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if _, err := compliance.LoadLicensePolicy(policyPath); err == nil {
+		t.Errorf("Expected an unknown strictness to be rejected")
+	}
+}
+
+func TestComplianceCheckVulnerabilities(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	osvDir := filepath.Join(tempDir, "osv-dump")
+	writeOSVEntries(t, osvDir)
+	db := vulndb.Open(filepath.Join(tempDir, "vulndb"))
+	if _, err := db.Import(osvDir); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	defer compliance.SetVulnerabilityDatabase(nil, 0)
+
+	bundlePath := filepath.Join(tempDir, "service")
+	if err := os.MkdirAll(bundlePath, 0755); err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	requirements := "requests==2.19.1\nnumpy==1.26.4\n"
+	if err := os.WriteFile(filepath.Join(bundlePath, "requirements.txt"), []byte(requirements), 0644); err != nil {
+		t.Fatalf("Failed to create requirements: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(bundlePath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+	if err := compliance.GenerateSBOM(bundlePath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+
+	metadata, err := artifactmanager.GetArtifactMetadata(bundlePath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	sbom, err := compliance.BuildSBOM(bundlePath, metadata)
+	if err != nil {
+		t.Fatalf("BuildSBOM failed: %v", err)
+	}
+	findings, err := compliance.CheckVulnerabilities(db, sbom.Components)
+	if err != nil {
+		t.Fatalf("CheckVulnerabilities failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].ID != "GHSA-j8r2-6x86-q33q" || findings[0].Component != "requests@2.19.1" ||
+		len(findings[0].Fixed) != 1 || findings[0].Fixed[0] != "2.31.0" {
+		t.Fatalf("Expected one finding for requests, got %+v", findings)
+	}
+
+	// The medium-rated vulnerability fails at a medium threshold only
+	compliance.SetVulnerabilityDatabase(db, vulndb.SeverityHigh)
+	if err := compliance.PerformComplianceCheck(bundlePath); err != nil {
+		t.Errorf("Expected a warning only below the threshold: %v", err)
+	}
+	compliance.SetVulnerabilityDatabase(db, vulndb.SeverityMedium)
+	if err := compliance.PerformComplianceCheck(bundlePath); err == nil {
+		t.Errorf("Expected the vulnerability to fail the check")
+	}
+
+	// A configured database without data fails instead of matching nothing
+	compliance.SetVulnerabilityDatabase(vulndb.Open(filepath.Join(tempDir, "missing")), vulndb.SeverityHigh)
+	if err := compliance.PerformComplianceCheck(bundlePath); err == nil || !strings.Contains(err.Error(), "has no data") {
+		t.Errorf("Expected a missing database to fail the check, got %v", err)
+	}
+}
+
+func TestGenerateProvenance(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "example/server")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_RUN_ATTEMPT", "2")
+
+	bundlePath := filepath.Join(tempDir, "server")
+	if err := os.MkdirAll(bundlePath, 0755); err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+	goMod := "module example.com/server\n\nrequire github.com/spf13/cobra v1.8.1\n"
+	goSum := "github.com/spf13/cobra v1.8.1 h1:e5/vxKd/rZsfSJMUX1agtjeTDf+qv1/JdBF8gg5k9ZM=\n"
+	for name, content := range map[string]string{"go.mod": goMod, "go.sum": goSum} {
+		if err := os.WriteFile(filepath.Join(bundlePath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	if err := artifactmanager.TagArtifact(bundlePath, map[string]string{"version": "1.2.0"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+	if err := compliance.GenerateSBOM(bundlePath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+
+	startedOn := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	provenancePath, err := compliance.GenerateProvenance(bundlePath, compliance.ProvenanceOptions{
+		ExternalParameters: map[string]any{"command": "tracesync upload", "flags": map[string]string{"sbom-format": "cyclonedx"}},
+		InternalParameters: map[string]any{"environment": "production"},
+		StartedOn:          startedOn,
+		FinishedOn:         startedOn.Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("GenerateProvenance failed: %v", err)
+	}
+	if provenancePath != filepath.Join(tempDir, "server-provenance.intoto.json") {
+		t.Errorf("Unexpected provenance path %s", provenancePath)
+	}
+
+	data, err := os.ReadFile(provenancePath)
+	if err != nil {
+		t.Fatalf("Failed to read provenance: %v", err)
+	}
+	type descriptor struct {
+		Name   string            `json:"name"`
+		URI    string            `json:"uri"`
+		Digest map[string]string `json:"digest"`
+	}
+	var statement struct {
+		Type          string       `json:"_type"`
+		Subject       []descriptor `json:"subject"`
+		PredicateType string       `json:"predicateType"`
+		Predicate     struct {
+			BuildDefinition struct {
+				BuildType            string         `json:"buildType"`
+				ExternalParameters   map[string]any `json:"externalParameters"`
+				InternalParameters   map[string]any `json:"internalParameters"`
+				ResolvedDependencies []descriptor   `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+			RunDetails struct {
+				Builder struct {
+					ID string `json:"id"`
+				} `json:"builder"`
+				Metadata struct {
+					InvocationID string `json:"invocationId"`
+					StartedOn    string `json:"startedOn"`
+					FinishedOn   string `json:"finishedOn"`
+				} `json:"metadata"`
+				Byproducts []descriptor `json:"byproducts"`
+			} `json:"runDetails"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(data, &statement); err != nil {
+		t.Fatalf("Failed to parse provenance: %v", err)
+	}
+
+	if statement.Type != "https://in-toto.io/Statement/v1" || statement.PredicateType != "https://slsa.dev/provenance/v1" {
+		t.Errorf("Expected an in-toto statement with SLSA v1 provenance, got %s / %s", statement.Type, statement.PredicateType)
+	}
+	metadata, err := artifactmanager.GetArtifactMetadata(bundlePath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if len(statement.Subject) != 3 || statement.Subject[0].Name != "server" || statement.Subject[0].Digest["sha256"] != metadata.Digests["sha256"] {
+		t.Errorf("Expected the bundle and its files as subjects, got %+v", statement.Subject)
+	}
+	if statement.Subject[1].Name != "server/go.mod" || statement.Subject[1].Digest["sha256"] == "" {
+		t.Errorf("Expected the bundle files as subjects, got %+v", statement.Subject[1])
+	}
+
+	definition := statement.Predicate.BuildDefinition
+	if definition.BuildType != compliance.UploadBuildType || definition.ExternalParameters["command"] != "tracesync upload" ||
+		definition.InternalParameters["environment"] != "production" {
+		t.Errorf("Unexpected build definition %+v", definition)
+	}
+	if len(definition.ResolvedDependencies) != 1 || definition.ResolvedDependencies[0].URI != "pkg:golang/github.com/spf13/cobra@v1.8.1" {
+		t.Errorf("Expected the SBOM components as resolved dependencies, got %+v", definition.ResolvedDependencies)
+	}
+
+	run := statement.Predicate.RunDetails
+	if run.Builder.ID != compliance.DefaultBuilderID {
+		t.Errorf("Expected the default builder, got %s", run.Builder.ID)
+	}
+	if run.Metadata.InvocationID != "https://github.com/example/server/actions/runs/42/attempts/2" ||
+		run.Metadata.StartedOn != "2024-05-01T12:00:00Z" || run.Metadata.FinishedOn != "2024-05-01T12:01:00Z" {
+		t.Errorf("Unexpected run metadata %+v", run.Metadata)
+	}
+	if len(run.Byproducts) != 1 || run.Byproducts[0].Name != "server-sbom.json" || run.Byproducts[0].Digest["sha256"] == "" {
+		t.Errorf("Expected the SBOM as byproduct, got %+v", run.Byproducts)
+	}
+}
+
+func TestComplianceCheckPolicies(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	policyDir := filepath.Join(tempDir, "policies")
+	if err := os.Mkdir(policyDir, 0755); err != nil {
+		t.Fatalf("Failed to create policy directory: %v", err)
+	}
+	rules := `rules:
+  - id: licensed-components
+    message: Every component must declare a license
+    expr: sbom.components.all(c, c.license != "")
+  - id: production-provenance
+    message: Production artifacts need provenance
+    expr: environment != "production" || has(provenance.predicate)
+`
+	if err := os.WriteFile(filepath.Join(policyDir, "rules.yaml"), []byte(rules), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	set, err := policy.LoadDir(policyDir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	defer compliance.SetPolicies(nil, "")
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+	if err := compliance.GenerateSBOM(artifactPath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+
+	compliance.SetPolicies(set, "staging")
+	if err := compliance.PerformComplianceCheck(artifactPath); err != nil {
+		t.Errorf("Expected the policies to pass in staging: %v", err)
+	}
+
+	compliance.SetPolicies(set, "production")
+	if err := compliance.PerformComplianceCheck(artifactPath); err == nil {
+		t.Errorf("Expected missing provenance to fail in production")
+	}
+	if _, err := compliance.GenerateProvenance(artifactPath, compliance.ProvenanceOptions{}); err != nil {
+		t.Fatalf("GenerateProvenance failed: %v", err)
+	}
+	if err := compliance.PerformComplianceCheck(artifactPath); err != nil {
+		t.Errorf("Expected the provenance to satisfy the policy: %v", err)
+	}
+
+	// The built-in rules still apply
+	if err := os.Remove(compliance.SBOMPath(artifactPath, "model.bin", compliance.FormatCycloneDX)); err != nil {
+		t.Fatalf("Failed to remove SBOM: %v", err)
+	}
+	if err := compliance.PerformComplianceCheck(artifactPath); err == nil {
+		t.Errorf("Expected the missing SBOM to fail")
+	}
+}
+
+func TestComplianceReport(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+
+	// Without an SBOM the built-in sbom-present rule fails
+	report, err := compliance.CheckCompliance(artifactPath)
+	if err != nil {
+		t.Fatalf("CheckCompliance failed: %v", err)
+	}
+	if report.Passed() || len(report.Issues) != 1 {
+		t.Fatalf("Expected one issue, got %+v", report.Issues)
+	}
+	issue := report.Issues[0]
+	if issue.RuleID != "sbom-present" || issue.Severity != compliance.SeverityError || issue.Message != "SBOM file is missing" {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	var complianceErr *compliance.ComplianceError
+	if err := compliance.PerformComplianceCheck(artifactPath); !errors.As(err, &complianceErr) || len(complianceErr.Report.Issues) != 1 {
+		t.Errorf("Expected a ComplianceError with the report, got %v", err)
+	}
+
+	var jsonReport bytes.Buffer
+	if err := report.Write(&jsonReport, compliance.ReportJSON); err != nil {
+		t.Fatalf("Writing JSON failed: %v", err)
+	}
+	var decoded compliance.Report
+	if err := json.Unmarshal(jsonReport.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON report: %v", err)
+	}
+	if decoded.Name != "model.bin" || len(decoded.Issues) != 1 || decoded.Issues[0].RuleID != "sbom-present" {
+		t.Errorf("Unexpected JSON report: %s", jsonReport.String())
+	}
+
+	var junit bytes.Buffer
+	if err := report.Write(&junit, compliance.ReportJUnit); err != nil {
+		t.Fatalf("Writing JUnit failed: %v", err)
+	}
+	var suites struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Cases    []struct {
+			Name    string `xml:"name,attr"`
+			Failure *struct {
+				Message string `xml:"message,attr"`
+			} `xml:"failure"`
+		} `xml:"testsuite>testcase"`
+	}
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil {
+		t.Fatalf("Invalid JUnit report: %v", err)
+	}
+	if suites.Tests != len(report.Rules) || suites.Failures != 1 {
+		t.Errorf("Expected %d tests with 1 failure, got %d and %d", len(report.Rules), suites.Tests, suites.Failures)
+	}
+	for _, testCase := range suites.Cases {
+		if failed := testCase.Failure != nil; failed != (testCase.Name == "sbom-present") {
+			t.Errorf("Unexpected result for test case %s", testCase.Name)
+		}
+	}
+
+	var sarif bytes.Buffer
+	if err := report.Write(&sarif, compliance.ReportSARIF); err != nil {
+		t.Fatalf("Writing SARIF failed: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid SARIF report: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("Unexpected SARIF report: %s", sarif.String())
+	}
+	result := log.Runs[0].Results[0]
+	if result.Level != "error" || log.Runs[0].Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+		t.Errorf("Unexpected SARIF result: %+v", result)
+	}
+
+	if _, err := compliance.ParseReportFormat("html"); err == nil {
+		t.Errorf("Expected an unsupported report format to be rejected")
+	}
+
+	// With an SBOM the check passes
+	if err := compliance.GenerateSBOM(artifactPath); err != nil {
+		t.Fatalf("GenerateSBOM failed: %v", err)
+	}
+	if err := compliance.PerformComplianceCheck(artifactPath); err != nil {
+		t.Errorf("Expected the check to pass: %v", err)
+	}
+}
+
+func TestLoadSBOM(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "service")
+	if err := os.Mkdir(artifactPath, 0755); err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(artifactPath, "requirements.txt"), []byte("requests==2.31.0\nnumpy==1.26.4\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.2.0", "license": "Apache-2.0"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+
+	for _, format := range []compliance.Format{compliance.FormatCycloneDX, compliance.FormatSPDXJSON, compliance.FormatSPDXTagValue, compliance.FormatLegacy} {
+		sbomPath, err := compliance.GenerateSBOMAs(artifactPath, format)
+		if err != nil {
+			t.Fatalf("GenerateSBOMAs(%s) failed: %v", format, err)
+		}
+		data, err := os.ReadFile(sbomPath)
+		if err != nil {
+			t.Fatalf("Failed to read SBOM: %v", err)
+		}
+		sbom, detected, err := compliance.DecodeSBOM(data)
+		if err != nil {
+			t.Fatalf("DecodeSBOM(%s) failed: %v", format, err)
+		}
+		if detected != format {
+			t.Errorf("Expected format %s, detected %s", format, detected)
+		}
+		if sbom.Name != "service" || sbom.Version != "1.2.0" {
+			t.Errorf("%s: unexpected subject %s %s", format, sbom.Name, sbom.Version)
+		}
+		versions := make(map[string]string)
+		for _, component := range sbom.Components {
+			versions[component.Name] = component.Version
+		}
+		if versions["requests"] != "2.31.0" || versions["numpy"] != "1.26.4" {
+			t.Errorf("%s: unexpected components %v", format, versions)
+		}
+		if format == compliance.FormatLegacy {
+			continue
+		}
+		if sbom.Subject.License != "Apache-2.0" {
+			t.Errorf("%s: expected the subject license, got %q", format, sbom.Subject.License)
+		}
+		for _, component := range sbom.Components {
+			if !strings.HasPrefix(component.PURL, "pkg:pypi/") {
+				t.Errorf("%s: expected a purl for %s, got %q", format, component.Name, component.PURL)
+			}
+		}
+	}
+
+	if _, _, err := compliance.DecodeSBOM([]byte(`{"hello": "world"}`)); err == nil {
+		t.Errorf("Expected an unknown document to be rejected")
+	}
+}
+
+func TestDiffSBOMs(t *testing.T) {
+	old := &compliance.SBOM{Name: "model", Version: "1.0.0", Components: []compliance.Component{
+		{Name: "numpy", Version: "1.9.0", PURL: "pkg:pypi/numpy@1.9.0", License: "BSD-3-Clause"},
+		{Name: "requests", Version: "2.31.0", PURL: "pkg:pypi/requests@2.31.0", License: "Apache-2.0"},
+		{Name: "tqdm", Version: "4.66.1", PURL: "pkg:pypi/tqdm@4.66.1", License: "MIT"},
+		{Name: "libfoo", Version: "2.0", License: "MIT"},
+		{Name: "weights", Type: compliance.ComponentData, Version: "1"},
+	}}
+	new := &compliance.SBOM{Name: "model", Version: "1.1.0", Components: []compliance.Component{
+		{Name: "numpy", Version: "1.10.0", PURL: "pkg:pypi/numpy@1.10.0", License: "BSD-3-Clause"},
+		{Name: "requests", Version: "2.31.0", PURL: "pkg:pypi/requests@2.31.0", License: "Apache-2.0 OR GPL-3.0-only"},
+		{Name: "libfoo", Version: "1.5", License: "LGPL-2.1-only"},
+		{Name: "readline", Version: "8.2", PURL: "pkg:generic/readline@8.2", License: "GPL-3.0-or-later"},
+		{Name: "weights", Type: compliance.ComponentData, Version: "1"},
+	}}
+
+	diff := compliance.DiffSBOMs(old, new)
+	got := make(map[string]string)
+	for _, change := range diff.Changes {
+		got[change.Name] = strings.Join(change.Kinds, ",")
+	}
+	want := map[string]string{
+		"numpy":    "upgraded",
+		"requests": "license",
+		"tqdm":     "removed",
+		"libfoo":   "downgraded,license,copyleft",
+		"readline": "added,copyleft",
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected changes %v, got %v", want, got)
+	}
+	for name, kinds := range want {
+		if got[name] != kinds {
+			t.Errorf("%s: expected %s, got %q", name, kinds, got[name])
+		}
+	}
+	if diff.Old.Version != "1.0.0" || diff.New.Components != 5 {
+		t.Errorf("Unexpected summaries: %+v %+v", diff.Old, diff.New)
+	}
+
+	// A choice with a permissive license does not impose copyleft
+	if compliance.IsCopyleft("Apache-2.0 OR GPL-3.0-only") || !compliance.IsCopyleft("MIT AND GPL-2.0-only") {
+		t.Errorf("Unexpected copyleft classification")
+	}
+
+	kinds, err := compliance.ParseChangeKinds([]string{"copyleft", "Removed"})
+	if err != nil {
+		t.Fatalf("ParseChangeKinds failed: %v", err)
+	}
+	if matching := diff.Matching(kinds); len(matching) != 3 {
+		t.Errorf("Expected 3 copyleft or removed changes, got %v", matching)
+	}
+	if _, err := compliance.ParseChangeKinds([]string{"renamed"}); err == nil {
+		t.Errorf("Expected an unknown change kind to be rejected")
+	}
+}
+
+func TestMergeSBOMs(t *testing.T) {
+	model := &compliance.SBOM{
+		Name: "model", Version: "1.0.0",
+		Subject: compliance.Component{BOMRef: "model@1.0.0", Type: compliance.ComponentMachineLearningModel, Name: "model", Version: "1.0.0"},
+		Components: []compliance.Component{
+			{BOMRef: "pkg:pypi/numpy@1.26.4", Name: "numpy", Version: "1.26.4", PURL: "pkg:pypi/numpy@1.26.4"},
+			{BOMRef: "data/eval.csv", Type: compliance.ComponentData, Name: "data/eval.csv", Hashes: map[string]string{"sha256": "abc"}},
+			{BOMRef: "tokenizer", Name: "tokenizer", Version: "3"},
+		},
+		Dependencies: []compliance.Dependency{
+			{Ref: "model@1.0.0", DependsOn: []string{"pkg:pypi/numpy@1.26.4", "data/eval.csv", "tokenizer"}},
+		},
+	}
+	serving := &compliance.SBOM{
+		Name: "serving", Version: "2.0.0",
+		Subject: compliance.Component{BOMRef: "serving@2.0.0", Type: compliance.ComponentFile, Name: "serving", Version: "2.0.0"},
+		Components: []compliance.Component{
+			{BOMRef: "numpy", Name: "numpy", Version: "1.26.4", PURL: "pkg:pypi/numpy@1.26.4", Hashes: map[string]string{"sha256": "def"}},
+			{BOMRef: "pkg:pypi/scipy@1.11.0", Name: "scipy", Version: "1.11.0", PURL: "pkg:pypi/scipy@1.11.0"},
+			{BOMRef: "pkg:pypi/numpy@1.24.0", Name: "numpy", Version: "1.24.0", PURL: "pkg:pypi/numpy@1.24.0"},
+			// Same bom-ref as the model's tokenizer, but a different component
+			{BOMRef: "tokenizer", Name: "tokenizers", Version: "0.15.0"},
+		},
+		Dependencies: []compliance.Dependency{
+			{Ref: "serving@2.0.0", DependsOn: []string{"pkg:pypi/scipy@1.11.0", "tokenizer"}},
+			{Ref: "pkg:pypi/scipy@1.11.0", DependsOn: []string{"numpy"}},
+		},
+	}
+	// The evaluation dataset is shipped as an artifact of its own
+	eval := &compliance.SBOM{
+		Name: "eval.csv", Version: "1.0.0",
+		Subject: compliance.Component{BOMRef: "eval.csv@1.0.0", Type: compliance.ComponentData, Name: "eval.csv", Version: "1.0.0",
+			License: "CC-BY-4.0", Hashes: map[string]string{"sha256": "abc"}},
+	}
+
+	merged, conflicts, err := compliance.MergeSBOMs("release", "2024.1", []*compliance.SBOM{model, serving, eval})
+	if err != nil {
+		t.Fatalf("MergeSBOMs failed: %v", err)
+	}
+	if merged.Subject.Name != "release" || merged.Subject.BOMRef != "release@2024.1" {
+		t.Errorf("Unexpected subject: %+v", merged.Subject)
+	}
+
+	refs := make(map[string]compliance.Component)
+	for _, component := range merged.Components {
+		if _, ok := refs[component.BOMRef]; ok {
+			t.Errorf("Duplicate bom-ref %s", component.BOMRef)
+		}
+		refs[component.BOMRef] = component
+	}
+	// model, numpy 1.26.4, eval data, tokenizer, serving, scipy, numpy 1.24.0, tokenizers
+	if len(merged.Components) != 8 {
+		t.Errorf("Expected 8 components, got %d", len(merged.Components))
+	}
+	numpy := refs["pkg:pypi/numpy@1.26.4"]
+	if numpy.Hashes["sha256"] != "def" {
+		t.Errorf("Expected the numpy hashes to be merged, got %v", numpy.Hashes)
+	}
+	if data := refs["data/eval.csv"]; data.License != "CC-BY-4.0" {
+		t.Errorf("Expected the dataset to be merged by hash, got %+v", data)
+	}
+	if tokenizers := refs["serving@2.0.0:tokenizer"]; tokenizers.Name != "tokenizers" {
+		t.Errorf("Expected the colliding bom-ref to be renamed, got %v", refs)
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, dependency := range merged.Dependencies {
+		dependsOn[dependency.Ref] = dependency.DependsOn
+	}
+	if got := strings.Join(dependsOn["release@2024.1"], ","); got != "model@1.0.0,serving@2.0.0,data/eval.csv" {
+		t.Errorf("Unexpected release dependencies: %s", got)
+	}
+	if got := strings.Join(dependsOn["serving@2.0.0"], ","); got != "pkg:pypi/scipy@1.11.0,serving@2.0.0:tokenizer" {
+		t.Errorf("Unexpected serving dependencies: %s", got)
+	}
+	if got := strings.Join(dependsOn["pkg:pypi/scipy@1.11.0"], ","); got != "pkg:pypi/numpy@1.26.4" {
+		t.Errorf("Unexpected scipy dependencies: %s", got)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Name != "numpy" {
+		t.Fatalf("Expected a numpy conflict, got %v", conflicts)
+	}
+	if got := conflicts[0].String(); got != "numpy: 1.24.0 (serving@2.0.0), 1.26.4 (model@1.0.0, serving@2.0.0)" {
+		t.Errorf("Unexpected conflict: %s", got)
+	}
+
+	// The merged SBOM can be written in every format
+	for _, format := range compliance.Formats {
+		if _, err := compliance.EncodeSBOM(merged, format); err != nil {
+			t.Errorf("EncodeSBOM(%s) failed: %v", format, err)
+		}
+	}
+}
+
+func TestMergeSBOMsNestedComponents(t *testing.T) {
+	// Both SBOMs include the same bundle; its nested config collides with the
+	// model's own config and is renamed when the bundle is first merged
+	bundle := func() compliance.Component {
+		return compliance.Component{
+			BOMRef: "bundle", Name: "bundle", Version: "1.0", PURL: "pkg:generic/bundle@1.0",
+			Components: []compliance.Component{
+				{BOMRef: "config", Type: compliance.ComponentFile, Name: "bundle/config.json", Hashes: map[string]string{"sha256": "nested"}},
+			},
+		}
+	}
+	model := &compliance.SBOM{
+		Name: "model", Version: "1.0.0",
+		Subject: compliance.Component{BOMRef: "model@1.0.0", Type: compliance.ComponentMachineLearningModel, Name: "model", Version: "1.0.0"},
+		Components: []compliance.Component{
+			{BOMRef: "config", Type: compliance.ComponentFile, Name: "config.json", Hashes: map[string]string{"sha256": "top"}},
+			bundle(),
+		},
+		Dependencies: []compliance.Dependency{
+			{Ref: "model@1.0.0", DependsOn: []string{"config", "bundle"}},
+		},
+	}
+	serving := &compliance.SBOM{
+		Name: "serving", Version: "2.0.0",
+		Subject:    compliance.Component{BOMRef: "serving@2.0.0", Type: compliance.ComponentFile, Name: "serving", Version: "2.0.0"},
+		Components: []compliance.Component{bundle()},
+		Dependencies: []compliance.Dependency{
+			{Ref: "serving@2.0.0", DependsOn: []string{"config"}},
+		},
+	}
+
+	merged, _, err := compliance.MergeSBOMs("release", "2024.1", []*compliance.SBOM{model, serving})
+	if err != nil {
+		t.Fatalf("MergeSBOMs failed: %v", err)
+	}
+
+	var nested string
+	for _, component := range merged.Components {
+		if component.Name == "bundle" {
+			if len(component.Components) != 1 {
+				t.Fatalf("Expected the bundle to keep one nested component, got %+v", component.Components)
+			}
+			nested = component.Components[0].BOMRef
+		}
+	}
+	if nested == "" || nested == "config" {
+		t.Fatalf("Expected the nested config to be renamed, got %q", nested)
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, dependency := range merged.Dependencies {
+		dependsOn[dependency.Ref] = dependency.DependsOn
+	}
+	if got := strings.Join(dependsOn["serving@2.0.0"], ","); got != nested {
+		t.Errorf("Expected serving to depend on the bundle's config %s, got %s", nested, got)
+	}
+}
+
+func TestImportSBOM(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "vendor.bin")
+	if err := os.WriteFile(artifactPath, []byte("vendor binary"), 0644); err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "3.2.0", "license": "MIT"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+
+	// Invalid documents are rejected with every violation
+	invalid := `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.9",
+  "components": [
+    {"bom-ref": "a", "type": "gadget", "name": "a", "purl": "npm/a",
+     "components": [{"bom-ref": "a", "type": "library"}]},
+    {"type": "library", "name": "b", "licenses": [{"expression": "MIT AND"}]}
+  ],
+  "dependencies": [{"ref": "a", "dependsOn": ["missing"]}]
+}`
+	invalidPath := filepath.Join(tempDir, "invalid.cdx.json")
+	if err := os.WriteFile(invalidPath, []byte(invalid), 0644); err != nil {
+		t.Fatalf("Failed to write SBOM: %v", err)
+	}
+	_, err = compliance.ImportSBOM(artifactPath, invalidPath)
+	var validationErr *compliance.SBOMValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected an SBOMValidationError, got %v", err)
+	}
+	paths := make(map[string]bool)
+	for _, violation := range validationErr.Violations {
+		paths[violation.Path] = true
+	}
+	for _, path := range []string{
+		"/specVersion", "/components/0/type", "/components/0/purl",
+		"/components/0/components/0/bom-ref", "/components/0/components/0/name",
+		"/components/1/licenses/0/expression", "/dependencies/0/dependsOn/0",
+	} {
+		if !paths[path] {
+			t.Errorf("Expected a violation at %s, got %v", path, validationErr.Violations)
+		}
+	}
+	if _, _, err := compliance.ValidateSBOM([]byte(`{"components": []}`)); err == nil {
+		t.Errorf("Expected TraceSync legacy SBOMs to be refused")
+	}
+
+	// A vendor CycloneDX SBOM replaces the generated one
+	vendor := `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "version": 1,
+  "metadata": {"component": {"bom-ref": "app", "type": "application", "name": "vendor-app", "version": "3.2.0"}},
+  "components": [
+    {"bom-ref": "requests", "type": "library", "name": "requests", "version": "2.31.0",
+     "purl": "pkg:pypi/requests@2.31.0", "licenses": [{"license": {"id": "Apache-2.0"}}]},
+    {"bom-ref": "leftpad", "type": "library", "name": "leftpad"}
+  ],
+  "dependencies": [{"ref": "app", "dependsOn": ["requests", "leftpad"]}]
+}`
+	vendorPath := filepath.Join(tempDir, "vendor.cdx.json")
+	if err := os.WriteFile(vendorPath, []byte(vendor), 0644); err != nil {
+		t.Fatalf("Failed to write SBOM: %v", err)
+	}
+	importedPath, err := compliance.ImportSBOM(artifactPath, vendorPath)
+	if err != nil {
+		t.Fatalf("ImportSBOM failed: %v", err)
+	}
+	if importedPath != compliance.ImportedSBOMPath(artifactPath, "vendor.bin", compliance.FormatCycloneDX) {
+		t.Errorf("Unexpected imported SBOM path %s", importedPath)
+	}
+	metadata, err := artifactmanager.GetArtifactMetadata(artifactPath)
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if metadata.SBOM == nil || metadata.SBOM.Source != vendorPath || metadata.SBOM.Format != string(compliance.FormatCycloneDX) {
+		t.Fatalf("Expected the import to be recorded, got %+v", metadata.SBOM)
+	}
+
+	// The compliance check evaluates the vendor's components
+	report, err := compliance.CheckCompliance(artifactPath)
+	if err != nil {
+		t.Fatalf("CheckCompliance failed: %v", err)
+	}
+	if len(report.Errors()) != 1 || report.Errors()[0].RuleID != compliance.RulePinnedDependencies || report.Errors()[0].Component != "leftpad" {
+		t.Errorf("Expected only the unversioned vendor component to fail, got %v", report.Issues)
+	}
+
+	sbomPath, err := compliance.GenerateSBOMAs(artifactPath, compliance.FormatSPDXJSON)
+	if err != nil {
+		t.Fatalf("GenerateSBOMAs failed: %v", err)
+	}
+	sbom, err := compliance.LoadSBOM(sbomPath)
+	if err != nil {
+		t.Fatalf("LoadSBOM failed: %v", err)
+	}
+	if sbom.Subject.Name != "vendor-app" || sbom.Subject.License != "MIT" {
+		t.Errorf("Expected the vendor subject described as the artifact, got %+v", sbom.Subject)
+	}
+	if sbom.Subject.Hashes["sha256"] != metadata.Digests["sha256"] {
+		t.Errorf("Expected the artifact digest on the subject, got %v", sbom.Subject.Hashes)
+	}
+	if len(sbom.Components) != 2 || sbom.Components[0].PURL != "pkg:pypi/requests@2.31.0" {
+		t.Errorf("Expected the vendor components, got %+v", sbom.Components)
+	}
+
+	// The imported copy must not change
+	if err := os.WriteFile(importedPath, []byte(strings.Replace(vendor, "2.31.0", "2.32.0", -1)), 0644); err != nil {
+		t.Fatalf("Failed to modify SBOM: %v", err)
+	}
+	if _, err := compliance.CheckCompliance(artifactPath); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("Expected a modified imported SBOM to be refused, got %v", err)
+	}
+
+	// An SPDX SBOM of other content is refused, one of this artifact replaces
+	// the CycloneDX import
+	spdx := `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "vendor-app",
+  "documentNamespace": "https://vendor.example/spdx/vendor-app-3.2.0",
+  "creationInfo": {"created": "2024-05-01T10:00:00Z", "creators": ["Organization: Vendor"]},
+  "packages": [
+    {"SPDXID": "SPDXRef-app", "name": "vendor-app", "versionInfo": "3.2.0", "downloadLocation": "NOASSERTION",
+     "checksums": [{"algorithm": "SHA256", "checksumValue": "DIGEST"}]},
+    {"SPDXID": "SPDXRef-zlib", "name": "zlib", "versionInfo": "1.3", "downloadLocation": "NOASSERTION",
+     "licenseDeclared": "Zlib", "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:generic/zlib@1.3"}]}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-zlib"}
+  ]
+}`
+	spdxPath := filepath.Join(tempDir, "vendor.spdx.json")
+	otherDigest := strings.Repeat("0", 64)
+	if err := os.WriteFile(spdxPath, []byte(strings.Replace(spdx, "DIGEST", otherDigest, 1)), 0644); err != nil {
+		t.Fatalf("Failed to write SBOM: %v", err)
+	}
+	if _, err := compliance.ImportSBOM(artifactPath, spdxPath); err == nil {
+		t.Errorf("Expected an SBOM of different content to be refused")
+	}
+	if err := os.WriteFile(spdxPath, []byte(strings.Replace(spdx, "DIGEST", metadata.Digests["sha256"], 1)), 0644); err != nil {
+		t.Fatalf("Failed to write SBOM: %v", err)
+	}
+	if _, err := compliance.ImportSBOM(artifactPath, spdxPath); err != nil {
+		t.Fatalf("ImportSBOM failed: %v", err)
+	}
+	if _, err := os.Stat(importedPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the previous imported SBOM to be removed")
+	}
+	report, err = compliance.CheckCompliance(artifactPath)
+	if err != nil {
+		t.Fatalf("CheckCompliance failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Errorf("Expected the SPDX SBOM to pass, got %v", report.Issues)
+	}
+}
+
+func TestValidateSBOMSpecVersions(t *testing.T) {
+	cycloneDX := func(specVersion, components string) string {
+		return `{"bomFormat": "CycloneDX", "specVersion": "` + specVersion + `", "version": 1, "components": [` + components + `]}`
+	}
+	model := `{"type": "machine-learning-model", "name": "model", "version": "1"}`
+	crypto := `{"type": "cryptographic-asset", "name": "aes", "version": "1"}`
+	spdx22 := `{
+  "spdxVersion": "SPDX-2.2",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "app",
+  "documentNamespace": "https://example.com/spdx/app",
+  "creationInfo": {"created": "2024-05-01T10:00:00Z", "creators": ["Tool: test"]},
+  "documentDescribes": ["SPDXRef-app"],
+  "packages": [{"SPDXID": "SPDXRef-app", "name": "app", "downloadLocation": "NOASSERTION", "primaryPackagePurpose": "LIBRARY"}]
+}`
+
+	tests := []struct {
+		name     string
+		document string
+		// expected lists the paths of the expected violations
+		expected []string
+	}{
+		{"model before 1.5", cycloneDX("1.4", model), []string{"/components/0/type"}},
+		{"model in 1.5", cycloneDX("1.5", model), nil},
+		{"cryptographic asset before 1.6", cycloneDX("1.5", crypto), []string{"/components/0/type"}},
+		{"cryptographic asset in 1.6", cycloneDX("1.6", crypto), nil},
+		{"component version before 1.4", cycloneDX("1.3", `{"type": "library", "name": "a"}`), []string{"/components/0/version"}},
+		{"component version in 1.4", cycloneDX("1.4", `{"type": "library", "name": "a"}`), nil},
+		{"document version before 1.5", `{"bomFormat": "CycloneDX", "specVersion": "1.4"}`, []string{"/version"}},
+		{"document version in 1.5", `{"bomFormat": "CycloneDX", "specVersion": "1.5"}`, nil},
+		{"SPDX 2.2 package fields", spdx22, []string{
+			"/packages/0/copyrightText", "/packages/0/licenseConcluded", "/packages/0/licenseDeclared", "/packages/0/primaryPackagePurpose",
+		}},
+		{"SPDX 2.3 package fields", strings.Replace(spdx22, "SPDX-2.2", "SPDX-2.3", 1), nil},
+	}
+	for _, test := range tests {
+		_, violations, err := compliance.ValidateSBOM([]byte(test.document))
+		if err != nil {
+			t.Errorf("%s: ValidateSBOM failed: %v", test.name, err)
+			continue
+		}
+		var paths []string
+		for _, violation := range violations {
+			paths = append(paths, violation.Path)
+		}
+		if strings.Join(paths, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected violations at %v, got %v", test.name, test.expected, violations)
+		}
+	}
+}
+
+func TestCheckSBOMCompliance(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"version": "1.0.0"}); err != nil {
+		t.Fatalf("Failed to tag artifact: %v", err)
+	}
+	sbomPath, err := compliance.GenerateSBOMAs(artifactPath, compliance.FormatCycloneDX)
+	if err != nil {
+		t.Fatalf("GenerateSBOMAs failed: %v", err)
+	}
+
+	// The given SBOM is checked as it is, without building it again
+	sbom, err := compliance.LoadSBOM(sbomPath)
+	if err != nil {
+		t.Fatalf("LoadSBOM failed: %v", err)
+	}
+	sbom.Components = append(sbom.Components, compliance.Component{
+		Type:       compliance.ComponentLibrary,
+		Name:       "leftpad",
+		Properties: map[string]string{"tracesync:unpinned": "*"},
+	})
+	report, err := compliance.CheckSBOMCompliance(artifactPath, sbom, sbomPath)
+	if err != nil {
+		t.Fatalf("CheckSBOMCompliance failed: %v", err)
+	}
+	if len(report.Errors()) != 1 || report.Errors()[0].Component != "leftpad" {
+		t.Errorf("Expected the given SBOM to be checked, got %v", report.Issues)
+	}
+
+	// Without one, the written SBOM is checked
+	report, err = compliance.CheckCompliance(artifactPath)
+	if err != nil {
+		t.Fatalf("CheckCompliance failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Errorf("Expected the written SBOM to pass, got %v", report.Issues)
+	}
+}
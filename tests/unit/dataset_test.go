@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/dataset"
+)
+
+func checkStatuses(report *dataset.Report) map[string]string {
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Check] = check.Status
+	}
+	return statuses
+}
+
+func TestValidateCSVDataset(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	datasetPath := filepath.Join(tempDir, "train.csv")
+	content := "id,label,score,created_at\n" +
+		"1,cat,0.9,2026-01-01\n" +
+		"2,,0.8,2026-01-02\n" +
+		"2,dog,high,2026-01-03\n"
+	if err := os.WriteFile(datasetPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test dataset: %v", err)
+	}
+
+	maxNullRate := 0.1
+	minRows := int64(10)
+	spec := artifactmanager.DatasetSpec{
+		Columns: []artifactmanager.ColumnSpec{
+			{Name: "id", Type: "integer"},
+			{Name: "label", Type: "string", MaxNullRate: &maxNullRate},
+			{Name: "score", Type: "number"},
+			{Name: "created_at", Type: "date"},
+			{Name: "split"},
+		},
+		PrimaryKey: []string{"id"},
+		MinRows:    &minRows,
+	}
+
+	report, err := dataset.Validate(datasetPath, spec)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if report.Rows != 3 || report.Format != "csv" {
+		t.Errorf("Expected 3 csv rows, got %d %s rows", report.Rows, report.Format)
+	}
+
+	expected := map[string]string{
+		"parse":           dataset.StatusPass,
+		"columns":         dataset.StatusFail,
+		"type:id":         dataset.StatusPass,
+		"type:score":      dataset.StatusFail,
+		"type:created_at": dataset.StatusPass,
+		"null_rate:label": dataset.StatusFail,
+		"primary_key":     dataset.StatusFail,
+		"row_count":       dataset.StatusFail,
+	}
+	statuses := checkStatuses(report)
+	for check, status := range expected {
+		if statuses[check] != status {
+			t.Errorf("Expected check %s to be %s, got %q", check, status, statuses[check])
+		}
+	}
+	if report.Passed() {
+		t.Errorf("Expected report to fail")
+	}
+}
+
+func TestValidateJSONLDataset(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	datasetPath := filepath.Join(tempDir, "eval.jsonl")
+	content := `{"id": 1, "text": "hello", "label": true}
+{"id": 2, "text": "world", "label": false}
+
+{"id": 3, "text": null, "label": true}
+`
+	if err := os.WriteFile(datasetPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test dataset: %v", err)
+	}
+
+	maxNullRate := 0.5
+	spec := artifactmanager.DatasetSpec{
+		Columns: []artifactmanager.ColumnSpec{
+			{Name: "id", Type: "integer"},
+			{Name: "text", Type: "string", MaxNullRate: &maxNullRate},
+			{Name: "label", Type: "boolean"},
+		},
+		PrimaryKey: []string{"id"},
+	}
+	report, err := dataset.Validate(datasetPath, spec)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Errorf("Expected report to pass, got %+v", report.Checks)
+	}
+
+	// A malformed line fails the parse check
+	if err := os.WriteFile(datasetPath, []byte(content+"{not json}\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test dataset: %v", err)
+	}
+	report, err = dataset.Validate(datasetPath, spec)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if statuses := checkStatuses(report); statuses["parse"] != dataset.StatusFail {
+		t.Errorf("Expected parse check to fail, got %+v", report.Checks)
+	}
+}
+
+// featureRow is a row of the parquet dataset used in tests.
+type featureRow struct {
+	ID    int64   `parquet:"id"`
+	Label *string `parquet:"label,optional"`
+}
+
+func TestValidateParquetDataset(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// 4 rows where label has 2 nulls and id 2 appears twice
+	cat, dog := "cat", "dog"
+	rows := []featureRow{{ID: 1, Label: &cat}, {ID: 2}, {ID: 3, Label: &dog}, {ID: 2}}
+	datasetPath := filepath.Join(tempDir, "features.parquet")
+	writeParquet := func(rows []featureRow) {
+		var file bytes.Buffer
+		if err := parquet.Write(&file, rows); err != nil {
+			t.Fatalf("Failed to encode test dataset: %v", err)
+		}
+		if err := os.WriteFile(datasetPath, file.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to create test dataset: %v", err)
+		}
+	}
+	writeParquet(rows)
+
+	maxNullRate := 0.25
+	spec := artifactmanager.DatasetSpec{
+		Columns: []artifactmanager.ColumnSpec{
+			{Name: "id", Type: "integer"},
+			{Name: "label", Type: "string", MaxNullRate: &maxNullRate},
+		},
+		PrimaryKey: []string{"id"},
+	}
+	report, err := dataset.Validate(datasetPath, spec)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if report.Rows != 4 {
+		t.Errorf("Expected 4 rows, got %d", report.Rows)
+	}
+
+	expected := map[string]string{
+		"parse":           dataset.StatusPass,
+		"columns":         dataset.StatusPass,
+		"type:id":         dataset.StatusPass,
+		"type:label":      dataset.StatusPass,
+		"null_rate:label": dataset.StatusFail,
+		"primary_key":     dataset.StatusFail,
+	}
+	statuses := checkStatuses(report)
+	for check, status := range expected {
+		if statuses[check] != status {
+			t.Errorf("Expected check %s to be %s, got %q", check, status, statuses[check])
+		}
+	}
+
+	// Unique keys pass
+	rows[3].ID = 4
+	writeParquet(rows)
+	report, err = dataset.Validate(datasetPath, spec)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if statuses := checkStatuses(report); statuses["primary_key"] != dataset.StatusPass {
+		t.Errorf("Expected primary key check to pass, got %+v", report.Checks)
+	}
+}
@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MChorfa/TraceSync/internal/policy"
+)
+
+func TestPolicyExpressions(t *testing.T) {
+	vars := map[string]any{
+		"descriptor": map[string]any{
+			"name":       "model",
+			"version":    "1.2.0",
+			"size":       json.Number("42"),
+			"created_at": "2024-05-01T10:00:00Z",
+			"tags":       map[string]string{"owner": "ml-team"},
+		},
+		"sbom": map[string]any{
+			"components": []any{
+				map[string]any{"name": "numpy", "license": "BSD-3-Clause", "purl": "pkg:pypi/numpy@1.26.4"},
+				map[string]any{"name": "left-pad", "license": "", "purl": "pkg:npm/left-pad@1.3.0"},
+			},
+		},
+		"environment": "production",
+	}
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{`descriptor.name == "model"`, true},
+		{`descriptor.size > 40 && descriptor.size * 2 == 84`, true},
+		{`double(descriptor.size) + 0.5`, 42.5},
+		{`has(descriptor.tags.owner) && !has(descriptor.tags.team)`, true},
+		{`descriptor.tags["owner"].startsWith("ml-")`, true},
+		{`"owner" in descriptor.tags`, true},
+		{`size(sbom.components)`, int64(2)},
+		{`sbom.components.all(c, c.license != "")`, false},
+		{`sbom.components.exists_one(c, c.purl.startsWith("pkg:npm/"))`, true},
+		{`sbom.components.filter(c, c.license == "").map(c, c.name) == ["left-pad"]`, true},
+		{`environment in ["production", "staging"] ? "strict" : "lenient"`, "strict"},
+		{`timestamp("2024-06-01T00:00:00Z") - timestamp(descriptor.created_at) < duration("744h")`, true},
+		{`descriptor.version.matches("^[0-9]+\\.[0-9]+\\.[0-9]+$")`, true},
+		{`descriptor.tags.owner.upperAscii().split("-")[0]`, "ML"},
+		// A decided && or || absorbs an error on the other side
+		{`false && descriptor.missing`, false},
+		{`descriptor.missing || true`, true},
+	}
+	for _, test := range tests {
+		expression, err := policy.Compile(test.expr)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", test.expr, err)
+			continue
+		}
+		got, err := expression.Eval(vars)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %v", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Eval(%q) = %v (%T), want %v", test.expr, got, got, test.want)
+		}
+	}
+
+	for _, expr := range []string{`descriptor.missing`, `1 / 0`, `descriptor.name + 1`, `sbom.components[5]`} {
+		expression, err := policy.Compile(expr)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", expr, err)
+			continue
+		}
+		if _, err := expression.Eval(vars); err == nil {
+			t.Errorf("Expected Eval(%q) to fail", expr)
+		}
+	}
+
+	// Syntax errors, unknown variables and functions and mismatched types are
+	// found when compiling
+	for _, expr := range []string{``, `descriptor &&`, `has(descriptor)`, `[1, 2`, `"unterminated`, `"a" + 1`, `unknown(1)`, `owner == "x"`} {
+		if _, err := policy.Compile(expr); err == nil {
+			t.Errorf("Expected Compile(%q) to fail", expr)
+		}
+	}
+
+	// Timestamps and durations can be passed in directly
+	expression, err := policy.Compile(`timestamp("2024-05-01T10:00:00Z") - descriptor.built < duration("1h")`)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	built := time.Date(2024, 5, 1, 9, 59, 0, 0, time.UTC)
+	if got, err := expression.Eval(map[string]any{"descriptor": map[string]any{"built": built}}); err != nil || got != true {
+		t.Errorf("Expected a recent build, got %v, %v", got, err)
+	}
+}
+
+func TestPolicyLoadDir(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	rules := `rules:
+  - id: owner-tag
+    description: Artifacts must name an owning team
+    severity: warning
+    message: Artifact has no owner tag
+    expr: has(descriptor.tags.owner)
+  - id: artifact-version
+    message: Production artifacts need a release version
+    expr: 'environment != "production" || !descriptor.version.contains("-")'
+  - id: sbom-present
+    message: SBOM file is missing
+    expr: sbom.path != ""
+    enabled: false
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "rules.yaml"), []byte(rules), 0644); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("not a policy"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	set, err := policy.LoadDir(tempDir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	var ids []string
+	for _, rule := range set.Rules() {
+		ids = append(ids, rule.ID)
+	}
+	if got := strings.Join(ids, ","); got != "artifact-name,artifact-version,sbom-present,owner-tag" {
+		t.Errorf("Unexpected rules: %s", got)
+	}
+
+	input := map[string]any{
+		"descriptor":  map[string]any{"name": "model", "version": "1.0.0-rc.1", "tags": map[string]any{}},
+		"sbom":        map[string]any{"path": ""},
+		"environment": "production",
+	}
+	violations := set.Evaluate(input)
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %v", violations)
+	}
+	if violations[0].RuleID != "artifact-version" || violations[0].Severity != policy.SeverityError ||
+		violations[0].Message != "Production artifacts need a release version" {
+		t.Errorf("Unexpected violation: %+v", violations[0])
+	}
+	if violations[1].RuleID != "owner-tag" || violations[1].Severity != policy.SeverityWarning {
+		t.Errorf("Unexpected violation: %+v", violations[1])
+	}
+
+	// Rules that cannot be evaluated are violated
+	input["descriptor"] = map[string]any{"version": "1.0.0"}
+	violations = set.Evaluate(input)
+	if len(violations) == 0 || violations[0].RuleID != "artifact-name" ||
+		!strings.Contains(violations[0].Message, "could not be evaluated") {
+		t.Errorf("Expected the missing name to be reported, got %v", violations)
+	}
+
+	invalid := map[string]string{
+		"duplicate.yaml": "rules:\n  - {id: a, message: m, expr: 'true'}\n  - {id: a, message: m, expr: 'true'}\n",
+		"severity.yaml":  "rules:\n  - {id: a, severity: fatal, message: m, expr: 'true'}\n",
+		"syntax.yaml":    "rules:\n  - {id: a, message: m, expr: 'descriptor.'}\n",
+		"message.yaml":   "rules:\n  - {id: a, expr: 'true'}\n",
+		"result.yaml":    "rules:\n  - {id: a, message: m, expr: 'size(descriptor)'}\n",
+	}
+	for name, content := range invalid {
+		dir := filepath.Join(tempDir, strings.TrimSuffix(name, ".yaml"))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write policy: %v", err)
+		}
+		if _, err := policy.LoadDir(dir); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
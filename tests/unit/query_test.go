@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/MChorfa/TraceSync/internal/query"
+)
+
+func TestQueryMatch(t *testing.T) {
+	fields := map[string]string{
+		"name":       "resnet50",
+		"version":    "1.2.0",
+		"created_at": "2026-03-01T10:00:00Z",
+		"size":       "2048",
+		"tags.owner": "vision",
+		"tags.stage": "production",
+		"tags.data":  "imagenet v2",
+	}
+
+	testCases := []struct {
+		expression string
+		expected   bool
+	}{
+		{"owner=vision AND stage!=archived AND created_at>2026-01-01", true},
+		{"tags.owner=vision", true},
+		{"owner=nlp OR stage=production", true},
+		{"NOT (owner=vision)", false},
+		{"created_at<2026-01-01", false},
+		{"size>=1024 and size<4096", true},
+		{"name~resnet*", true},
+		{`data="imagenet v2"`, true},
+		{"team=research", false},
+		{"team!=research", true},
+		{"version<1.10.0", true},
+		{"version>1.2.0-rc.1 AND version<=1.2.0", true},
+		{"version>1.9.0", false},
+	}
+
+	for _, tc := range testCases {
+		q, err := query.Parse(tc.expression)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tc.expression, err)
+			continue
+		}
+		if result := q.Match(fields); result != tc.expected {
+			t.Errorf("Match(%q) = %v; want %v", tc.expression, result, tc.expected)
+		}
+	}
+}
+
+func TestQueryParseErrors(t *testing.T) {
+	for _, expression := range []string{"", "owner", "owner=", "(owner=vision", "owner=vision AND", `owner="vision`} {
+		if _, err := query.Parse(expression); err == nil {
+			t.Errorf("Expected Parse(%q) to fail, but it succeeded", expression)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/MChorfa/TraceSync/internal/registry"
+)
+
+func TestRegistryPutGetDelete(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	reg, err := registry.Open(filepath.Join(tempDir, "registry.json"))
+	if err != nil {
+		t.Fatalf("Failed to open registry: %v", err)
+	}
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := reg.Put(registry.Entry{Path: artifactPath, Name: "model.bin", Version: "1.0.0", Status: "tagged"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	entry, err := reg.Get(artifactPath)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if entry.Name != "model.bin" || entry.Version != "1.0.0" || entry.CreatedAt.IsZero() {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	entries, err := reg.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != artifactPath {
+		t.Errorf("Expected one entry for %s, got %+v", artifactPath, entries)
+	}
+
+	if err := reg.Delete(artifactPath); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := reg.Get(artifactPath); !errors.Is(err, registry.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got: %v", err)
+	}
+}
+
+func TestRegistryConcurrentRecord(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	registryPath := filepath.Join(tempDir, "registry.json")
+	const writers = 20
+
+	// Each writer opens its own handle, as separate CLI invocations would
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reg, err := registry.Open(registryPath)
+			if err != nil {
+				t.Errorf("Failed to open registry: %v", err)
+				return
+			}
+			artifactPath := filepath.Join(tempDir, fmt.Sprintf("artifact-%d", i))
+			if err := reg.Record(artifactPath, registry.Event{Action: "tag", Status: "tagged"}, nil); err != nil {
+				t.Errorf("Record failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reg, err := registry.Open(registryPath)
+	if err != nil {
+		t.Fatalf("Failed to open registry: %v", err)
+	}
+	entries, err := reg.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != writers {
+		t.Errorf("Expected %d entries, got %d", writers, len(entries))
+	}
+}
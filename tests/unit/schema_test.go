@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MChorfa/TraceSync/internal/artifactmanager"
+	"github.com/MChorfa/TraceSync/internal/schema"
+)
+
+func TestDescriptorSchemaByType(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	schemaPath := filepath.Join(tempDir, "model.yaml")
+	modelSchema := `type: object
+properties:
+  tags:
+    type: object
+    required: [owner, license, intended_use]
+    properties:
+      license:
+        enum: [Apache-2.0, MIT]
+`
+	if err := os.WriteFile(schemaPath, []byte(modelSchema), 0644); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	registry, err := schema.LoadRegistry(map[string]string{"model": schemaPath})
+	if err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	artifactmanager.SetDescriptorSchemas(registry)
+	defer artifactmanager.SetDescriptorSchemas(schema.NewRegistry())
+
+	artifactPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(artifactPath, []byte("weights"), 0644); err != nil {
+		t.Fatalf("Failed to create test artifact: %v", err)
+	}
+	if err := artifactmanager.TagArtifact(artifactPath, map[string]string{"license": "GPL-3.0"}); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+
+	// Untyped artifacts only need the built-in fields
+	if err := artifactmanager.ValidateArtifact(artifactPath); err != nil {
+		t.Fatalf("Expected untyped artifact to validate, got %v", err)
+	}
+
+	// Models must satisfy the model schema; every violation is reported
+	if err := artifactmanager.SetArtifactFields(artifactPath, map[string]string{"type": "model"}); err != nil {
+		t.Fatalf("SetArtifactFields failed: %v", err)
+	}
+	err = artifactmanager.ValidateArtifact(artifactPath)
+	var schemaErr *artifactmanager.SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected a schema error, got %v", err)
+	}
+	expected := []schema.Violation{
+		{Path: "/tags/intended_use", Message: "is required"},
+		{Path: "/tags/license", Message: "must be one of Apache-2.0, MIT"},
+		{Path: "/tags/owner", Message: "is required"},
+	}
+	if len(schemaErr.Violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %v", len(expected), schemaErr.Violations)
+	}
+	for i, violation := range expected {
+		if schemaErr.Violations[i] != violation {
+			t.Errorf("Expected violation %v, got %v", violation, schemaErr.Violations[i])
+		}
+	}
+
+	tags := map[string]string{"owner": "vision", "license": "MIT", "intended_use": "research"}
+	if err := artifactmanager.TagArtifact(artifactPath, tags); err != nil {
+		t.Fatalf("TagArtifact failed: %v", err)
+	}
+	if err := artifactmanager.ValidateArtifact(artifactPath); err != nil {
+		t.Errorf("Expected model to validate, got %v", err)
+	}
+}
+
+func TestSchemaKeywords(t *testing.T) {
+	minLength := 3
+	minimum := 0.0
+	s := &schema.Schema{
+		Type:     "object",
+		Required: []string{"name", "size"},
+		Properties: map[string]*schema.Schema{
+			"name":       {Type: "string", MinLength: &minLength},
+			"size":       {Type: "integer", Minimum: &minimum},
+			"created_at": {Type: "string", Format: "date-time"},
+			"items":      {Type: "array", Items: &schema.Schema{Type: "string"}},
+		},
+	}
+	document := map[string]interface{}{
+		"name":       "ab",
+		"size":       -1.0,
+		"created_at": "yesterday",
+		"items":      []interface{}{"a", 2.0},
+	}
+	violations := s.Validate(document)
+	paths := make(map[string]bool)
+	for _, violation := range violations {
+		paths[violation.Path] = true
+	}
+	for _, path := range []string{"/name", "/size", "/created_at", "/items/1"} {
+		if !paths[path] {
+			t.Errorf("Expected a violation at %s, got %v", path, violations)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/MChorfa/TraceSync/internal/semver"
+)
+
+func TestSemverParseAndCompare(t *testing.T) {
+	for _, invalid := range []string{"1.0", "01.2.3", "1.2.3-", "1.2.3-01", "v1.2.3", "1.2.x"} {
+		if _, err := semver.Parse(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+
+	// Versions in increasing order of precedence, as listed on semver.org
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0+build.1"}
+	for i := 1; i < len(ordered); i++ {
+		a, err := semver.Parse(ordered[i-1])
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", ordered[i-1], err)
+		}
+		b, err := semver.Parse(ordered[i])
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", ordered[i], err)
+		}
+		if semver.Compare(a, b) != -1 || semver.Compare(b, a) != 1 {
+			t.Errorf("Expected %s < %s", a, b)
+		}
+	}
+}
+
+func TestSemverBump(t *testing.T) {
+	tests := []struct {
+		version, part, expected string
+	}{
+		{"1.2.3", semver.Major, "2.0.0"},
+		{"1.2.3", semver.Minor, "1.3.0"},
+		{"1.2.3+build.7", semver.Patch, "1.2.4"},
+		{"2.0.0-rc.1", semver.Major, "2.0.0"},
+		{"1.2.3-rc.1", semver.Minor, "1.3.0"},
+		{"1.2.3-rc.1", semver.Patch, "1.2.3"},
+	}
+	for _, test := range tests {
+		v, err := semver.Parse(test.version)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", test.version, err)
+		}
+		bumped, err := v.Bump(test.part)
+		if err != nil {
+			t.Fatalf("Bump failed: %v", err)
+		}
+		if bumped.String() != test.expected {
+			t.Errorf("Expected %s bumped by %s to be %s, got %s", test.version, test.part, test.expected, bumped)
+		}
+	}
+}
@@ -95,3 +95,34 @@ func TestSwitchBackend(t *testing.T) {
 		}
 	}
 }
+
+func TestEncryptBundleArtifact(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a mock bundle directory
+	bundlePath := filepath.Join(tempDir, "model")
+	if err := os.MkdirAll(filepath.Join(bundlePath, "shards"), 0755); err != nil {
+		t.Fatalf("Failed to create bundle directory: %v", err)
+	}
+	for _, name := range []string{"config.json", "shards/model-00001.bin"} {
+		if err := os.WriteFile(filepath.Join(bundlePath, filepath.FromSlash(name)), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create bundle file: %v", err)
+		}
+	}
+
+	encryptedPath, err := storagemanager.EncryptArtifact(bundlePath)
+	if err != nil {
+		t.Fatalf("EncryptArtifact failed: %v", err)
+	}
+	if encryptedPath != bundlePath+".tar.enc" {
+		t.Errorf("Expected encrypted bundle at %s, got %s", bundlePath+".tar.enc", encryptedPath)
+	}
+	if _, err := os.Stat(encryptedPath); os.IsNotExist(err) {
+		t.Errorf("Encrypted bundle was not created")
+	}
+}
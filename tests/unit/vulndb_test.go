@@ -0,0 +1,233 @@
+package unit
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MChorfa/TraceSync/internal/vulndb"
+)
+
+// osvEntries are OSV entries covering the range types and version schemes
+// the database matches.
+var osvEntries = map[string]string{
+	"GHSA-j8r2-6x86-q33q.json": `{
+  "id": "GHSA-j8r2-6x86-q33q",
+  "modified": "2024-01-01T00:00:00Z",
+  "aliases": ["CVE-2023-32681"],
+  "summary": "Unintended leak of Proxy-Authorization header",
+  "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:C/C:H/I:N/A:N"}],
+  "affected": [{
+    "package": {"ecosystem": "PyPI", "name": "requests"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.3.0"}, {"fixed": "2.31.0"}]}]
+  }]
+}`,
+	"GO-2022-0493.json": `{
+  "id": "GO-2022-0493",
+  "modified": "2024-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "Go", "name": "golang.org/x/sys"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.0.0-20220412211240-33da011f77ad"}]}]
+  }],
+  "database_specific": {"severity": "MODERATE"}
+}`,
+	"DSA-5000-1.json": `{
+  "id": "DSA-5000-1",
+  "modified": "2024-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "Debian:12", "name": "openssl"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.11-1~deb12u2"}]}]
+  }],
+  "severity": [{"type": "CVSS_V2", "score": "AV:N/AC:L/Au:N/C:P/I:P/A:P"}]
+}`,
+	"ALPINE-CVE-2024-0001.json": `{
+  "id": "ALPINE-CVE-2024-0001",
+  "modified": "2024-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "Alpine:v3.20", "name": "busybox"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"last_affected": "1.36.1-r29"}]}]
+  }]
+}`,
+	"ALSA-2024:0001.json": `{
+  "id": "ALSA-2024:0001",
+  "modified": "2024-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "AlmaLinux:9", "name": "curl"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "7.76.1-26.el9_3.3"}]}]
+  }],
+  "database_specific": {"severity": "Important"}
+}`,
+	"GHSA-withdrawn.json": `{
+  "id": "GHSA-withdrawn",
+  "modified": "2024-01-01T00:00:00Z",
+  "withdrawn": "2024-02-01T00:00:00Z",
+  "affected": [{"package": {"ecosystem": "PyPI", "name": "requests"}, "versions": ["2.20.0"]}]
+}`,
+}
+
+func writeOSVEntries(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create OSV directory: %v", err)
+	}
+	for name, content := range osvEntries {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestVulnerabilityDatabaseLookup(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	osvDir := filepath.Join(tempDir, "osv-dump")
+	writeOSVEntries(t, osvDir)
+
+	db := vulndb.Open(filepath.Join(tempDir, "vulndb"))
+	if db.Exists() {
+		t.Fatalf("Expected a new database to be empty")
+	}
+	result, err := db.Import(osvDir)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Added != len(osvEntries) || !db.Exists() {
+		t.Errorf("Unexpected import result %+v", result)
+	}
+	if result, err = db.Import(osvDir); err != nil || result.Unchanged != len(osvEntries) {
+		t.Errorf("Expected a repeated import to change nothing, got %+v (%v)", result, err)
+	}
+
+	tests := []struct {
+		pkg      vulndb.Package
+		expected string
+		severity vulndb.Severity
+	}{
+		{vulndb.Package{Ecosystem: "PyPI", Name: "Requests", Version: "2.19.1"}, "GHSA-j8r2-6x86-q33q", vulndb.SeverityMedium},
+		{vulndb.Package{Ecosystem: "PyPI", Name: "requests", Version: "2.31.0rc1"}, "GHSA-j8r2-6x86-q33q", vulndb.SeverityMedium},
+		{vulndb.Package{Ecosystem: "PyPI", Name: "requests", Version: "2.31.0"}, "", 0},
+		{vulndb.Package{Ecosystem: "PyPI", Name: "requests", Version: "2.2.1"}, "", 0},
+		{vulndb.Package{Ecosystem: "Go", Name: "golang.org/x/sys", Version: "0.0.0-20210630005230-0f9fa26af87c"}, "GO-2022-0493", vulndb.SeverityMedium},
+		{vulndb.Package{Ecosystem: "Go", Name: "golang.org/x/sys", Version: "0.1.0"}, "", 0},
+		{vulndb.Package{Ecosystem: "Debian:12", Name: "openssl", Version: "3.0.11-1~deb12u1"}, "DSA-5000-1", vulndb.SeverityHigh},
+		{vulndb.Package{Ecosystem: "Debian:12", Name: "openssl", Version: "3.0.11-1"}, "", 0},
+		{vulndb.Package{Ecosystem: "Debian:11", Name: "openssl", Version: "1.1.1n-0+deb11u5"}, "", 0},
+		{vulndb.Package{Ecosystem: "Alpine:3.20.3", Name: "busybox", Version: "1.36.1-r29"}, "ALPINE-CVE-2024-0001", vulndb.SeverityUnknown},
+		{vulndb.Package{Ecosystem: "Alpine:3.20.3", Name: "busybox", Version: "1.36.1-r30"}, "", 0},
+		{vulndb.Package{Ecosystem: "Alpine:3.2", Name: "busybox", Version: "1.36.1-r2"}, "", 0},
+		{vulndb.Package{Ecosystem: "AlmaLinux:9.3", Name: "curl", Version: "7.76.1-26.el9_3.2"}, "ALSA-2024:0001", vulndb.SeverityHigh},
+		{vulndb.Package{Ecosystem: "AlmaLinux:9.3", Name: "curl", Version: "7.76.1-26.el9_3.10"}, "", 0},
+	}
+	for _, test := range tests {
+		vulns, err := db.Lookup(test.pkg)
+		if err != nil {
+			t.Fatalf("Lookup(%+v) failed: %v", test.pkg, err)
+		}
+		if test.expected == "" {
+			if len(vulns) != 0 {
+				t.Errorf("Expected %s %s to be unaffected, got %s", test.pkg.Name, test.pkg.Version, vulns[0].ID)
+			}
+			continue
+		}
+		if len(vulns) != 1 || vulns[0].ID != test.expected {
+			t.Errorf("Expected %s %s to be affected by %s, got %d entries", test.pkg.Name, test.pkg.Version, test.expected, len(vulns))
+			continue
+		}
+		if severity, _ := vulns[0].Rating(test.pkg); severity != test.severity {
+			t.Errorf("Expected %s to be rated %s, got %s", test.expected, test.severity, severity)
+		}
+	}
+}
+
+func TestVulnerabilityDatabaseImportZip(t *testing.T) {
+	// Create a temporary directory for the test
+	tempDir, err := os.MkdirTemp("", "tracesync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	zipPath := filepath.Join(tempDir, "all.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	archive := zip.NewWriter(file)
+	entry, _ := archive.Create("GHSA-j8r2-6x86-q33q.json")
+	entry.Write([]byte(osvEntries["GHSA-j8r2-6x86-q33q.json"]))
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	file.Close()
+
+	db := vulndb.Open(filepath.Join(tempDir, "vulndb"))
+	if result, err := db.Import(zipPath); err != nil || result.Added != 1 {
+		t.Fatalf("Import failed: %+v (%v)", result, err)
+	}
+
+	// A newer modification replaces the stored entry
+	updated := filepath.Join(tempDir, "updated")
+	if err := os.MkdirAll(updated, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	newer := `{"id": "GHSA-j8r2-6x86-q33q", "modified": "2024-06-01T00:00:00Z", "affected": [
+  {"package": {"ecosystem": "PyPI", "name": "requests"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.3.0"}, {"fixed": "2.30.0"}]}]}]}`
+	if err := os.WriteFile(filepath.Join(updated, "entry.json"), []byte(newer), 0644); err != nil {
+		t.Fatalf("Failed to write entry: %v", err)
+	}
+	if result, err := db.Import(updated); err != nil || result.Updated != 1 {
+		t.Fatalf("Import failed: %+v (%v)", result, err)
+	}
+	vulns, err := db.Lookup(vulndb.Package{Ecosystem: "PyPI", Name: "requests", Version: "2.30.1"})
+	if err != nil || len(vulns) != 0 {
+		t.Errorf("Expected the updated range to apply, got %d entries (%v)", len(vulns), err)
+	}
+
+	if err := os.WriteFile(filepath.Join(updated, "broken.json"), []byte(`{"summary": "no id"}`), 0644); err != nil {
+		t.Fatalf("Failed to write entry: %v", err)
+	}
+	if _, err := db.Import(updated); err == nil {
+		t.Errorf("Expected an entry without id to be rejected")
+	}
+}
+
+func TestVulnerabilityRating(t *testing.T) {
+	tests := []struct {
+		vector   string
+		score    float64
+		severity vulndb.Severity
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, vulndb.SeverityCritical},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, vulndb.SeverityMedium},
+		{"CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:N/I:N/A:N", 0, vulndb.SeverityNone},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 7.5, vulndb.SeverityHigh},
+	}
+	pkg := vulndb.Package{Ecosystem: "npm", Name: "example", Version: "1.0.0"}
+	for _, test := range tests {
+		vuln := vulndb.Vulnerability{
+			ID:       "TEST-1",
+			Severity: []vulndb.SeverityInfo{{Type: "CVSS_V3", Score: test.vector}},
+			Affected: []vulndb.Affected{{Package: vulndb.Package{Ecosystem: "npm", Name: "example"}}},
+		}
+		if test.vector[0] == 'A' {
+			vuln.Severity[0].Type = "CVSS_V2"
+		}
+		severity, score := vuln.Rating(pkg)
+		if score != test.score || severity != test.severity {
+			t.Errorf("%s: expected %.1f (%s), got %.1f (%s)", test.vector, test.score, test.severity, score, severity)
+		}
+	}
+
+	if _, err := vulndb.ParseSeverity("severe"); err == nil {
+		t.Errorf("Expected an unknown severity to be rejected")
+	}
+	if severity, _ := vulndb.ParseSeverity("Moderate"); severity != vulndb.SeverityMedium {
+		t.Errorf("Expected moderate to be medium, got %s", severity)
+	}
+}